@@ -0,0 +1,389 @@
+// Package agenda collects the headlines of one or many parsed org documents into agenda views
+// similar to the ones of the Org mode agenda: day and week views, a global TODO list and a tags / property match view.
+package agenda
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/ihdavids/go-org/org"
+)
+
+// Agenda collects the headlines of Documents. All dates are interpreted as wall clock dates (as they are written in org timestamps).
+type Agenda struct {
+	Documents   []*org.Document
+	Today       time.Time // Today is the day overdue items and upcoming deadlines are shown on. Defaults to the current date.
+	WarningDays int       // WarningDays is the number of days a deadline is shown in advance if it does not specify a warning delay.
+}
+
+// Kind describes why an Item is part of an agenda view.
+type Kind string
+
+const (
+	Scheduled Kind = "scheduled" // the headline is scheduled for the day (or overdue)
+	Deadline  Kind = "deadline"  // the headline has a deadline on the day (or overdue / upcoming)
+	Timestamp Kind = "timestamp" // the headline title or body contains an active timestamp for the day
+	Entry     Kind = "entry"     // the headline is part of a list view (TODO list, match view)
+)
+
+// Item is a headline in an agenda view.
+type Item struct {
+	Kind      Kind
+	Time      time.Time     // Time is the date (and time of day for timestamps with time) of the occurrence. It is zero for Entry items.
+	Timestamp org.Timestamp // Timestamp is the timestamp the occurrence was created from - for repeaters Time is an expanded occurrence of it.
+	Days      int           // Days is the number of days the item is overdue (> 0) or the deadline is ahead (< 0).
+	Document  *org.Document
+	Section   *org.Section
+}
+
+// Day is the agenda of a single day.
+type Day struct {
+	Date  time.Time
+	Items []Item
+}
+
+// New returns an Agenda for documents with Today set to the current date.
+func New(documents ...*org.Document) *Agenda {
+	return &Agenda{
+		Documents:   documents,
+		Today:       time.Now(),
+		WarningDays: 14,
+	}
+}
+
+// Day returns the agenda for the day of t.
+func (a *Agenda) Day(t time.Time) Day {
+	return a.Range(t, t.AddDate(0, 0, 1))[0]
+}
+
+// Week returns the agendas for the 7 days of the week (starting on Monday) that contains t.
+func (a *Agenda) Week(t time.Time) []Day {
+	day := date(t)
+	start := day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	return a.Range(start, start.AddDate(0, 0, 7))
+}
+
+// Range returns the agendas for all days from the day of start up to (excluding) the day of end.
+// Repeating timestamps are expanded to all of their occurrences in the range.
+func (a *Agenda) Range(start, end time.Time) []Day {
+	days := []Day{}
+	for day := date(start); day.Before(date(end)); day = day.AddDate(0, 0, 1) {
+		days = append(days, Day{Date: day})
+	}
+	if len(days) == 0 {
+		return days
+	}
+	today := date(a.Today)
+	a.walk(func(d *org.Document, s *org.Section) {
+		h, done := s.Headline, d.IsDone(*s.Headline)
+		add := func(kind Kind, ts org.Timestamp, t time.Time, delta int) {
+			if i := daysBetween(days[0].Date, t); i >= 0 && i < len(days) {
+				days[i].Items = append(days[i].Items, Item{kind, t, ts, delta, d, s})
+			}
+		}
+		addPlanning := func(kind Kind, ts *org.Timestamp) {
+			if ts == nil || ts.Inactive {
+				return
+			}
+			delta := daysBetween(ts.Time, today)
+			for _, t := range occurrences(*ts, days[0].Date, date(end)) {
+				if done || delta <= 0 || !date(t).Equal(today) {
+					add(kind, *ts, t, 0)
+				}
+			}
+			if !done && delta > 0 {
+				add(kind, *ts, today, delta)
+			} else if !done && kind == Deadline && delta < 0 && -delta <= a.warningDays(*ts) {
+				add(kind, *ts, today, delta)
+			}
+		}
+		addPlanning(Scheduled, h.Planning.Scheduled())
+		addPlanning(Deadline, h.Planning.Deadline())
+		for _, ts := range timestamps(append(append([]org.Node{}, h.Title...), h.Children...)) {
+			if !ts.Inactive {
+				for _, t := range occurrences(ts, days[0].Date, date(end)) {
+					add(Timestamp, ts, t, 0)
+				}
+			}
+		}
+	})
+	for _, day := range days {
+		sortItems(day.Items)
+	}
+	return days
+}
+
+// Todos returns all headlines with a TODO status that is not done - i.e. the global TODO list.
+func (a *Agenda) Todos() []Item {
+	return a.Filter(func(d *org.Document, s *org.Section) bool {
+		return s.Headline.Status != "" && !d.IsDone(*s.Headline)
+	})
+}
+
+// Match returns all headlines that have all of tags and the given values for all of properties.
+// Tags are inherited (see org.Section.InheritedTags) - and so are the properties listed in
+// org.Configuration.InheritedProperties (see org.Section.InheritedProperty).
+func (a *Agenda) Match(tags []string, properties map[string]string) []Item {
+	return a.Filter(func(d *org.Document, s *org.Section) bool {
+		headlineTags := s.InheritedTags(d)
+		for _, tag := range tags {
+			if !contains(headlineTags, tag) {
+				return false
+			}
+		}
+		for k, v := range properties {
+			if value, ok := s.InheritedProperty(d, k); !ok || value != v {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// MatchQuery returns all headlines that match the Org mode tags / property match query (see org.ParseMatch).
+func (a *Agenda) MatchQuery(query string) ([]Item, error) {
+	m, err := org.ParseMatch(query)
+	if err != nil {
+		return nil, err
+	}
+	return a.Filter(m.Matches), nil
+}
+
+// Filter returns all headlines for which f returns true in document order.
+func (a *Agenda) Filter(f func(*org.Document, *org.Section) bool) []Item {
+	items := []Item{}
+	a.walk(func(d *org.Document, s *org.Section) {
+		if f(d, s) {
+			items = append(items, Item{Kind: Entry, Document: d, Section: s})
+		}
+	})
+	return items
+}
+
+// Headline returns the headline of the item.
+func (i Item) Headline() *org.Headline { return i.Section.Headline }
+
+// Title returns the title of the item as org mode text.
+func (i Item) Title() string { return strings.TrimSpace(org.String(i.Section.Headline.Title)) }
+
+// Tags returns the tags of the item including inherited tags (see org.Section.InheritedTags).
+func (i Item) Tags() []string { return i.Section.InheritedTags(i.Document) }
+
+// IsTimed returns true if the item occurs at a specific time of the day.
+func (i Item) IsTimed() bool { return i.Kind != Entry && !i.Timestamp.IsDate && i.Days == 0 }
+
+// Category returns the #+CATEGORY of the document the item belongs to - or the base name of its file.
+func (i Item) Category() string {
+	if c, ok := i.Document.BufferSettings["CATEGORY"]; ok && c != "" {
+		return c
+	}
+	return strings.TrimSuffix(filepath.Base(i.Document.Path), filepath.Ext(i.Document.Path))
+}
+
+// String returns the day formatted like an org mode agenda day.
+func (d Day) String() string {
+	var b strings.Builder
+	b.WriteString(d.Date.Format("Monday 2 January 2006") + "\n")
+	for _, item := range d.Items {
+		b.WriteString(item.String() + "\n")
+	}
+	return b.String()
+}
+
+// String returns the item formatted like a line of the org mode agenda.
+func (i Item) String() string {
+	when := ""
+	if i.IsTimed() {
+		when = i.Time.Format("15:04")
+		if end := i.Timestamp.EndTime; !end.IsZero() && !i.Timestamp.IsDateRange {
+			when += "-" + end.Format("15:04")
+		}
+	}
+	label := ""
+	switch {
+	case i.Kind == Scheduled && i.Days > 0:
+		label = fmt.Sprintf("Sched.%2dx:", i.Days)
+	case i.Kind == Scheduled:
+		label = "Scheduled:"
+	case i.Kind == Deadline && i.Days > 0:
+		label = fmt.Sprintf("%2d d. ago:", i.Days)
+	case i.Kind == Deadline && i.Days < 0:
+		label = fmt.Sprintf("In %3d d.:", -i.Days)
+	case i.Kind == Deadline:
+		label = "Deadline:"
+	}
+	h, title := i.Headline(), i.Title()
+	if h.Priority != "" {
+		title = "[#" + h.Priority + "] " + title
+	}
+	if h.Status != "" {
+		title = h.Status + " " + title
+	}
+	s := fmt.Sprintf("  %-12s%-12s%-11s %s", i.Category()+":", when, label, title)
+	if tags := i.Tags(); len(tags) != 0 {
+		s += " :" + strings.Join(tags, ":") + ":"
+	}
+	return strings.TrimRightFunc(s, unicode.IsSpace)
+}
+
+func (a *Agenda) walk(f func(*org.Document, *org.Section)) {
+	for _, d := range a.Documents {
+		var walk func(*org.Section)
+		walk = func(s *org.Section) {
+			if s.Headline != nil {
+				f(d, s)
+			}
+			for _, child := range s.Children {
+				walk(child)
+			}
+		}
+		walk(d.Outline.Section)
+	}
+}
+
+func (a *Agenda) warningDays(ts org.Timestamp) int {
+	if w := strings.TrimLeft(ts.Warning, "-"); w != "" {
+		n, _ := strconv.Atoi(w[:len(w)-1])
+		switch w[len(w)-1] {
+		case 'w':
+			return n * 7
+		case 'm':
+			return n * 30
+		case 'y':
+			return n * 365
+		case 'h':
+			return 0
+		}
+		return n
+	}
+	return a.WarningDays
+}
+
+// occurrences returns the (start) times of all occurrences of ts on the days in [start, end).
+// Repeaters are expanded and date ranges occur on every day they span.
+func occurrences(ts org.Timestamp, start, end time.Time) []time.Time {
+	times := []time.Time{}
+	last := date(ts.Time)
+	if ts.IsDateRange {
+		last = date(ts.EndTime)
+	}
+	span := daysBetween(ts.Time, last)
+	n, unit := parseRepeater(ts.Repeater)
+	for i, t := 0, ts.Time; date(t).Before(end); i, t = i+1, repeat(ts.Time, n, unit, i+1) {
+		if !date(t).AddDate(0, 0, span).Before(start) {
+			for day := 0; day <= span; day++ {
+				if occurrence := t.AddDate(0, 0, day); !date(occurrence).Before(start) && date(occurrence).Before(end) {
+					times = append(times, occurrence)
+				}
+			}
+		}
+		if n <= 0 {
+			break
+		}
+	}
+	return times
+}
+
+func parseRepeater(repeater string) (int, byte) {
+	repeater = strings.TrimLeft(repeater, ".+")
+	if len(repeater) < 2 {
+		return 0, 0
+	}
+	n, err := strconv.Atoi(repeater[:len(repeater)-1])
+	if err != nil {
+		return 0, 0
+	}
+	return n, repeater[len(repeater)-1]
+}
+
+func repeat(t time.Time, n int, unit byte, times int) time.Time {
+	switch unit {
+	case 'h':
+		return t.Add(time.Duration(n*times) * time.Hour)
+	case 'd':
+		return t.AddDate(0, 0, n*times)
+	case 'w':
+		return t.AddDate(0, 0, 7*n*times)
+	case 'm':
+		return t.AddDate(0, n*times, 0)
+	case 'y':
+		return t.AddDate(n*times, 0, 0)
+	}
+	return t
+}
+
+// timestamps returns the timestamps of nodes - without descending into child headlines, drawers and planning lines.
+func timestamps(nodes []org.Node) []org.Timestamp {
+	out := []org.Timestamp{}
+	for _, n := range nodes {
+		switch n := n.(type) {
+		case org.Timestamp:
+			out = append(out, n)
+		case org.Paragraph:
+			out = append(out, timestamps(n.Children)...)
+		case org.Emphasis:
+			out = append(out, timestamps(n.Content)...)
+		case org.RegularLink:
+			out = append(out, timestamps(n.Description)...)
+		case org.List:
+			out = append(out, timestamps(n.Items)...)
+		case org.ListItem:
+			out = append(out, timestamps(n.Children)...)
+		case org.DescriptiveListItem:
+			out = append(out, timestamps(append(append([]org.Node{}, n.Term...), n.Details...))...)
+		case org.Block:
+			out = append(out, timestamps(n.Children)...)
+		case org.NodeWithMeta:
+			out = append(out, timestamps([]org.Node{n.Node})...)
+		case org.NodeWithName:
+			out = append(out, timestamps([]org.Node{n.Node})...)
+		case org.FootnoteDefinition:
+			out = append(out, timestamps(n.Children)...)
+		case org.Table:
+			for _, row := range n.Rows {
+				for _, column := range row.Columns {
+					out = append(out, timestamps(column.Children)...)
+				}
+			}
+		}
+	}
+	return out
+}
+
+func sortItems(items []Item) {
+	priority := func(i Item) int {
+		return i.Document.Priorities().Rank(i.Headline().EffectivePriority(i.Document))
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if a.IsTimed() != b.IsTimed() {
+			return a.IsTimed()
+		} else if a.IsTimed() && !a.Time.Equal(b.Time) {
+			return a.Time.Before(b.Time)
+		}
+		return priority(a) < priority(b)
+	})
+}
+
+func date(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// daysBetween returns the number of days from the day of a to the day of b.
+func daysBetween(a, b time.Time) int {
+	return int(date(b).Sub(date(a)).Hours() / 24)
+}
+
+func contains(xs []string, x string) bool {
+	for _, y := range xs {
+		if x == y {
+			return true
+		}
+	}
+	return false
+}
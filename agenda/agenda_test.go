@@ -0,0 +1,118 @@
+package agenda
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ihdavids/go-org/org"
+)
+
+func testAgenda(t *testing.T) *Agenda {
+	documents := []*org.Document{}
+	for _, path := range []string{"testdata/work.org", "testdata/home.org"} {
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		documents = append(documents, org.New().Silent().Parse(f, path))
+	}
+	a := New(documents...)
+	a.Today = time.Date(2021, 3, 2, 12, 0, 0, 0, time.UTC)
+	return a
+}
+
+func TestWeek(t *testing.T) {
+	a, actual := testAgenda(t), ""
+	for _, day := range a.Week(a.Today) {
+		actual += day.String()
+	}
+	expected := `Monday 1 March 2021
+  work:       10:00-11:00 Scheduled:  TODO Weekly meeting :project:meeting:
+  work:                   Scheduled:  NEXT [#A] Write report :project:
+  home:                   Deadline:   TODO Pay rent :money:
+Tuesday 2 March 2021
+  home:       08:30                   Dentist at <2021-03-02 Tue 08:30>
+  work:                   Sched. 1x:  NEXT [#A] Write report :project:
+  work:                   Sched. 8x:  TODO Weekly meeting :project:meeting:
+  home:                   29 d. ago:  TODO Pay rent :money:
+  home:                   Sched. 3x:  TODO Water plants
+Wednesday 3 March 2021
+  work:                               Conference
+Thursday 4 March 2021
+  work:                               Conference
+Friday 5 March 2021
+  work:                   Deadline:   NEXT [#A] Write report :project:
+Saturday 6 March 2021
+Sunday 7 March 2021
+`
+	if actual != expected {
+		t.Errorf("got:\n%s\nexpected:\n%s", actual, expected)
+	}
+}
+
+func TestTodos(t *testing.T) {
+	titles := []string{}
+	for _, item := range testAgenda(t).Todos() {
+		titles = append(titles, item.Category()+": "+item.Title())
+	}
+	if actual, expected := strings.Join(titles, ", "), "work: Write report, work: Weekly meeting, home: Pay rent, home: Water plants"; actual != expected {
+		t.Errorf("got %q, expected %q", actual, expected)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		tags       []string
+		properties map[string]string
+		expected   string
+	}{
+		{[]string{"project"}, nil, "Project, Write report, Weekly meeting, Prepare slides, Old task"},
+		{[]string{"project", "meeting"}, nil, "Weekly meeting"},
+		{nil, map[string]string{"LOCATION": "Berlin"}, "Conference"},
+		{[]string{"money"}, map[string]string{"LOCATION": "Berlin"}, ""},
+	}
+	a := testAgenda(t)
+	for _, test := range tests {
+		titles := []string{}
+		for _, item := range a.Match(test.tags, test.properties) {
+			titles = append(titles, item.Title())
+		}
+		if actual := strings.Join(titles, ", "); actual != test.expected {
+			t.Errorf("%v %v: got %q, expected %q", test.tags, test.properties, actual, test.expected)
+		}
+	}
+}
+
+func TestRepeaterOccurrences(t *testing.T) {
+	a := testAgenda(t)
+	days := a.Range(time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC))
+	meetings := []string{}
+	for _, day := range days {
+		for _, item := range day.Items {
+			if item.Title() == "Weekly meeting" && item.Days == 0 {
+				meetings = append(meetings, item.Time.Format("2006-01-02 15:04"))
+			}
+		}
+	}
+	expected := "2021-03-01 10:00, 2021-03-08 10:00, 2021-03-15 10:00, 2021-03-22 10:00, 2021-03-29 10:00"
+	if actual := strings.Join(meetings, ", "); actual != expected {
+		t.Errorf("got %q, expected %q", actual, expected)
+	}
+}
+
+func TestMatchQuery(t *testing.T) {
+	items, err := testAgenda(t).MatchQuery(`project/!`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	titles := []string{}
+	for _, item := range items {
+		titles = append(titles, item.Title())
+	}
+	if actual, expected := strings.Join(titles, ", "), "Write report, Weekly meeting"; actual != expected {
+		t.Errorf("got %q, expected %q", actual, expected)
+	}
+}
@@ -1,11 +1,14 @@
 package main
 
 import (
+	"encoding/json"
+	"flag"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
 	"runtime/debug"
 	"strings"
 
@@ -15,13 +18,24 @@ import (
 	"github.com/alecthomas/chroma/styles"
 	"github.com/niklasfasching/go-org/blorg"
 	"github.com/niklasfasching/go-org/org"
+	"github.com/pmezard/go-difflib/difflib"
 )
 
 var usage = `Usage: go-org COMMAND [ARGS]...
 Commands:
 - render [FILE] FORMAT
-  FORMAT: org, html, html-chroma
+  FORMAT: org, html, html-chroma, md, txt, latex, latex-minted, json, pandoc
   Instead of specifying a file, org mode content can also be passed on stdin
+- from-json [FILE] FORMAT
+  Like render - but reads a document that was rendered as json
+- convert FILE FORMAT
+  Like render - but reads a markdown (.md, .markdown) or html (.html, .htm) file
+- lint [-json] [-strict CODE,...] FILE...
+  Reports problems like broken links or mismatched blocks - exits non-zero if there are errors
+  -strict turns the diagnostics with the given codes into errors
+- fmt [-w] [-d] FILE...
+  Prints the files formatted as canonical org (aligned tags and tables, renumbered lists, ...)
+  -w writes the formatted files back instead, -d prints a unified diff of the changes
 - blorg
   - blorg init
   - blorg build
@@ -35,7 +49,15 @@ func main() {
 	}
 	switch cmd, args := os.Args[1], os.Args[2:]; cmd {
 	case "render":
-		render(args)
+		render(args, org.New().Parse)
+	case "from-json":
+		render(args, org.New().ParseJSON)
+	case "convert":
+		convert(args)
+	case "lint":
+		lint(args)
+	case "fmt":
+		format(args)
 	case "blorg":
 		runBlorg(args)
 	case "version":
@@ -81,7 +103,7 @@ func runBlorg(args []string) {
 	}
 }
 
-func render(args []string) {
+func render(args []string, parse func(io.Reader, string) *org.Document) {
 	r, path, format := io.Reader(nil), "", ""
 	if fi, err := os.Stdin.Stat(); err != nil {
 		log.Fatal(err)
@@ -97,7 +119,7 @@ func render(args []string) {
 	} else {
 		log.Fatal(usage)
 	}
-	d := org.New().Parse(r, path)
+	d := parse(r, path)
 	write := func(w org.Writer) {
 		out, err := d.Write(w)
 		if err != nil {
@@ -110,6 +132,20 @@ func render(args []string) {
 		write(org.NewOrgWriter())
 	case "html":
 		write(org.NewHTMLWriter())
+	case "md":
+		write(org.NewMarkdownWriter())
+	case "txt":
+		write(org.NewTextWriter())
+	case "latex":
+		write(org.NewLaTeXWriter())
+	case "json":
+		write(org.NewJSONWriter())
+	case "pandoc":
+		write(org.NewPandocWriter())
+	case "latex-minted":
+		writer := org.NewLaTeXWriter()
+		writer.Minted = true
+		write(writer)
 	case "html-chroma":
 		writer := org.NewHTMLWriter()
 		writer.HighlightCodeBlock = highlightCodeBlock
@@ -119,6 +155,130 @@ func render(args []string) {
 	}
 }
 
+func convert(args []string) {
+	if len(args) != 2 {
+		log.Fatal(usage)
+	}
+	switch strings.ToLower(filepath.Ext(args[0])) {
+	case ".md", ".markdown":
+		render(args, org.New().ParseMarkdown)
+	case ".html", ".htm":
+		render(args, org.New().ParseHTML)
+	default:
+		log.Fatalf("Cannot convert %s: unsupported file type", args[0])
+	}
+}
+
+func lint(args []string) {
+	flags := flag.NewFlagSet("lint", flag.ExitOnError)
+	flags.Usage = func() { log.Print(usage) }
+	asJSON := flags.Bool("json", false, "print diagnostics as json")
+	strict := flags.String("strict", "", "comma separated codes of diagnostics to report as errors")
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		log.Fatal(usage)
+	}
+	type diagnostic struct {
+		File     string       `json:"file"`
+		Line     int          `json:"line,omitempty"`
+		Column   int          `json:"column,omitempty"`
+		Severity org.Severity `json:"severity"`
+		Code     string       `json:"code"`
+		Message  string       `json:"message"`
+	}
+	diagnostics, failed := []diagnostic{}, false
+	for _, path := range flags.Args() {
+		c := org.New().Silent()
+		if *strict != "" {
+			c.Strict = strings.Split(*strict, ",")
+		}
+		bs, err := ioutil.ReadFile(path)
+		if err != nil {
+			failed = true
+			diagnostics = append(diagnostics, diagnostic{path, 0, 0, org.SeverityError, org.CodeParseError, err.Error()})
+			continue
+		}
+		for _, d := range c.Parse(strings.NewReader(string(bs)), path).Lint() {
+			failed = failed || d.Severity == org.SeverityError
+			diagnostics = append(diagnostics, diagnostic{path, d.Position.Start.Line, d.Position.Start.Column, d.Severity, d.Code, d.Message})
+		}
+	}
+	if *asJSON {
+		bs, err := json.MarshalIndent(diagnostics, "", "  ")
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Fprintln(os.Stdout, string(bs))
+	} else {
+		for _, d := range diagnostics {
+			location := d.File
+			if d.Line != 0 {
+				location = fmt.Sprintf("%s:%d:%d", d.File, d.Line, d.Column)
+			}
+			fmt.Fprintf(os.Stdout, "%s: %s: %s (%s)\n", location, d.Severity, d.Message, d.Code)
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func format(args []string) {
+	flags := flag.NewFlagSet("fmt", flag.ExitOnError)
+	flags.Usage = func() { log.Print(usage) }
+	write := flags.Bool("w", false, "write the formatted files instead of printing them")
+	showDiff := flags.Bool("d", false, "print a unified diff of the changes instead of the formatted files")
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		log.Fatal(usage)
+	}
+	failed := false
+	for _, path := range flags.Args() {
+		if err := formatFile(path, *write, *showDiff); err != nil {
+			log.Printf("%s: %s", path, err)
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func formatFile(path string, write, showDiff bool) error {
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	input := string(bs)
+	formatted, err := org.New().Silent().Parse(strings.NewReader(input), path).Format()
+	if err != nil {
+		return err
+	}
+	if showDiff && formatted != input {
+		diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        difflib.SplitLines(input),
+			B:        difflib.SplitLines(formatted),
+			FromFile: path + ".orig",
+			ToFile:   path,
+			Context:  3,
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(os.Stdout, diff)
+	}
+	if write && formatted != input {
+		fi, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(path, []byte(formatted), fi.Mode())
+	} else if !write && !showDiff {
+		fmt.Fprint(os.Stdout, formatted)
+	}
+	return nil
+}
+
 func highlightCodeBlock(source, lang string, inline bool) string {
 	var w strings.Builder
 	l := lexers.Get(lang)
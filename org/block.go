@@ -11,19 +11,32 @@ type Block struct {
 	Parameters []string
 	Children   []Node
 	Result     Node
+	Position
+}
+
+// DynamicBlock is a #+BEGIN: NAME PARAMETERS ... #+END: block whose content is generated (e.g. a clocktable).
+type DynamicBlock struct {
+	Name       string
+	Parameters []string
+	Children   []Node
+	Position
 }
 
 type Result struct {
 	Node Node
+	Position
 }
 
 type Example struct {
 	Children []Node
+	Position
 }
 
 var exampleLineRegexp = regexp.MustCompile(`^(\s*):(\s(.*)|\s*$)`)
 var beginBlockRegexp = regexp.MustCompile(`(?i)^(\s*)#\+BEGIN_(\w+)(.*)`)
 var endBlockRegexp = regexp.MustCompile(`(?i)^(\s*)#\+END_(\w+)`)
+var beginDynamicBlockRegexp = regexp.MustCompile(`(?i)^(\s*)#\+BEGIN:\s*(\S*)(.*)`)
+var endDynamicBlockRegexp = regexp.MustCompile(`(?i)^(\s*)#\+END:\s*$`)
 var resultRegexp = regexp.MustCompile(`(?i)^(\s*)#\+RESULTS:`)
 var exampleBlockEscapeRegexp = regexp.MustCompile(`(^|\n)([ \t]*),([ \t]*)(\*|,\*|#\+|,#\+)`)
 
@@ -32,6 +45,10 @@ func lexBlock(line string) (token, bool) {
 		return token{"beginBlock", len(m[1]), strings.ToUpper(m[2]), m}, true
 	} else if m := endBlockRegexp.FindStringSubmatch(line); m != nil {
 		return token{"endBlock", len(m[1]), strings.ToUpper(m[2]), m}, true
+	} else if m := beginDynamicBlockRegexp.FindStringSubmatch(line); m != nil {
+		return token{"beginDynamicBlock", len(m[1]), m[2], m}, true
+	} else if m := endDynamicBlockRegexp.FindStringSubmatch(line); m != nil {
+		return token{"endDynamicBlock", len(m[1]), "", m}, true
 	}
 	return nilToken, false
 }
@@ -59,16 +76,18 @@ func (d *Document) parseBlock(i int, parentStop stopFn) (int, Node) {
 	stop := func(d *Document, i int) bool {
 		return i >= len(d.tokens) || (d.tokens[i].kind == "endBlock" && d.tokens[i].content == name)
 	}
-	block, i := Block{name, parameters, nil, nil}, i+1
+	block, i := Block{name, parameters, nil, nil, Position{}}, i+1
 	if isRawTextBlock(name) {
-		rawText := ""
+		rawText, lines, cols := "", []string{}, []int{}
 		for ; !stop(d, i); i++ {
-			rawText += trim(d.tokens[i].matches[0]) + "\n"
+			line := trim(d.tokens[i].matches[0])
+			rawText += line + "\n"
+			lines, cols = append(lines, line), append(cols, d.suffixColumn(i, line))
 		}
 		if name == "EXAMPLE" || (name == "SRC" && len(parameters) >= 1 && parameters[0] == "org") {
 			rawText = exampleBlockEscapeRegexp.ReplaceAllString(rawText, "$1$2$3$4")
 		}
-		block.Children = d.parseRawInline(rawText)
+		block.Children = d.parseRawInlineFrom(rawText, d.lineSourceMap(start+1, lines, cols))
 	} else {
 		consumed, nodes := d.parseMany(i, stop)
 		block.Children = nodes
@@ -82,6 +101,22 @@ func (d *Document) parseBlock(i int, parentStop stopFn) (int, Node) {
 		block.Result = result
 		i += consumed
 	}
+	block.Position = d.blockPosition(start, i+1-start)
+	return i + 1 - start, block
+}
+
+func (d *Document) parseDynamicBlock(i int, parentStop stopFn) (int, Node) {
+	t, start := d.tokens[i], i
+	stop := func(d *Document, i int) bool {
+		return parentStop(d, i) || d.tokens[i].kind == "endDynamicBlock" || d.tokens[i].kind == "headline"
+	}
+	block, i := DynamicBlock{t.content, splitParameters(t.matches[3]), nil, Position{}}, i+1
+	consumed, nodes := d.parseMany(i, stop)
+	block.Children, i = nodes, i+consumed
+	if i >= len(d.tokens) || d.tokens[i].kind != "endDynamicBlock" {
+		return 0, nil
+	}
+	block.Position = d.blockPosition(start, i+1-start)
 	return i + 1 - start, block
 }
 
@@ -99,8 +134,11 @@ func (d *Document) parseSrcBlockResult(i int, parentStop stopFn) (int, Node) {
 func (d *Document) parseExample(i int, parentStop stopFn) (int, Node) {
 	example, start := Example{}, i
 	for ; !parentStop(d, i) && d.tokens[i].kind == "example"; i++ {
-		example.Children = append(example.Children, Text{d.tokens[i].content, true})
+		content := d.tokens[i].content
+		col := d.suffixColumn(i, content)
+		example.Children = append(example.Children, Text{content, true, Position{d.point(i, col), d.point(i, col+len(content))}})
 	}
+	example.Position = d.blockPosition(start, i-start)
 	return i - start, example
 }
 
@@ -109,7 +147,7 @@ func (d *Document) parseResult(i int, parentStop stopFn) (int, Node) {
 		return 0, nil
 	}
 	consumed, node := d.parseOne(i+1, parentStop)
-	return consumed + 1, Result{node}
+	return consumed + 1, Result{node, d.blockPosition(i, consumed+1)}
 }
 
 func trimIndentUpTo(max int) func(string) string {
@@ -145,6 +183,16 @@ func (b Block) ParameterMap() map[string]string {
 	return m
 }
 
-func (n Example) String() string { return orgWriter.WriteNodesAsString(n) }
-func (n Block) String() string   { return orgWriter.WriteNodesAsString(n) }
-func (n Result) String() string  { return orgWriter.WriteNodesAsString(n) }
+// ParameterMap returns the parameters of the dynamic block as a map of :key to value.
+func (b DynamicBlock) ParameterMap() map[string]string {
+	m := map[string]string{}
+	for i := 0; i+1 < len(b.Parameters); i += 2 {
+		m[b.Parameters[i]] = b.Parameters[i+1]
+	}
+	return m
+}
+
+func (n Example) String() string      { return orgWriter.WriteNodesAsString(n) }
+func (n DynamicBlock) String() string { return orgWriter.WriteNodesAsString(n) }
+func (n Block) String() string        { return orgWriter.WriteNodesAsString(n) }
+func (n Result) String() string       { return orgWriter.WriteNodesAsString(n) }
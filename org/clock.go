@@ -0,0 +1,139 @@
+package org
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// Clock is a CLOCK entry. Running clocks have no EndTime.
+type Clock struct {
+	Timestamp Timestamp
+	Duration  time.Duration // Duration is the duration written after => or the duration of Timestamp if there is none.
+	Position
+}
+
+// LogbookDrawer is a :LOGBOOK: drawer - it usually contains Clock entries and state change notes.
+type LogbookDrawer struct {
+	Children []Node
+	Position
+}
+
+var clockRegexp = regexp.MustCompile(`^(\s*)CLOCK:\s+(.*)$`)
+var clockDurationRegexp = regexp.MustCompile(`^\s*=>\s*(\d+):(\d{2})\s*$`)
+
+func lexClock(line string) (token, bool) {
+	if m := clockRegexp.FindStringSubmatch(line); m != nil {
+		return token{"clock", len(m[1]), m[2], m}, true
+	}
+	return nilToken, false
+}
+
+func (d *Document) parseClock(i int, parentStop stopFn) (int, Node) {
+	content := d.tokens[i].content
+	consumed, node := d.parseTimestamp(content, 0)
+	if consumed == 0 {
+		return 0, nil
+	}
+	timestamp := node.(Timestamp)
+	col := d.suffixColumn(i, content)
+	timestamp.Position = Position{d.point(i, col), d.point(i, col+consumed)}
+	clock := Clock{timestamp, timestamp.EndTime.Sub(timestamp.Time), d.blockPosition(i, 1)}
+	if timestamp.EndTime.IsZero() {
+		clock.Duration = 0
+	}
+	if rest := content[consumed:]; rest != "" {
+		m := clockDurationRegexp.FindStringSubmatch(rest)
+		if m == nil || timestamp.EndTime.IsZero() {
+			return 0, nil
+		}
+		hours, _ := strconv.Atoi(m[1])
+		minutes, _ := strconv.Atoi(m[2])
+		clock.Duration = time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute
+	}
+	return 1, clock
+}
+
+// IsRunning returns true if the clock has not been stopped yet.
+func (c Clock) IsRunning() bool { return c.Timestamp.EndTime.IsZero() }
+
+// DurationIn returns the part of the clocked time that lies in the range [start, end).
+// A zero start or end means the range is unbounded in that direction. Running clocks count as zero.
+func (c Clock) DurationIn(start, end time.Time) time.Duration {
+	if c.IsRunning() {
+		return 0
+	}
+	from, to := c.Timestamp.Time, c.Timestamp.EndTime
+	if !start.IsZero() && from.Before(start) {
+		from = start
+	}
+	if !end.IsZero() && to.After(end) {
+		to = end
+	}
+	if !to.After(from) {
+		return 0
+	}
+	return to.Sub(from)
+}
+
+// Clocks returns the Clock entries of the headline - excluding those of its child headlines.
+func (h Headline) Clocks() []Clock {
+	return collectClocks(h.Children, nil)
+}
+
+// ClockedTime returns the time clocked on the headline (excluding child headlines) in the range [start, end).
+// A zero start or end means the range is unbounded in that direction.
+func (h Headline) ClockedTime(start, end time.Time) time.Duration {
+	sum := time.Duration(0)
+	for _, c := range h.Clocks() {
+		sum += c.DurationIn(start, end)
+	}
+	return sum
+}
+
+// ClockedTime returns the time clocked on the section and all of its subsections in the range [start, end).
+// A zero start or end means the range is unbounded in that direction.
+func (s *Section) ClockedTime(start, end time.Time) time.Duration {
+	sum := time.Duration(0)
+	if s.Headline != nil {
+		sum += s.Headline.ClockedTime(start, end)
+	}
+	for _, child := range s.Children {
+		sum += child.ClockedTime(start, end)
+	}
+	return sum
+}
+
+func collectClocks(nodes []Node, clocks []Clock) []Clock {
+	for _, n := range nodes {
+		switch n := n.(type) {
+		case Clock:
+			clocks = append(clocks, n)
+		case LogbookDrawer:
+			clocks = collectClocks(n.Children, clocks)
+		case Drawer:
+			clocks = collectClocks(n.Children, clocks)
+		}
+	}
+	return clocks
+}
+
+// writeLogbookClocks writes the clock entries of the logbook drawer d - they are only exported if the c option is set
+// (see WriteClock). Other entries of the logbook (e.g. state changes) are never exported.
+func writeLogbookClocks(w Writer, d LogbookDrawer) {
+	for _, n := range d.Children {
+		if c, ok := n.(Clock); ok {
+			w.WriteClock(c)
+		}
+	}
+}
+
+// formatClockDuration formats d like Org mode does for the => part of CLOCK entries.
+func formatClockDuration(d time.Duration) string {
+	minutes := int(d.Round(time.Minute) / time.Minute)
+	return fmt.Sprintf("%2d:%02d", minutes/60, minutes%60)
+}
+
+func (n Clock) String() string         { return orgWriter.WriteNodesAsString(n) }
+func (n LogbookDrawer) String() string { return orgWriter.WriteNodesAsString(n) }
@@ -0,0 +1,97 @@
+package org
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClockedTime(t *testing.T) {
+	d := New().Silent().Parse(strings.NewReader(fileString("./testdata/clocks.org")), "./testdata/clocks.org")
+	date := func(s string) time.Time {
+		t, _ := time.Parse("2006-01-02 15:04", s)
+		return t
+	}
+	project := d.Outline.Children[0]
+	tests := []struct {
+		section    *Section
+		start, end time.Time
+		expected   time.Duration
+	}{
+		{project, time.Time{}, time.Time{}, 13*time.Hour + 30*time.Minute},
+		{project.Children[0], time.Time{}, time.Time{}, 3*time.Hour + 15*time.Minute},
+		{project.Children[1], time.Time{}, time.Time{}, 10*time.Hour + 15*time.Minute},
+		{project, date("2021-03-02 00:00"), date("2021-03-03 00:00"), 2*time.Hour + 30*time.Minute},
+		{project, date("2021-03-02 15:00"), date("2021-03-03 09:00"), 2*time.Hour + 30*time.Minute},
+		{project, date("2021-03-04 00:00"), time.Time{}, 0},
+	}
+	for _, test := range tests {
+		if actual := test.section.ClockedTime(test.start, test.end); actual != test.expected {
+			t.Errorf("%s [%s, %s): got %s, expected %s", String(test.section.Headline.Title), test.start, test.end, actual, test.expected)
+		}
+	}
+	if clocks := project.Children[1].Headline.Clocks(); len(clocks) != 2 || !clocks[1].IsRunning() {
+		t.Errorf("expected 2 clocks with the last one running: %#v", clocks)
+	}
+}
+
+func TestUpdateDynamicBlocks(t *testing.T) {
+	path := "./testdata/clocktable.org"
+	d := New().Silent().Parse(strings.NewReader(fileString(path)), path)
+	d.UpdateDynamicBlocks()
+	actual, err := d.Write(NewOrgWriter())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"#+BEGIN: clocktable :scope file :maxlevel 2\n" +
+			"| Headline           | Time   |      |\n" +
+			"|--------------------+--------+------|\n" +
+			"| *Total time*       | *9:30* |      |\n" +
+			"|--------------------+--------+------|\n" +
+			"| Project            | 8:30   |      |\n" +
+			"| \\_  Design         |        | 2:30 |\n" +
+			"| \\_  Implementation |        | 6:00 |\n" +
+			"| Other              | 1:00   |      |\n" +
+			"#+END:\n",
+		"#+BEGIN: clocktable :scope subtree :block 2021-03-01\n" +
+			"| Headline     | Time   |\n" +
+			"|--------------+--------|\n" +
+			"| *Total time* | *1:00* |\n" +
+			"|--------------+--------|\n" +
+			"| Other        | 1:00   |\n" +
+			"#+END:\n",
+	}
+	for _, e := range expected {
+		if !strings.Contains(actual, e) {
+			t.Errorf("expected clocktable:\n%s\nin:\n%s", e, actual)
+		}
+	}
+	if len(d.Outline.Children[1].Headline.Children) == 0 {
+		t.Errorf("expected outline headline children to be updated")
+	}
+}
+
+func TestClocktableRange(t *testing.T) {
+	date := func(s string) time.Time {
+		t, _ := time.Parse("2006-01-02", s)
+		return t
+	}
+	wednesday := time.Date(2021, 3, 3, 14, 0, 0, 0, time.UTC)
+	tests := []struct {
+		params     map[string]string
+		start, end time.Time
+	}{
+		{map[string]string{":block": "today"}, date("2021-03-03"), date("2021-03-04")},
+		{map[string]string{":block": "thisweek"}, date("2021-03-01"), date("2021-03-08")},
+		{map[string]string{":block": "lastmonth"}, date("2021-02-01"), date("2021-03-01")},
+		{map[string]string{":block": "2020"}, date("2020-01-01"), date("2021-01-01")},
+		{map[string]string{":block": "thisyear", ":tstart": `"<2021-02-10 Wed>"`, ":tend": `"<today>"`}, date("2021-02-10"), date("2021-03-03")},
+	}
+	for _, test := range tests {
+		start, end, err := clocktableRange(test.params, wednesday)
+		if err != nil || !start.Equal(test.start) || !end.Equal(test.end) {
+			t.Errorf("%v: got [%s, %s) (%v), expected [%s, %s)", test.params, start, end, err, test.start, test.end)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package org
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// now returns the current time. It is used to resolve relative clocktable ranges (e.g. :block today).
+var now = time.Now
+
+var clocktableScopeTreeRegexp = regexp.MustCompile(`^tree(\d*)$`)
+
+// UpdateDynamicBlocks recomputes the content of all dynamic blocks of the document that have a generator.
+// Currently only clocktable blocks are supported. Blocks that cannot be generated are left untouched.
+// Use an OrgWriter to write the updated document back.
+func (d *Document) UpdateDynamicBlocks() {
+	d.Nodes = d.updateDynamicBlocks(d.Nodes, d.Outline.Section)
+}
+
+func (d *Document) updateDynamicBlocks(nodes []Node, section *Section) []Node {
+	for i, n := range nodes {
+		switch n := n.(type) {
+		case Headline:
+			s := section.findByIndex(n.Index)
+			if s == nil {
+				s = section
+			}
+			n.Children = d.updateDynamicBlocks(n.Children, s)
+			if s.Headline != nil && s.Headline.Index == n.Index {
+				s.Headline.Children = n.Children
+			}
+			nodes[i] = n
+		case DynamicBlock:
+			if strings.ToLower(n.Name) != "clocktable" {
+				continue
+			}
+			children, err := d.ClockTable(n, section)
+			if err != nil {
+				d.addDiagnostic(CodeBadClocktable, n.Position, "could not update clocktable: %s", err)
+				continue
+			}
+			n.Children = children
+			nodes[i] = n
+		}
+	}
+	return nodes
+}
+
+// ClockTable generates the content of the clocktable dynamic block b that is located in section
+// (the Outline itself for blocks before the first headline). The following parameters are supported:
+// - :scope file (default), subtree, tree or treeN
+// - :maxlevel N (default 3)
+// - :block today, yesterday, thisweek, lastweek, thismonth, lastmonth, thisyear, lastyear, YYYY-MM-DD, YYYY-MM or YYYY
+// - :tstart & :tend (e.g. "<2021-03-01 Mon>" or "<today>") - overriding :block
+// Unlike Org mode, no caption with the time of generation is added to keep the output stable.
+func (d *Document) ClockTable(b DynamicBlock, section *Section) ([]Node, error) {
+	params := b.ParameterMap()
+	root, err := clocktableScope(d, section, params[":scope"])
+	if err != nil {
+		return nil, err
+	}
+	maxLvl := 3
+	if v := params[":maxlevel"]; v != "" {
+		if maxLvl, err = strconv.Atoi(v); err != nil {
+			return nil, fmt.Errorf("bad :maxlevel %q", v)
+		}
+	}
+	start, end, err := clocktableRange(params, now())
+	if err != nil {
+		return nil, err
+	}
+
+	type row struct {
+		lvl   int
+		title string
+		time  time.Duration
+	}
+	rows, columns := []row{}, 1
+	var collect func(*Section)
+	collect = func(s *Section) {
+		if h := s.Headline; h != nil && h.Lvl <= maxLvl {
+			if t := s.ClockedTime(start, end); t != 0 {
+				title := strings.ReplaceAll(strings.TrimSpace(String(h.Title)), "|", `\vert{}`)
+				rows = append(rows, row{h.Lvl, title, t})
+				if h.Lvl > columns {
+					columns = h.Lvl
+				}
+			}
+		}
+		for _, child := range s.Children {
+			collect(child)
+		}
+	}
+	collect(root)
+
+	cells := func(first, second string, n int) string {
+		return "| " + first + " | " + second + " |" + strings.Repeat(" |", n)
+	}
+	total := "*" + strings.TrimSpace(formatClockDuration(root.ClockedTime(start, end))) + "*"
+	lines := []string{
+		cells("Headline", "Time", columns-1),
+		"|-",
+		cells("*Total time*", total, columns-1),
+		"|-",
+	}
+	for _, r := range rows {
+		indent, cols := "", strings.Repeat(" |", r.lvl-1)
+		if r.lvl > 1 {
+			indent = `\_` + strings.Repeat(" ", 2*(r.lvl-1))
+		}
+		time := strings.TrimSpace(formatClockDuration(r.time))
+		lines = append(lines, "| "+indent+r.title+" |"+cols+" "+time+" |"+strings.Repeat(" |", columns-r.lvl))
+	}
+	table := d.parseFragment(strings.Join(lines, "\n"))
+	if table.Error != nil {
+		return nil, table.Error
+	}
+	return table.Nodes, nil
+}
+
+func clocktableScope(d *Document, section *Section, scope string) (*Section, error) {
+	switch scope {
+	case "", "file":
+		return d.Outline.Section, nil
+	case "subtree":
+		return section, nil
+	}
+	m := clocktableScopeTreeRegexp.FindStringSubmatch(scope)
+	if m == nil {
+		return nil, fmt.Errorf("unsupported :scope %q", scope)
+	}
+	lvl := 1
+	if m[1] != "" {
+		lvl, _ = strconv.Atoi(m[1])
+	}
+	for ; section.Headline != nil && section.Headline.Lvl > lvl; section = section.Parent {
+	}
+	return section, nil
+}
+
+func clocktableRange(params map[string]string, now time.Time) (start, end time.Time, err error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	weekStart := today.AddDate(0, 0, -(int(today.Weekday())+6)%7)
+	monthStart := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+	yearStart := time.Date(today.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	switch block := params[":block"]; block {
+	case "":
+	case "today":
+		start, end = today, today.AddDate(0, 0, 1)
+	case "yesterday":
+		start, end = today.AddDate(0, 0, -1), today
+	case "thisweek":
+		start, end = weekStart, weekStart.AddDate(0, 0, 7)
+	case "lastweek":
+		start, end = weekStart.AddDate(0, 0, -7), weekStart
+	case "thismonth":
+		start, end = monthStart, monthStart.AddDate(0, 1, 0)
+	case "lastmonth":
+		start, end = monthStart.AddDate(0, -1, 0), monthStart
+	case "thisyear":
+		start, end = yearStart, yearStart.AddDate(1, 0, 0)
+	case "lastyear":
+		start, end = yearStart.AddDate(-1, 0, 0), yearStart
+	default:
+		if t, err := time.Parse("2006-01-02", block); err == nil {
+			start, end = t, t.AddDate(0, 0, 1)
+		} else if t, err := time.Parse("2006-01", block); err == nil {
+			start, end = t, t.AddDate(0, 1, 0)
+		} else if t, err := time.Parse("2006", block); err == nil {
+			start, end = t, t.AddDate(1, 0, 0)
+		} else {
+			return start, end, fmt.Errorf("unsupported :block %q", block)
+		}
+	}
+	for key, t := range map[string]*time.Time{":tstart": &start, ":tend": &end} {
+		v := strings.Trim(params[key], `"`)
+		switch v {
+		case "":
+		case "<now>":
+			*t = now
+		case "<today>":
+			*t = today
+		case "<yesterday>":
+			*t = today.AddDate(0, 0, -1)
+		case "<tomorrow>":
+			*t = today.AddDate(0, 0, 1)
+		default:
+			consumed, timestamp, ok := parseSingleTimestamp(v)
+			if !ok || consumed != len(v) {
+				return start, end, fmt.Errorf("unsupported %s %q", key, v)
+			}
+			*t = timestamp.Time
+		}
+	}
+	return start, end, nil
+}
@@ -0,0 +1,145 @@
+package org
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Diagnostic is a problem that was found while parsing or writing a document.
+type Diagnostic struct {
+	Severity Severity
+	Position Position // Position is the range of the parse input the problem was found in - it is not valid for problems without a source position.
+	Code     string   // Code identifies the kind of problem (e.g. bad-include). Codes are stable and can be used in Configuration.Strict.
+	Message  string
+}
+
+type Severity int
+
+const (
+	SeverityWarning Severity = iota
+	SeverityError
+)
+
+// Codes of the diagnostics reported during parsing and writing.
+const (
+	CodeParseError          = "parse-error"           // The input could not be parsed at all.
+	CodeUnparsableToken     = "unparsable-token"      // A line could not be parsed as what it looks like and was treated as plain text.
+	CodeBadInclude          = "bad-include"           // An #+INCLUDE keyword is malformed or its file cannot be read.
+	CodeBadSetupFile        = "bad-setup-file"        // A #+SETUPFILE cannot be read or parsed.
+	CodeUndefinedFootnote   = "undefined-footnote"    // A footnote is referenced but not defined.
+	CodeUnknownMacro        = "unknown-macro"         // A macro is used but not defined with #+MACRO.
+	CodeBadMacro            = "bad-macro"             // The expansion of a macro cannot be parsed.
+	CodeBadClocktable       = "bad-clocktable"        // A clocktable dynamic block cannot be generated.
+	CodeMissingExportOption = "missing-export-option" // An export option has no value in #+OPTIONS or DefaultSettings.
+	CodeBadHTMLAttributes   = "bad-html-attributes"   // #+ATTR_HTML attributes cannot be added to the written html.
+	CodeBrokenLink          = "broken-link"           // An internal link does not point to a headline, CUSTOM_ID, name or target of the document.
+)
+
+// builtinMacros are the macros Org mode defines itself - they are not reported as unknown.
+var builtinMacros = map[string]bool{
+	"title": true, "author": true, "date": true, "email": true, "time": true, "modification-time": true,
+	"input-file": true, "keyword": true, "property": true, "n": true, "results": true,
+}
+
+func (s Severity) String() string {
+	if s == SeverityError {
+		return "error"
+	}
+	return "warning"
+}
+
+func (s Severity) MarshalText() ([]byte, error) { return []byte(s.String()), nil }
+
+// String returns the diagnostic as LINE:COLUMN: SEVERITY: MESSAGE (CODE).
+func (d Diagnostic) String() string {
+	s := fmt.Sprintf("%s: %s (%s)", d.Severity, d.Message, d.Code)
+	if d.Position.IsValid() {
+		s = fmt.Sprintf("%d:%d: %s", d.Position.Start.Line, d.Position.Start.Column, s)
+	}
+	return s
+}
+
+func (d Diagnostic) Error() string { return d.String() }
+
+// Errors returns the diagnostics of the document with SeverityError.
+func (d *Document) Errors() []Diagnostic {
+	errors := []Diagnostic{}
+	for _, diagnostic := range d.Diagnostics {
+		if diagnostic.Severity == SeverityError {
+			errors = append(errors, diagnostic)
+		}
+	}
+	return errors
+}
+
+// addDiagnostic adds a diagnostic (see newDiagnostic) to the Diagnostics of the document and logs it.
+// Diagnostics without a valid position are only added once. The first error is stored in Error to make writing
+// the document fail.
+func (d *Document) addDiagnostic(code string, pos Position, format string, args ...interface{}) {
+	diagnostic := d.newDiagnostic(code, pos, format, args...)
+	for _, existing := range d.Diagnostics {
+		if existing.Code == code && existing.Message == diagnostic.Message && (!pos.IsValid() || existing.Position == pos) {
+			return
+		}
+	}
+	d.Diagnostics = append(d.Diagnostics, diagnostic)
+	if d.Log != nil {
+		d.Log.Print(strings.TrimSpace(d.Path + ": " + diagnostic.String()))
+	}
+	if diagnostic.Severity == SeverityError && d.Error == nil {
+		d.Error = diagnostic
+	}
+}
+
+// newDiagnostic returns a warning - or an error if code is listed in Strict.
+func (d *Document) newDiagnostic(code string, pos Position, format string, args ...interface{}) Diagnostic {
+	diagnostic := Diagnostic{SeverityWarning, pos, code, fmt.Sprintf(format, args...)}
+	if d.isStrict(code) {
+		diagnostic.Severity = SeverityError
+	}
+	return diagnostic
+}
+
+// isStrict reports whether diagnostics with the given code are errors (see Configuration.Strict).
+func (d *Document) isStrict(code string) bool {
+	for _, c := range d.Strict {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// checkReferences reports footnotes and macros that are used but not defined in the document.
+func (d *Document) checkReferences() {
+	definitions, links, macros := map[string]bool{}, []FootnoteLink{}, []Macro{}
+	Inspect(d.Nodes, func(n Node) bool {
+		switch n := n.(type) {
+		case FootnoteDefinition:
+			definitions[n.Name] = true
+		case FootnoteLink:
+			if n.Definition == nil {
+				links = append(links, n)
+			}
+		case Macro:
+			macros = append(macros, n)
+		}
+		return true
+	})
+	for _, l := range links {
+		if !definitions[l.Name] {
+			d.addDiagnostic(CodeUndefinedFootnote, l.Position, "undefined footnote [fn:%s]", l.Name)
+		}
+	}
+	for _, m := range macros {
+		if _, ok := d.Macros[m.Name]; !ok && !builtinMacros[strings.ToLower(m.Name)] {
+			d.addDiagnostic(CodeUnknownMacro, m.Position, "unknown macro %s", m.Name)
+		}
+	}
+}
+
+// setError sets Error to err - and adds it to Diagnostics as a parse-error.
+func (d *Document) setError(err error) {
+	d.Error = err
+	d.Diagnostics = append(d.Diagnostics, Diagnostic{SeverityError, Position{}, CodeParseError, err.Error()})
+}
@@ -0,0 +1,73 @@
+package org
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiagnostics(t *testing.T) {
+	input := "#+MACRO: known $1\n#+INCLUDE: \"does-not-exist.org\" src org\n#+INCLUDE: malformed\n\n" +
+		"{{{known(x)}}} [fn:1] [fn:2] [fn::inline]\n{{{unknown(y)}}}\n\n[fn:1] defined\n\n#+END_SRC\n"
+	c, reads := New().Silent(), 0
+	c.ReadFile = func(path string) ([]byte, error) { reads++; return New().ReadFile(path) }
+	d := c.Parse(strings.NewReader(input), "./testdata/diagnostics.org")
+	if reads != 0 {
+		t.Errorf("expected included files to not be read during parsing, got %d reads", reads)
+	}
+	actual := []string{}
+	for _, diagnostic := range d.Diagnostics {
+		actual = append(actual, diagnostic.String())
+	}
+	expected := []string{
+		`3:1: warning: bad include "malformed" (bad-include)`,
+		`10:1: warning: could not parse endBlock - treating it as plain text (unparsable-token)`,
+		`5:23: warning: undefined footnote [fn:2] (undefined-footnote)`,
+		`6:1: warning: unknown macro unknown (unknown-macro)`,
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("got diagnostics\n%s\nexpected\n%s", strings.Join(actual, "\n"), strings.Join(expected, "\n"))
+	}
+	if _, err := d.Write(NewHTMLWriter()); err != nil || len(d.Errors()) != 0 {
+		t.Errorf("expected warnings to not fail writing: %v", err)
+	}
+	missing := `2:1: warning: bad include "\"does-not-exist.org\" src org": open testdata/does-not-exist.org: no such file or directory (bad-include)`
+	if len(d.Diagnostics) != len(expected)+1 || d.Diagnostics[len(expected)].String() != missing {
+		t.Errorf("expected writing to only add the missing include, got %v", d.Diagnostics)
+	}
+
+	c = New().Silent()
+	c.Strict = []string{CodeUndefinedFootnote, CodeUnknownMacro}
+	d = c.Parse(strings.NewReader(input), "./testdata/diagnostics.org")
+	if errors := d.Errors(); len(errors) != 2 || errors[0].Code != CodeUndefinedFootnote || errors[1].Code != CodeUnknownMacro {
+		t.Errorf("expected strict codes to be errors, got %v", errors)
+	}
+	if _, err := d.Write(NewHTMLWriter()); err == nil || err.Error() != d.Errors()[0].String() {
+		t.Errorf("expected writing to fail with the first error, got %v", err)
+	}
+
+	d = New().Silent().Parse(strings.NewReader(input), "./testdata/diagnostics.org")
+	if d.CheckIncludes(); len(d.Diagnostics) != len(expected)+1 || d.Diagnostics[len(expected)].String() != missing {
+		t.Errorf("expected CheckIncludes to add the missing include, got %v", d.Diagnostics)
+	}
+	c = New().Silent()
+	c.Strict = []string{CodeBadInclude}
+	d = c.Parse(strings.NewReader("#+INCLUDE: \"does-not-exist.org\" src org\n"), "./testdata/diagnostics.org")
+	if d.Error == nil || d.Error.Error() != strings.Replace(missing, "2:1: warning", "1:1: error", 1) {
+		t.Errorf("expected strict parsing to fail on the missing include, got %v", d.Error)
+	}
+
+	c = New().Silent()
+	c.Strict = []string{CodeUnknownMacro}
+	d = c.Parse(strings.NewReader("#+TITLE: title\n{{{title()}}}\n{{{date()}}}\n{{{keyword(TITLE)}}}\n"), "./testdata/diagnostics.org")
+	if _, err := d.Write(NewHTMLWriter()); err != nil || len(d.Diagnostics) != 0 {
+		t.Errorf("expected builtin macros to not be reported, got %v %v", err, d.Diagnostics)
+	}
+
+	c = New().Silent()
+	c.Strict = []string{CodeUndefinedFootnote}
+	d = c.Parse(strings.NewReader("#+MACRO: footnote x[fn:1]\n{{{footnote()}}}\n"), "./testdata/diagnostics.org")
+	if _, err := d.Write(NewHTMLWriter()); err == nil || len(d.Errors()) != 1 {
+		t.Errorf("expected errors found during writing to fail writing, got %v %v", err, d.Diagnostics)
+	}
+}
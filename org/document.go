@@ -23,11 +23,15 @@ import (
 )
 
 type Configuration struct {
-	MaxEmphasisNewLines int                                   // Maximum number of newlines inside an emphasis. See org-emphasis-regexp-components newline.
-	AutoLink            bool                                  // Try to convert text passages that look like hyperlinks into hyperlinks.
-	DefaultSettings     map[string]string                     // Default values for settings that are overriden by setting the same key in BufferSettings.
-	Log                 *log.Logger                           // Log is used to print warnings during parsing.
-	ReadFile            func(filename string) ([]byte, error) // ReadFile is used to read e.g. #+INCLUDE files.
+	MaxEmphasisNewLines        int                                   // Maximum number of newlines inside an emphasis. See org-emphasis-regexp-components newline.
+	AutoLink                   bool                                  // Try to convert text passages that look like hyperlinks into hyperlinks.
+	DefaultSettings            map[string]string                     // Default values for settings that are overriden by setting the same key in BufferSettings.
+	Log                        *log.Logger                           // Log is used to print diagnostics during parsing and writing.
+	ReadFile                   func(filename string) ([]byte, error) // ReadFile is used to read e.g. #+INCLUDE files.
+	Lossless                   bool                                  // Lossless retains the parse input so that OrgWriter writes unmodified nodes byte for byte.
+	Strict                     []string                              // Strict lists the codes of diagnostics that are errors rather than warnings (see Diagnostic.Code).
+	InheritedProperties        []string                              // InheritedProperties lists the properties headlines inherit from their ancestors. See org-use-property-inheritance.
+	TagsExcludeFromInheritance []string                              // TagsExcludeFromInheritance lists the tags headlines do not inherit. See org-tags-exclude-from-inheritance.
 }
 
 // Document contains the parsing results and a pointer to the Configuration.
@@ -43,11 +47,26 @@ type Document struct {
 	Outline        Outline           // Outline is a Table Of Contents for the document and contains all sections (headline + content).
 	BufferSettings map[string]string // Settings contains all settings that were parsed from keywords.
 	Error          error
+	Diagnostics    []Diagnostic // Diagnostics contains the problems found while parsing and writing the document.
+
+	lines        []string  // lines contains the raw input lines - lines[i] is the source of tokens[i].
+	lineOffsets  []int     // lineOffsets contains the byte offset of each line in the input.
+	inlineSource sourceMap // inlineSource maps the input currently parsed by parseInline back to the parse input.
+	inlineOffset int       // inlineOffset is the offset of the input currently parsed by parseInline in inlineSource.
+
+	todoSettings  string         // todoSettings are the TODO settings todoSequences were parsed from.
+	todoSequences []TodoSequence // todoSequences caches the result of TodoSequences.
+
+	source *source // source is the retained parse input of documents parsed in lossless mode.
 }
 
 // Node represents a parsed node of the document.
+//
+// Breaking change: Pos was added to the interface - Node implementations outside of this package
+// no longer satisfy it until they implement Pos, e.g. by embedding Position.
 type Node interface {
 	String() string // String returns the pretty printed Org mode string for the node (see OrgWriter).
+	Pos() Position  // Pos returns the range of the parse input the node was parsed from (see Position).
 }
 
 type lexFn = func(line string) (t token, ok bool)
@@ -72,6 +91,7 @@ var lexFns = []lexFn{
 	lexKeywordOrComment,
 	lexFootnoteDefinition,
 	lexExample,
+	lexClock,
 	lexText,
 }
 
@@ -86,7 +106,8 @@ func New() *Configuration {
 		DefaultSettings: map[string]string{
 			"TODO":         "TODO | DONE",
 			"EXCLUDE_TAGS": "noexport",
-			"OPTIONS":      "toc:t <:t e:t f:t pri:t todo:t tags:t title:t ealb:nil",
+			"PRIORITIES":   "A C B",
+			"OPTIONS":      "toc:t <:t c:nil e:t f:t num:t p:t pri:t todo:t tags:t title:t ealb:nil",
 		},
 		Log:      log.New(os.Stderr, "go-org: ", 0),
 		ReadFile: ioutil.ReadFile,
@@ -111,6 +132,9 @@ func (d *Document) Write(w Writer) (out string, err error) {
 	w.Before(d)
 	WriteNodes(w, d.Nodes...)
 	w.After(d)
+	if d.Error != nil {
+		return "", d.Error
+	}
 	return w.String(), err
 }
 
@@ -129,18 +153,37 @@ func (c *Configuration) Parse(input io.Reader, path string) (d *Document) {
 	}
 	defer func() {
 		if recovered := recover(); recovered != nil {
-			d.Error = fmt.Errorf("could not parse input: %v", recovered)
+			d.setError(fmt.Errorf("could not parse input: %v", recovered))
 		}
 	}()
 	if d.tokens != nil {
-		d.Error = fmt.Errorf("parse was called multiple times")
+		d.setError(fmt.Errorf("parse was called multiple times"))
+	}
+	raw := &strings.Builder{}
+	if c.Lossless {
+		input = io.TeeReader(input, raw)
 	}
 	d.tokenize(input)
 	_, nodes := d.parseMany(0, func(d *Document, i int) bool { return i >= len(d.tokens) })
 	d.Nodes = nodes
+	d.checkReferences()
+	if d.isStrict(CodeBadInclude) {
+		d.CheckIncludes()
+	}
+	if c.Lossless {
+		d.source = newSource(raw.String(), d.lineOffsets, nodes)
+	}
 	return d
 }
 
+// parseFragment parses input that is part of the document (e.g. its title or the expansion of a macro).
+// Problems of the fragment are not reported - references are resolved and checked in the context of the document.
+func (d *Document) parseFragment(input string) *Document {
+	c := *d.Configuration
+	c.Strict = nil
+	return c.Silent().Parse(strings.NewReader(input), d.Path)
+}
+
 // Silent disables all logging of warnings during parsing.
 func (c *Configuration) Silent() *Configuration {
 	c.Log = log.New(ioutil.Discard, "", 0)
@@ -148,13 +191,21 @@ func (c *Configuration) Silent() *Configuration {
 }
 
 func (d *Document) tokenize(input io.Reader) {
-	d.tokens = []token{}
-	scanner := bufio.NewScanner(input)
+	d.tokens, d.lines, d.lineOffsets = []token{}, []string{}, []int{}
+	scanner, offset, lineOffset := bufio.NewScanner(input), 0, 0
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		advance, line, err := bufio.ScanLines(data, atEOF)
+		lineOffset, offset = offset, offset+advance
+		return advance, line, err
+	})
 	for scanner.Scan() {
-		d.tokens = append(d.tokens, tokenize(scanner.Text()))
+		line := scanner.Text()
+		d.lines = append(d.lines, line)
+		d.lineOffsets = append(d.lineOffsets, lineOffset)
+		d.tokens = append(d.tokens, tokenize(line))
 	}
 	if err := scanner.Err(); err != nil {
-		d.Error = fmt.Errorf("could not tokenize input: %s", err)
+		d.setError(fmt.Errorf("could not tokenize input: %s", err))
 	}
 }
 
@@ -172,8 +223,11 @@ func (d *Document) Get(key string) string {
 // GetOption returns the value associated to the export option key
 // Currently supported options:
 // - < (export timestamps)
+// - c (export CLOCK entries)
 // - e (export org entities)
 // - f (export footnotes)
+// - num (number sections - only used by the LaTeX export)
+// - p (export headline planning information - SCHEDULED, DEADLINE & CLOSED)
 // - title (export title)
 // - toc (export table of content. an int limits the included org headline lvl)
 // - todo (export headline todo status)
@@ -196,11 +250,12 @@ func (d *Document) GetOption(key string) string {
 	}
 	if value == "" {
 		value = "nil"
-		d.Log.Printf("Missing value for export option %s", key)
+		d.addDiagnostic(CodeMissingExportOption, Position{}, "missing value for export option %s", key)
 	}
 	return value
 }
 
+// parseOne parses the node starting at the token at i. Parse functions set the Position of the nodes they return.
 func (d *Document) parseOne(i int, stop stopFn) (consumed int, node Node) {
 	switch d.tokens[i].kind {
 	case "unorderedList", "orderedList":
@@ -209,6 +264,8 @@ func (d *Document) parseOne(i int, stop stopFn) (consumed int, node Node) {
 		consumed, node = d.parseTable(i, stop)
 	case "beginBlock":
 		consumed, node = d.parseBlock(i, stop)
+	case "beginDynamicBlock":
+		consumed, node = d.parseDynamicBlock(i, stop)
 	case "result":
 		consumed, node = d.parseResult(i, stop)
 	case "beginDrawer":
@@ -227,12 +284,14 @@ func (d *Document) parseOne(i int, stop stopFn) (consumed int, node Node) {
 		consumed, node = d.parseHeadline(i, stop)
 	case "footnoteDefinition":
 		consumed, node = d.parseFootnoteDefinition(i, stop)
+	case "clock":
+		consumed, node = d.parseClock(i, stop)
 	}
 
 	if consumed != 0 {
 		return consumed, node
 	}
-	d.Log.Printf("Could not parse token %#v: Falling back to treating it as plain text.", d.tokens[i])
+	d.addDiagnostic(CodeUnparsableToken, d.blockPosition(i, 1), "could not parse %s - treating it as plain text", d.tokens[i].kind)
 	m := plainTextRegexp.FindStringSubmatch(d.tokens[i].matches[0])
 	d.tokens[i] = token{"text", len(m[1]), m[2], m}
 	return d.parseOne(i, stop)
@@ -8,10 +8,12 @@ import (
 type Drawer struct {
 	Name     string
 	Children []Node
+	Position
 }
 
 type PropertyDrawer struct {
 	Properties [][]string
+	Position
 }
 
 var beginDrawerRegexp = regexp.MustCompile(`^(\s*):(\S+):\s*$`)
@@ -46,7 +48,8 @@ func (d *Document) parseDrawer(i int, parentStop stopFn) (int, Node) {
 		i += consumed
 		drawer.Children = append(drawer.Children, nodes...)
 		if i < len(d.tokens) && d.tokens[i].kind == "beginDrawer" {
-			p := Paragraph{[]Node{Text{":" + d.tokens[i].content + ":", false}}}
+			pos := d.blockPosition(i, 1)
+			p := Paragraph{[]Node{Text{":" + d.tokens[i].content + ":", false, pos}}, pos}
 			drawer.Children = append(drawer.Children, p)
 			i++
 		} else {
@@ -56,6 +59,9 @@ func (d *Document) parseDrawer(i int, parentStop stopFn) (int, Node) {
 	if i < len(d.tokens) && d.tokens[i].kind == "endDrawer" {
 		i++
 	}
+	if drawer.Position = d.blockPosition(start, i-start); name == "LOGBOOK" {
+		return i - start, LogbookDrawer{drawer.Children, drawer.Position}
+	}
 	return i - start, drawer
 }
 
@@ -78,6 +84,7 @@ func (d *Document) parsePropertyDrawer(i int, parentStop stopFn) (int, Node) {
 	} else {
 		return 0, nil
 	}
+	drawer.Position = d.blockPosition(start, i-start)
 	return i - start, drawer
 }
 
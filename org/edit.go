@@ -0,0 +1,290 @@
+package org
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Placement specifies where InsertSubtree and MoveSubtree place a subtree relative to the target headline.
+type Placement int
+
+const (
+	PlaceBefore    Placement = iota // PlaceBefore places the subtree as the previous sibling of the target headline.
+	PlaceAfter                      // PlaceAfter places the subtree as the next sibling of the target headline.
+	PlaceLastChild                  // PlaceLastChild places the subtree as the last child of the target headline.
+)
+
+var validTagRegexp = regexp.MustCompile(`^[A-Za-z0-9_@#%]+$`)
+
+// The editing methods below modify the headline with the given index (see Headline.Index) in d.Nodes and keep
+// Outline and NamedNodes in sync with the modified nodes. As headlines are renumbered in document order after
+// structural changes and sections are rebuilt, indexes and *Section pointers obtained before an edit must not
+// be used after it.
+// Modified nodes of documents parsed in lossless mode are written by OrgWriter - everything else is written
+// as it was parsed.
+
+// SetTodo sets the TODO status of the headline - an empty status removes it.
+func (d *Document) SetTodo(index int, status string) error {
+	if _, _, ok := d.TodoKeyword(status); status != "" && !ok {
+		return fmt.Errorf("unknown todo keyword %q", status)
+	}
+	return d.editHeadline(index, func(h Headline) []Node {
+		h.Status = status
+		return []Node{h}
+	})
+}
+
+// SetProperty sets the value of the property key (case insensitive) in the property drawer of the headline -
+// the drawer is created if necessary.
+func (d *Document) SetProperty(index int, key, value string) error {
+	key = strings.ToUpper(key)
+	if key == "" || strings.ContainsAny(key, " \t\n:") || strings.Contains(value, "\n") {
+		return fmt.Errorf("invalid property %q: %q", key, value)
+	}
+	return d.editHeadline(index, func(h Headline) []Node {
+		drawer := PropertyDrawer{}
+		if h.Properties != nil {
+			drawer = *h.Properties
+		}
+		properties, found := make([][]string, 0, len(drawer.Properties)+1), false
+		for _, kv := range drawer.Properties {
+			if kv[0] == key {
+				kv, found = []string{key, value}, true
+			}
+			properties = append(properties, kv)
+		}
+		if !found {
+			properties = append(properties, []string{key, value})
+		}
+		drawer.Properties, h.Properties = properties, &drawer
+		return []Node{h}
+	})
+}
+
+// DeleteProperty removes the property key (case insensitive) from the property drawer of the headline -
+// the drawer is removed once it is empty.
+func (d *Document) DeleteProperty(index int, key string) error {
+	key = strings.ToUpper(key)
+	return d.editHeadline(index, func(h Headline) []Node {
+		if h.Properties == nil {
+			return []Node{h}
+		}
+		drawer, properties := *h.Properties, [][]string{}
+		for _, kv := range drawer.Properties {
+			if kv[0] != key {
+				properties = append(properties, kv)
+			}
+		}
+		if drawer.Properties, h.Properties = properties, &drawer; len(properties) == 0 {
+			h.Properties = nil
+		}
+		return []Node{h}
+	})
+}
+
+// AddTag adds tag to the tags of the headline unless it already has it.
+func (d *Document) AddTag(index int, tag string) error {
+	if !validTagRegexp.MatchString(tag) {
+		return fmt.Errorf("invalid tag %q", tag)
+	}
+	return d.editHeadline(index, func(h Headline) []Node {
+		if !hasAnyTag(h.Tags, []string{tag}) {
+			h.Tags = append(h.Tags[:len(h.Tags):len(h.Tags)], tag)
+		}
+		return []Node{h}
+	})
+}
+
+// RemoveTag removes tag from the tags of the headline.
+func (d *Document) RemoveTag(index int, tag string) error {
+	return d.editHeadline(index, func(h Headline) []Node {
+		tags := []string{}
+		for _, t := range h.Tags {
+			if t != tag {
+				tags = append(tags, t)
+			}
+		}
+		if len(tags) != len(h.Tags) {
+			h.Tags = tags
+		}
+		return []Node{h}
+	})
+}
+
+// AppendChildren appends nodes to the section of the headline (index 0 appends to the document itself).
+// Headlines are appended as the last children of the headline (and leveled accordingly); all other nodes are
+// appended to the content of the headline - i.e. before its first child headline.
+func (d *Document) AppendChildren(index int, nodes ...Node) error {
+	if index == 0 {
+		d.Nodes = appendChildren(d.Nodes, 1, nodes)
+		d.updateOutline()
+		return nil
+	}
+	return d.editHeadline(index, func(h Headline) []Node {
+		h.Children = appendChildren(h.Children, h.Lvl+1, nodes)
+		return []Node{h}
+	})
+}
+
+// InsertSubtree inserts the headline h (and its children) at placement relative to the headline with the given index.
+// The levels of h and its descendants are adjusted to their new position. Index 0 (and PlaceLastChild) appends h to
+// the document itself.
+func (d *Document) InsertSubtree(index int, placement Placement, h Headline) error {
+	if index == 0 && placement == PlaceLastChild {
+		d.Nodes = appendChildren(d.Nodes, 1, []Node{h})
+		d.updateOutline()
+		return nil
+	}
+	return d.editHeadline(index, func(target Headline) []Node { return place(h, target, placement) })
+}
+
+// DeleteSubtree removes the headline (and its children) from the document and returns it.
+func (d *Document) DeleteSubtree(index int) (Headline, error) {
+	deleted := Headline{}
+	err := d.editHeadline(index, func(h Headline) []Node {
+		deleted = h
+		return nil
+	})
+	return deleted, err
+}
+
+// MoveSubtree moves the headline with the given index (and its children) to placement relative to the headline target.
+// A subtree cannot be moved relative to itself or its descendants.
+func (d *Document) MoveSubtree(index, target int, placement Placement) error {
+	s := d.Outline.findByIndex(index)
+	if s == nil || s.Headline == nil {
+		return fmt.Errorf("headline %d does not exist", index)
+	} else if s.findByIndex(target) != nil {
+		return fmt.Errorf("cannot move headline %d relative to its own subtree", index)
+	}
+	nodes, h, ok := editHeadline(d.Nodes, index, func(Headline) []Node { return nil })
+	if !ok {
+		return fmt.Errorf("headline %d does not exist", index)
+	}
+	if target == 0 && placement == PlaceLastChild {
+		d.Nodes = appendChildren(nodes, 1, []Node{h})
+		d.updateOutline()
+		return nil
+	}
+	nodes, _, ok = editHeadline(nodes, target, func(t Headline) []Node { return place(h, t, placement) })
+	if !ok {
+		return fmt.Errorf("headline %d does not exist", target)
+	}
+	d.Nodes = nodes
+	d.updateOutline()
+	return nil
+}
+
+// editHeadline replaces the headline with the given index with the nodes returned by f and updates the Outline.
+func (d *Document) editHeadline(index int, f func(Headline) []Node) error {
+	nodes, _, ok := editHeadline(d.Nodes, index, f)
+	if !ok {
+		return fmt.Errorf("headline %d does not exist", index)
+	}
+	d.Nodes = nodes
+	d.updateOutline()
+	return nil
+}
+
+// editHeadline returns a copy of nodes with the headline with the given index replaced by the nodes returned by f -
+// and the replaced headline. The nodes themselves are not modified as they might be shared with the caller.
+func editHeadline(nodes []Node, index int, f func(Headline) []Node) ([]Node, Headline, bool) {
+	for i, n := range nodes {
+		h, ok := n.(Headline)
+		if !ok || h.Index > index {
+			continue
+		} else if h.Index == index {
+			return append(append(append([]Node{}, nodes[:i]...), f(h)...), nodes[i+1:]...), h, true
+		}
+		if children, found, ok := editHeadline(h.Children, index, f); ok {
+			h.Children = children
+			return append(append(append([]Node{}, nodes[:i]...), h), nodes[i+1:]...), found, true
+		}
+	}
+	return nodes, Headline{}, false
+}
+
+// place returns the nodes replacing target once h is placed relative to it.
+func place(h, target Headline, placement Placement) []Node {
+	switch placement {
+	case PlaceBefore:
+		return []Node{relevel(h, target.Lvl), target}
+	case PlaceAfter:
+		return []Node{target, relevel(h, target.Lvl)}
+	default:
+		target.Children = appendChildren(target.Children, target.Lvl+1, []Node{h})
+		return []Node{target}
+	}
+}
+
+// appendChildren appends nodes to the children of a section with headlines of level lvl.
+// Headlines are appended at the end - all other nodes before the first headline.
+func appendChildren(children []Node, lvl int, nodes []Node) []Node {
+	i := len(children)
+	for j, n := range children {
+		if _, ok := n.(Headline); ok {
+			i = j
+			break
+		}
+	}
+	content, headlines := append([]Node{}, children[:i]...), append([]Node{}, children[i:]...)
+	for _, n := range nodes {
+		if h, ok := n.(Headline); ok {
+			headlines = append(headlines, relevel(h, lvl))
+		} else {
+			content = append(content, n)
+		}
+	}
+	return append(content, headlines...)
+}
+
+// relevel returns a copy of h (and its descendants) moved to level lvl.
+func relevel(h Headline, lvl int) Headline {
+	delta := lvl - h.Lvl
+	if delta == 0 {
+		return h
+	}
+	var shift func(nodes []Node) []Node
+	shift = func(nodes []Node) []Node {
+		out := make([]Node, len(nodes))
+		for i, n := range nodes {
+			if h, ok := n.(Headline); ok {
+				h.Lvl += delta
+				h.Children = shift(h.Children)
+				n = h
+			}
+			out[i] = n
+		}
+		return out
+	}
+	return shift([]Node{h})[0].(Headline)
+}
+
+// updateOutline renumbers the headlines in document order and rebuilds Outline and NamedNodes from d.Nodes.
+func (d *Document) updateOutline() {
+	outlineSection := &Section{}
+	d.Outline = Outline{outlineSection, outlineSection, 0}
+	d.NamedNodes = map[string]Node{}
+	d.Nodes = d.indexNodes(d.Nodes)
+}
+
+func (d *Document) indexNodes(nodes []Node) []Node {
+	nodes = append([]Node(nil), nodes...)
+	for i, n := range nodes {
+		switch n := n.(type) {
+		case Headline:
+			headline := &n
+			headline.Index = d.addHeadline(headline)
+			headline.Children = d.indexNodes(headline.Children)
+			nodes[i] = *headline
+		case NodeWithName:
+			d.NamedNodes[n.Name] = n.Node
+			d.indexNodes([]Node{n.Node})
+		default:
+			children, _ := blockChildren(n)
+			d.indexNodes(children)
+		}
+	}
+	return nodes
+}
@@ -0,0 +1,112 @@
+package org
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEditing(t *testing.T) {
+	input := "#+TODO: TODO | DONE\n\n* TODO first   :a:\n  :PROPERTIES:\n  :ID:  1\n  :END:\n  text\n** child\n   #+NAME: src\n   #+BEGIN_SRC go\n   x\n   #+END_SRC\n* second\n"
+	tests := []struct {
+		name     string
+		edit     func(d *Document) error
+		expected string
+	}{
+		{"todo", func(d *Document) error { return d.SetTodo(1, "DONE") },
+			strings.Replace(input, "* TODO first   :a:", "* DONE first"+strings.Repeat(" ", 62)+":a:", 1)},
+		{"remove todo", func(d *Document) error { return d.SetTodo(1, "") },
+			strings.Replace(input, "* TODO first   :a:", "* first"+strings.Repeat(" ", 67)+":a:", 1)},
+		{"set property", func(d *Document) error { return d.SetProperty(1, "ID", "2") },
+			strings.Replace(input, ":ID:  1", ":ID: 2", 1)},
+		{"add property", func(d *Document) error { return d.SetProperty(2, "effort", "1:00") },
+			strings.Replace(input, "** child\n", "** child\n:PROPERTIES:\n:EFFORT: 1:00\n:END:\n", 1)},
+		{"delete property", func(d *Document) error { return d.DeleteProperty(1, "id") },
+			strings.Replace(input, "  :PROPERTIES:\n  :ID:  1\n  :END:\n", "", 1)},
+		{"add tag", func(d *Document) error { return d.AddTag(3, "b") },
+			strings.Replace(input, "* second", "* second"+strings.Repeat(" ", 66)+":b:", 1)},
+		{"remove tag", func(d *Document) error { return d.RemoveTag(1, "a") },
+			strings.Replace(input, "* TODO first   :a:", "* TODO first", 1)},
+		{"append children", func(d *Document) error {
+			return d.AppendChildren(1, Paragraph{[]Node{Text{"more", false, Position{}}}, Position{}}, Headline{Lvl: 1, Title: []Node{Text{"new", false, Position{}}}})
+		}, strings.Replace(strings.Replace(input, "** child", "more\n** child", 1), "* second", "** new\n* second", 1)},
+		{"insert before", func(d *Document) error {
+			return d.InsertSubtree(2, PlaceBefore, Headline{Lvl: 5, Title: []Node{Text{"new", false, Position{}}}})
+		}, strings.Replace(input, "** child", "** new\n** child", 1)},
+		{"insert document child", func(d *Document) error {
+			return d.InsertSubtree(0, PlaceLastChild, Headline{Lvl: 3, Title: []Node{Text{"new", false, Position{}}}})
+		}, input + "* new\n"},
+		{"delete", func(d *Document) error { _, err := d.DeleteSubtree(1); return err },
+			"#+TODO: TODO | DONE\n\n* second\n"},
+		{"move", func(d *Document) error { return d.MoveSubtree(3, 2, PlaceLastChild) },
+			strings.Replace(input, "* second\n", "", 1) + "*** second\n"},
+		{"move up", func(d *Document) error { return d.MoveSubtree(2, 3, PlaceAfter) },
+			"#+TODO: TODO | DONE\n\n* TODO first   :a:\n  :PROPERTIES:\n  :ID:  1\n  :END:\n  text\n* second\n* child\n   #+NAME: src\n   #+BEGIN_SRC go\n   x\n   #+END_SRC\n"},
+	}
+	for _, test := range tests {
+		c := New().Silent()
+		c.Lossless = true
+		d := c.Parse(strings.NewReader(input), "./testdata/editing.org")
+		if err := test.edit(d); err != nil {
+			t.Errorf("%s: got error: %s", test.name, err)
+			continue
+		}
+		checkOutline(t, test.name, d)
+		if actual, err := d.Write(NewOrgWriter()); err != nil {
+			t.Errorf("%s: got error: %s", test.name, err)
+		} else if actual != test.expected {
+			t.Errorf("%s:\n%s", test.name, diff(actual, test.expected))
+		}
+	}
+}
+
+func TestEditingErrors(t *testing.T) {
+	d := New().Silent().Parse(strings.NewReader("* a\n** b\n* c\n"), "./testdata/editing.org")
+	errors := map[string]error{
+		"unknown headline": d.SetTodo(4, "TODO"),
+		"unknown keyword":  d.SetTodo(1, "WAITING"),
+		"invalid tag":      d.AddTag(1, "a b"),
+		"invalid property": d.SetProperty(1, "a:b", "c"),
+		"move into itself": d.MoveSubtree(1, 2, PlaceLastChild),
+		"unknown target":   d.MoveSubtree(3, 4, PlaceAfter),
+	}
+	for name, err := range errors {
+		if err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+	if actual, _ := d.Write(NewOrgWriter()); actual != "* a\n** b\n* c\n" {
+		t.Errorf("expected document to be unmodified, got:\n%s", actual)
+	}
+}
+
+// checkOutline checks that the Outline of d contains exactly the headlines of d.Nodes - numbered in document order.
+func checkOutline(t *testing.T, name string, d *Document) {
+	index := 0
+	var check func(nodes []Node, parent *Section)
+	check = func(nodes []Node, parent *Section) {
+		sections := []*Section{}
+		for _, n := range nodes {
+			if h, ok := n.(Headline); ok {
+				index++
+				s := d.Outline.findByIndex(index)
+				if h.Index != index || s == nil || s.Parent != parent || !reflect.DeepEqual(*s.Headline, h) {
+					t.Errorf("%s: outline does not match headline %d", name, index)
+					return
+				}
+				sections = append(sections, s)
+				check(h.Children, s)
+			}
+		}
+		if !reflect.DeepEqual(parent.Children, sections) && len(sections)+len(parent.Children) != 0 {
+			t.Errorf("%s: outline has unexpected children", name)
+		}
+	}
+	check(d.Nodes, d.Outline.Section)
+	if index != d.Outline.count {
+		t.Errorf("%s: outline has %d headlines, expected %d", name, d.Outline.count, index)
+	}
+	if out, _ := d.Write(NewOrgWriter()); strings.Contains(out, "#+NAME: src") && d.NamedNodes["src"] == nil {
+		t.Errorf("%s: expected named node src", name)
+	}
+}
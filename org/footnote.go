@@ -8,6 +8,7 @@ type FootnoteDefinition struct {
 	Name     string
 	Children []Node
 	Inline   bool
+	Position
 }
 
 var footnoteDefinitionRegexp = regexp.MustCompile(`^\[fn:([\w-]+)\](\s+(.+)|\s*$)`)
@@ -20,7 +21,7 @@ func lexFootnoteDefinition(line string) (token, bool) {
 }
 
 func (d *Document) parseFootnoteDefinition(i int, parentStop stopFn) (int, Node) {
-	start, name := i, d.tokens[i].content
+	start, startPoint, name := i, d.blockStart(i), d.tokens[i].content
 	d.tokens[i] = tokenize(d.tokens[i].matches[2])
 	stop := func(d *Document, i int) bool {
 		return parentStop(d, i) ||
@@ -28,7 +29,7 @@ func (d *Document) parseFootnoteDefinition(i int, parentStop stopFn) (int, Node)
 			d.tokens[i].kind == "headline" || d.tokens[i].kind == "footnoteDefinition"
 	}
 	consumed, nodes := d.parseMany(i, stop)
-	definition := FootnoteDefinition{name, nodes, false}
+	definition := FootnoteDefinition{name, nodes, false, Position{startPoint, d.blockEnd(start, consumed)}}
 	return consumed, definition
 }
 
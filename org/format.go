@@ -0,0 +1,130 @@
+package org
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Format returns the document as canonical org - i.e. as written by OrgWriter (tags aligned to TagsColumn, tables
+// realigned, uppercase block names) with runs of blank lines normalized and the items of ordered lists renumbered.
+// Formatting must not change the meaning of the document: an error is returned if the html of the formatted
+// document differs from the html of the document.
+func (d *Document) Format() (string, error) {
+	if d.Error != nil {
+		return "", d.Error
+	}
+	formatted := *d
+	formatted.Nodes, formatted.source = Transform(normalizeBlankLines(d.Nodes), renumberListItems), nil
+	out, err := formatted.Write(NewOrgWriter())
+	if err != nil {
+		return "", err
+	}
+	expected, err := d.Write(NewHTMLWriter())
+	if err != nil {
+		return "", err
+	}
+	c := *d.Configuration
+	actual, err := c.Silent().Parse(strings.NewReader(out), d.Path).Write(NewHTMLWriter())
+	if err != nil {
+		return "", err
+	} else if actual != expected {
+		return "", fmt.Errorf("formatting %s would change its html output", d.Path)
+	}
+	return out, nil
+}
+
+// normalizeBlankLines returns a copy of nodes in which runs of blank lines are collapsed into one - or into two after
+// lists and footnote definitions, as a second blank line ends them. Blank lines the parser attached to the end of
+// the last list item or to the start of a paragraph following a blank line are counted as part of the run.
+func normalizeBlankLines(nodes []Node) []Node {
+	out, blanks, maxBlanks := nodes[:0:0], 0, 1
+	addBlankLines := func() {
+		for i := 0; i < blanks && i < maxBlanks; i++ {
+			out = append(out, Paragraph{})
+		}
+	}
+	for _, n := range nodes {
+		n = mapChildren(n, normalizeBlankLines)
+		if isBlankLine(n) {
+			blanks++
+			continue
+		} else if p, ok := n.(Paragraph); ok && blanks != 0 && len(out) != 0 {
+			for len(p.Children) > 1 {
+				l, ok := p.Children[0].(LineBreak)
+				if !ok {
+					break
+				}
+				p.Children, blanks = p.Children[1:], blanks+l.Count
+			}
+			n = p
+		}
+		addBlankLines()
+		out, blanks, maxBlanks = append(out, n), 0, 1
+		switch n := n.(type) {
+		case List:
+			if len(n.Items) == 0 {
+				break
+			}
+			if li, ok := n.Items[len(n.Items)-1].(ListItem); ok {
+				li.Children, blanks = trimBlankLines(li.Children)
+				n.Items = append(n.Items[:len(n.Items)-1:len(n.Items)-1], li)
+			}
+			out[len(out)-1], maxBlanks = n, 2
+		case FootnoteDefinition:
+			n.Children, blanks = trimBlankLines(n.Children)
+			out[len(out)-1], maxBlanks = n, 2
+		}
+	}
+	addBlankLines()
+	return out
+}
+
+// trimBlankLines returns nodes without its trailing blank lines - and their number.
+func trimBlankLines(nodes []Node) ([]Node, int) {
+	i := len(nodes)
+	for ; i > 0 && isBlankLine(nodes[i-1]); i-- {
+	}
+	return nodes[:i:i], len(nodes) - i
+}
+
+func isBlankLine(n Node) bool {
+	p, ok := n.(Paragraph)
+	return ok && len(p.Children) == 0
+}
+
+// renumberListItems renumbers the items of ordered lists starting from 1 - or the counter of an item (e.g. [@5]).
+func renumberListItems(n Node, _ Path) []Node {
+	l, ok := n.(List)
+	if !ok || l.Kind != "ordered" {
+		return []Node{n}
+	}
+	items, counter := make([]Node, len(l.Items)), 1
+	for i, item := range l.Items {
+		if li, ok := item.(ListItem); ok {
+			if value, err := strconv.Atoi(li.Value); err == nil {
+				counter = value
+			}
+			li.Bullet = orderedListBullet(li.Bullet, counter)
+			item = li
+		}
+		items[i], counter = item, counter+1
+	}
+	l.Items = items
+	return []Node{l}
+}
+
+// orderedListBullet returns bullet with its number (or letter) replaced by the n-th number (or letter).
+// Letter bullets that would go past z are returned unchanged.
+func orderedListBullet(bullet string, n int) string {
+	delimiter, first := bullet[len(bullet)-1:], rune(bullet[0])
+	if !unicode.IsLetter(first) {
+		return strconv.Itoa(n) + delimiter
+	} else if n > 26 {
+		return bullet
+	} else if unicode.IsUpper(first) {
+		return string(rune('A'+n-1)) + delimiter
+	}
+	return string(rune('a'+n-1)) + delimiter
+}
@@ -0,0 +1,37 @@
+package org
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	cases := []struct{ input, expected string }{
+		{
+			"*   headline :tag:\n#+begin_quote\nquoted\n#+end_quote\n\n\n\n| a | bb |\n|-+-|\n| ccc | d |\n\n" +
+				"3. one\n7. two\n   b) nested\n   a) nested\n1. [@5] five\n2. six\n",
+			"* headline                                                              :tag:\n#+BEGIN_QUOTE\nquoted\n#+END_QUOTE\n\n" +
+				"| a   | bb |\n|-----+----|\n| ccc | d  |\n\n1. one\n2. two\n   a) nested\n   b) nested\n5. [@5] five\n6. six\n",
+		},
+		{"a\n\n\n\nb\n", "a\n\nb\n"},
+		{"- a\n- b\n\n\n\nx\n", "- a\n- b\n\n\nx\n"},
+		{"- a\n\n\n\n  more\n- b\n", "- a\n\n\n  more\n- b\n"},
+		{"#+BEGIN_SRC\na\n\n\n\nb\n#+END_SRC\n", "#+BEGIN_SRC\na\n\n\n\nb\n#+END_SRC\n"},
+	}
+	for _, c := range cases {
+		if actual, err := New().Silent().Parse(strings.NewReader(c.input), "./testdata/format.org").Format(); err != nil || actual != c.expected {
+			t.Errorf("%q: got error %v or:\n%s", c.input, err, diff(actual, c.expected))
+		}
+	}
+
+	for _, path := range orgTestFiles() {
+		formatted, err := New().Silent().Parse(strings.NewReader(fileString(path)), path).Format()
+		if err != nil {
+			t.Errorf("%s: %s", path, err)
+			continue
+		}
+		if reformatted, err := New().Silent().Parse(strings.NewReader(formatted), path).Format(); err != nil || reformatted != formatted {
+			t.Errorf("%s: expected formatting to be idempotent (%v):\n%s", path, err, diff(reformatted, formatted))
+		}
+	}
+}
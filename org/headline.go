@@ -3,6 +3,7 @@ package org
 import (
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
 	"unicode"
 )
@@ -24,14 +25,29 @@ type Headline struct {
 	Lvl        int
 	Status     string
 	Priority   string
+	Planning   *Planning
 	Properties *PropertyDrawer
 	Title      []Node
 	Tags       []string
 	Children   []Node
+	Position
+}
+
+// Planning contains the SCHEDULED, DEADLINE and CLOSED timestamps of a headline in the order they were written.
+type Planning struct {
+	Entries []PlanningEntry
+	Position
+}
+
+type PlanningEntry struct {
+	Keyword   string
+	Timestamp Timestamp
 }
 
 var headlineRegexp = regexp.MustCompile(`^([*]+)\s+(.*)`)
 var tagRegexp = regexp.MustCompile(`(.*?)\s+(:[A-Za-z0-9_@#%:]+:\s*$)`)
+var planningLineRegexp = regexp.MustCompile(`^(\s*(SCHEDULED|DEADLINE|CLOSED):\s*(<[^>]+>(--<[^>]+>)?|\[[^\]]+\](--\[[^\]]+\])?))+\s*$`)
+var planningEntryRegexp = regexp.MustCompile(`(SCHEDULED|DEADLINE|CLOSED):\s*(<[^>]+>(?:--<[^>]+>)?|\[[^\]]+\](?:--\[[^\]]+\])?)`)
 
 func lexHeadline(line string) (token, bool) {
 	if m := headlineRegexp.FindStringSubmatch(line); m != nil {
@@ -47,33 +63,42 @@ func (d *Document) parseHeadline(i int, parentStop stopFn) (int, Node) {
 	headline.Index = d.addHeadline(&headline)
 
 	text := t.content
-	todoKeywords := trimFastTags(
-		strings.FieldsFunc(d.Get("TODO"), func(r rune) bool { return unicode.IsSpace(r) || r == '|' }),
-	)
-	for _, k := range todoKeywords {
-		if strings.HasPrefix(text, k) && len(text) > len(k) && unicode.IsSpace(rune(text[len(k)])) {
-			headline.Status = k
-			text = text[len(k)+1:]
+	for _, s := range d.TodoSequences() {
+		for _, k := range s.Keywords() {
+			if strings.HasPrefix(text, k.Name) && len(text) > len(k.Name) && unicode.IsSpace(rune(text[len(k.Name)])) {
+				headline.Status = k.Name
+				text = text[len(k.Name)+1:]
+				break
+			}
+		}
+		if headline.Status != "" {
 			break
 		}
 	}
 
-	if len(text) >= 4 && text[0:2] == "[#" && strings.Contains("ABC", text[2:3]) && text[3] == ']' {
-		headline.Priority = text[2:3]
-		text = strings.TrimSpace(text[4:])
+	if m := priorityRegexp.FindStringSubmatch(text); m != nil && d.Priorities().Contains(m[1]) {
+		headline.Priority = m[1]
+		text = strings.TrimSpace(text[len(m[0]):])
 	}
 
+	titleColumn := d.suffixColumn(i, text)
 	if m := tagRegexp.FindStringSubmatch(text); m != nil {
 		text = m[1]
 		headline.Tags = strings.FieldsFunc(m[2], func(r rune) bool { return r == ':' })
 	}
 
-	headline.Title = d.parseInline(text)
+	headline.Title = d.parseInlineFrom(text, d.lineSourceMap(i, []string{text}, []int{titleColumn}))
 
 	stop := func(d *Document, i int) bool {
 		return parentStop(d, i) || d.tokens[i].kind == "headline" && len(d.tokens[i].matches[1]) <= headline.Lvl
 	}
-	consumed, nodes := d.parseMany(i+1, stop)
+	start := i + 1
+	if consumed, planning := d.parsePlanning(start, stop); consumed != 0 {
+		headline.Planning = &planning
+		start += consumed
+	}
+	consumed, nodes := d.parseMany(start, stop)
+	consumed += start - (i + 1)
 	if len(nodes) > 0 {
 		if d, ok := nodes[0].(PropertyDrawer); ok {
 			headline.Properties = &d
@@ -81,22 +106,58 @@ func (d *Document) parseHeadline(i int, parentStop stopFn) (int, Node) {
 		}
 	}
 	headline.Children = nodes
+	headline.Position = d.blockPosition(i, consumed+1)
 	return consumed + 1, headline
 }
 
-func trimFastTags(tags []string) []string {
-	trimmedTags := make([]string, len(tags))
-	for i, t := range tags {
-		lParen := strings.LastIndex(t, "(")
-		rParen := strings.LastIndex(t, ")")
-		end := len(t) - 1
-		if lParen == end-2 && rParen == end {
-			trimmedTags[i] = t[:end-2]
-		} else {
-			trimmedTags[i] = t
+func (d *Document) parsePlanning(i int, stop stopFn) (int, Planning) {
+	planning := Planning{}
+	if i >= len(d.tokens) || stop(d, i) || d.tokens[i].kind != "text" || !planningLineRegexp.MatchString(d.tokens[i].content) {
+		return 0, planning
+	}
+	for _, m := range planningEntryRegexp.FindAllStringSubmatchIndex(d.tokens[i].content, -1) {
+		content := d.tokens[i].content
+		keyword, rawTimestamp := content[m[2]:m[3]], content[m[4]:m[5]]
+		consumed, node := d.parseTimestamp(rawTimestamp, 0)
+		if consumed != len(rawTimestamp) {
+			return 0, planning
 		}
+		timestamp := node.(Timestamp)
+		col := d.suffixColumn(i, content) + m[4]
+		timestamp.Position = Position{d.point(i, col), d.point(i, col+len(rawTimestamp))}
+		planning.Entries = append(planning.Entries, PlanningEntry{keyword, timestamp})
 	}
-	return trimmedTags
+	planning.Position = d.blockPosition(i, 1)
+	return 1, planning
+}
+
+// Get returns the timestamp for the planning keyword (SCHEDULED, DEADLINE or CLOSED).
+func (p *Planning) Get(keyword string) (Timestamp, bool) {
+	if p == nil {
+		return Timestamp{}, false
+	}
+	for _, e := range p.Entries {
+		if e.Keyword == keyword {
+			return e.Timestamp, true
+		}
+	}
+	return Timestamp{}, false
+}
+
+// Scheduled returns the SCHEDULED timestamp of the planning or nil.
+func (p *Planning) Scheduled() *Timestamp { return p.get("SCHEDULED") }
+
+// Deadline returns the DEADLINE timestamp of the planning or nil.
+func (p *Planning) Deadline() *Timestamp { return p.get("DEADLINE") }
+
+// Closed returns the CLOSED timestamp of the planning or nil.
+func (p *Planning) Closed() *Timestamp { return p.get("CLOSED") }
+
+func (p *Planning) get(keyword string) *Timestamp {
+	if t, ok := p.Get(keyword); ok {
+		return &t
+	}
+	return nil
 }
 
 func (h Headline) ID() string {
@@ -106,10 +167,104 @@ func (h Headline) ID() string {
 	return fmt.Sprintf("headline-%d", h.Index)
 }
 
+// IsExcluded returns true if the headline has (or inherits) one of the tags in #+EXCLUDE_TAGS (see Section.InheritedTags).
 func (h Headline) IsExcluded(d *Document) bool {
-	for _, excludedTag := range strings.Fields(d.Get("EXCLUDE_TAGS")) {
-		for _, tag := range h.Tags {
-			if tag == excludedTag {
+	if s := d.Outline.findByIndex(h.Index); s != nil {
+		return s.IsExcluded(d)
+	}
+	return hasAnyTag(h.Tags, strings.Fields(d.Get("EXCLUDE_TAGS")))
+}
+
+// IsExcluded returns true if the headline of s has (or inherits) one of the tags in #+EXCLUDE_TAGS.
+func (s *Section) IsExcluded(d *Document) bool {
+	return s.Headline != nil && hasAnyTag(s.InheritedTags(d), strings.Fields(d.Get("EXCLUDE_TAGS")))
+}
+
+// InheritedTags returns the tags of the headline of s including the tags it inherits from
+// #+FILETAGS and its ancestors - in that order and without duplicates.
+// Tags listed in Configuration.TagsExcludeFromInheritance are not inherited.
+func (s *Section) InheritedTags(d *Document) []string {
+	excluded := d.TagsExcludeFromInheritance
+	ancestors := []*Section{}
+	for p := s; p != nil && p.Headline != nil; p = p.Parent {
+		ancestors = append([]*Section{p}, ancestors...)
+	}
+	tags := []string{}
+	add := func(tag string, inherited bool) {
+		if tag != "" && !hasAnyTag(tags, []string{tag}) && (!inherited || !hasAnyTag(excluded, []string{tag})) {
+			tags = append(tags, tag)
+		}
+	}
+	for _, tag := range strings.FieldsFunc(d.Get("FILETAGS"), func(r rune) bool { return r == ':' || unicode.IsSpace(r) }) {
+		add(tag, true)
+	}
+	for _, p := range ancestors {
+		for _, tag := range p.Headline.Tags {
+			add(tag, p != s)
+		}
+	}
+	return tags
+}
+
+// InheritedProperty returns the value of the property key (case insensitive) for the headline of s.
+// Like Org mode, only the properties listed in Configuration.InheritedProperties (see org-use-property-inheritance)
+// are inherited from #+PROPERTY lines and the property drawers of ancestors; the closest definition wins
+// and KEY+ definitions append to the inherited value.
+func (s *Section) InheritedProperty(d *Document, key string) (string, bool) {
+	return s.property(d, key, d.inheritsProperty(key))
+}
+
+// AllowedValues returns the allowed values for the property key of the headline of s as defined by the KEY_ALL property.
+// Like in Org mode, KEY_ALL is always inherited.
+func (s *Section) AllowedValues(d *Document, key string) []string {
+	v, _ := s.property(d, key+"_ALL", true)
+	return strings.Fields(v)
+}
+
+func (s *Section) property(d *Document, key string, inherit bool) (string, bool) {
+	value, ok := "", false
+	set := func(k, v string) {
+		if strings.EqualFold(k, key) {
+			value, ok = v, true
+		} else if strings.EqualFold(k, key+"+") {
+			value, ok = strings.TrimSpace(value+" "+v), true
+		}
+	}
+	sections := []*Section{s}
+	if inherit {
+		for _, line := range strings.Split(d.Get("PROPERTY"), "\n") {
+			if kv := strings.SplitN(strings.TrimSpace(line), " ", 2); len(kv) == 2 {
+				set(kv[0], strings.TrimSpace(kv[1]))
+			}
+		}
+		sections = []*Section{}
+		for p := s; p != nil && p.Headline != nil; p = p.Parent {
+			sections = append([]*Section{p}, sections...)
+		}
+	}
+	for _, p := range sections {
+		if p.Headline != nil && p.Headline.Properties != nil {
+			for _, kv := range p.Headline.Properties.Properties {
+				set(kv[0], kv[1])
+			}
+		}
+	}
+	return value, ok
+}
+
+func (d *Document) inheritsProperty(key string) bool {
+	for _, k := range d.InheritedProperties {
+		if strings.EqualFold(k, key) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAnyTag(tags, candidates []string) bool {
+	for _, tag := range tags {
+		for _, candidate := range candidates {
+			if tag == candidate {
 				return true
 			}
 		}
@@ -126,4 +281,21 @@ func (parent *Section) add(current *Section) {
 	}
 }
 
+// findByIndex returns the section of the headline with the given index in the subtree of s - or nil.
+// As headline indexes increase in document order, only the child with the largest index not greater than index is searched.
+func (s *Section) findByIndex(index int) *Section {
+	for s != nil {
+		if s.Headline != nil && s.Headline.Index == index {
+			return s
+		}
+		i := sort.Search(len(s.Children), func(i int) bool { return s.Children[i].Headline.Index > index }) - 1
+		if i < 0 {
+			return nil
+		}
+		s = s.Children[i]
+	}
+	return nil
+}
+
 func (n Headline) String() string { return orgWriter.WriteNodesAsString(n) }
+func (n Planning) String() string { return orgWriter.WriteNodesAsString(n) }
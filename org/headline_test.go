@@ -0,0 +1,128 @@
+package org
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInheritance(t *testing.T) {
+	path, c := "./testdata/inheritance.org", New().Silent()
+	c.InheritedProperties, c.TagsExcludeFromInheritance = []string{"header-args", "OWNER"}, []string{"project"}
+	d := c.Parse(strings.NewReader(fileString(path)), path)
+	project, exported := d.Outline.Children[0], d.Outline.Children[1]
+	subtask, child := project.Children[0], exported.Children[0]
+	tags := map[*Section][]string{
+		project:  {"notes", "project", "noexport"},
+		subtask:  {"notes", "noexport"},
+		exported: {"notes", "project"},
+		child:    {"notes"},
+	}
+	for s, expected := range tags {
+		if actual := s.InheritedTags(d); !reflect.DeepEqual(actual, expected) {
+			t.Errorf("%s: got tags %v, expected %v", String(s.Headline.Title), actual, expected)
+		}
+	}
+	if !subtask.IsExcluded(d) || !subtask.Headline.IsExcluded(d) || exported.IsExcluded(d) {
+		t.Errorf("expected subtask (and only subtask) to inherit noexport")
+	}
+	properties := []struct {
+		section       *Section
+		key, expected string
+	}{
+		{subtask, "header-args", ":results silent"},
+		{child, "OWNER", "alice bob"},
+		{exported, "owner", "alice"},
+	}
+	for _, p := range properties {
+		if actual, ok := p.section.InheritedProperty(d, p.key); !ok || actual != p.expected {
+			t.Errorf("%s %s: got %q, expected %q", String(p.section.Headline.Title), p.key, actual, p.expected)
+		}
+	}
+	if _, ok := subtask.InheritedProperty(d, "owner"); ok {
+		t.Errorf("expected subtask to not have an owner")
+	}
+	if actual := child.AllowedValues(d, "Effort"); !reflect.DeepEqual(actual, []string{"0:10", "0:30", "1:00"}) {
+		t.Errorf("got allowed values %v", actual)
+	}
+
+	d = New().Silent().Parse(strings.NewReader(fileString(path)), path)
+	subtask, child = d.Outline.Children[0].Children[0], d.Outline.Children[1].Children[0]
+	if _, ok := subtask.InheritedProperty(d, "header-args"); ok {
+		t.Errorf("expected properties to not be inherited by default")
+	}
+	if actual, _ := child.InheritedProperty(d, "owner"); actual != "bob" {
+		t.Errorf("expected only the own owner of child by default, got %q", actual)
+	}
+	d = New().Silent().Parse(strings.NewReader("* a\n:PROPERTIES:\n:CUSTOM_ID: x\n:END:\n** b\n"), "./match.org")
+	if sections, err := d.Match(`CUSTOM_ID="x"`); err != nil || len(sections) != 1 || String(sections[0].Headline.Title) != "a" {
+		t.Errorf("expected CUSTOM_ID to not be inherited, got %v %v", sections, err)
+	}
+}
+
+func TestTodoSequences(t *testing.T) {
+	input := `#+TODO: TODO(t) WAIT(w@/!) | DONE(d!) CANCELED(c@)
+#+TODO: REPORT BUG KNOWNCAUSE | FIXED
+#+TYP_TODO: Fred Sara
+* WAIT waiting
+* FIXED fixed
+* Sara assigned
+* Fred assigned
+* DONE done
+* TODOS not a keyword
+`
+	d := New().Silent().Parse(strings.NewReader(input), "./todo.org")
+	expected := []TodoSequence{
+		{"sequence", []TodoKeyword{{"TODO", "t", ""}, {"WAIT", "w", "@/!"}}, []TodoKeyword{{"DONE", "d", "!"}, {"CANCELED", "c", "@"}}},
+		{"sequence", []TodoKeyword{{"REPORT", "", ""}, {"BUG", "", ""}, {"KNOWNCAUSE", "", ""}}, []TodoKeyword{{"FIXED", "", ""}}},
+		{"type", []TodoKeyword{{"Fred", "", ""}}, []TodoKeyword{{"Sara", "", ""}}},
+	}
+	if actual := d.TodoSequences(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("got sequences\n%#v\nexpected\n%#v", actual, expected)
+	}
+	statuses := []string{}
+	for _, s := range d.Outline.Children {
+		statuses = append(statuses, fmt.Sprintf("%s:%v", s.Headline.Status, d.IsDone(*s.Headline)))
+	}
+	if actual, expected := strings.Join(statuses, " "), "WAIT:false FIXED:true Sara:true Fred:false DONE:true :false"; actual != expected {
+		t.Errorf("got statuses %q, expected %q", actual, expected)
+	}
+	if d := New().Silent().Parse(strings.NewReader("* DONE done\n"), "./todo.org"); !d.IsDone(*d.Outline.Children[0].Headline) {
+		t.Errorf("expected default TODO setting to have DONE as done keyword")
+	}
+	if h := (Headline{Status: "DONE"}); !New().Silent().Parse(strings.NewReader(""), "./todo.org").IsDone(h) {
+		t.Errorf("expected headlines that were not parsed to be done based on their status")
+	}
+	d = New().Silent().ParseHTML(strings.NewReader(`<h2><span class="done FIXED">FIXED</span> fixed</h2>`), "./todo.html")
+	if h := d.Outline.Children[0].Headline; h.Status != "FIXED" || !d.IsDone(*h) {
+		t.Errorf("expected headlines read from html to be done based on their status, got %#v", h)
+	}
+}
+
+func TestPriorities(t *testing.T) {
+	input := `#+PRIORITIES: 1 20 5
+* [#1] highest
+* [#12] numeric
+* [#21] out of range
+* [#A] letter
+* no priority
+`
+	d := New().Silent().Parse(strings.NewReader(input), "./priorities.org")
+	if p := d.Priorities(); p != (Priorities{"1", "20", "5"}) {
+		t.Errorf("got priorities %#v", p)
+	}
+	expected := []struct{ priority, effective string }{{"1", "1"}, {"12", "12"}, {"", "5"}, {"", "5"}, {"", "5"}}
+	for i, s := range d.Outline.Children {
+		h := s.Headline
+		if h.Priority != expected[i].priority || h.EffectivePriority(d) != expected[i].effective {
+			t.Errorf("%s: got priority %q (effective %q), expected %q (%q)", String(h.Title), h.Priority, h.EffectivePriority(d), expected[i].priority, expected[i].effective)
+		}
+	}
+	if ranks := []int{d.Priorities().Rank("1"), d.Priorities().Rank("12"), d.Priorities().Rank("A")}; !reflect.DeepEqual(ranks, []int{0, 11, 4}) {
+		t.Errorf("got ranks %v", ranks)
+	}
+	if p := New().Silent().Parse(strings.NewReader("#+PRIORITIES: C A B\n"), "./priorities.org").Priorities(); p != (Priorities{"A", "C", "B"}) {
+		t.Errorf("expected invalid priorities to fall back to the default: %#v", p)
+	}
+}
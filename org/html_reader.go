@@ -0,0 +1,651 @@
+package org
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	h "golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// htmlReader converts HTML into the nodes the org parser creates for the equivalent Org mode input.
+type htmlReader struct {
+	d          *Document
+	offset     int      // offset is subtracted from the level of html headings (h1-h6) to get the level of the headline.
+	todo, done []string // todo and done are the todo keywords of the headlines that are not known to the document.
+}
+
+var htmlWhitespaceRegexp = regexp.MustCompile(`[ \t\n\r\f]+`)
+var htmlHeadlineIDRegexp = regexp.MustCompile(`^headline-\d+$`)
+var htmlBlockClassRegexp = regexp.MustCompile(`^(\w+)-block$`)
+var htmlLanguageClassRegexp = regexp.MustCompile(`^(?:src|language|lang)-(\S+)$`)
+
+var htmlEmphasisKinds = map[atom.Atom]string{
+	atom.Em:     "/",
+	atom.I:      "/",
+	atom.Strong: "*",
+	atom.B:      "*",
+	atom.Del:    "+",
+	atom.S:      "+",
+	atom.Strike: "+",
+	atom.U:      "_",
+	atom.Ins:    "_",
+	atom.Kbd:    "=",
+	atom.Samp:   "=",
+	atom.Tt:     "=",
+	atom.Sub:    "_{}",
+	atom.Sup:    "^{}",
+}
+
+var htmlListItemStatuses = map[string]string{
+	"unchecked":     " ",
+	"indeterminate": "-",
+	"checked":       "X",
+}
+
+var htmlBlockElements = map[atom.Atom]bool{
+	atom.Address: true, atom.Article: true, atom.Aside: true, atom.Blockquote: true, atom.Body: true,
+	atom.Center: true, atom.Details: true, atom.Dialog: true, atom.Div: true, atom.Dl: true, atom.Fieldset: true,
+	atom.Figure: true, atom.Footer: true, atom.Form: true, atom.H1: true, atom.H2: true, atom.H3: true,
+	atom.H4: true, atom.H5: true, atom.H6: true, atom.Head: true, atom.Header: true, atom.Hr: true,
+	atom.Main: true, atom.Nav: true, atom.Noscript: true, atom.Ol: true, atom.P: true, atom.Pre: true,
+	atom.Script: true, atom.Section: true, atom.Style: true, atom.Table: true, atom.Template: true,
+	atom.Title: true, atom.Ul: true,
+}
+
+// ParseHTML parses HTML input into a Document. The document consists of the same nodes that Parse creates
+// for the equivalent Org mode input - e.g. to convert HTML into Org mode using OrgWriter.
+// Headings, paragraphs, lists, tables, code blocks, quotes, links, images and inline markup are mapped onto
+// their Org mode counterparts; the markup HTMLWriter creates (e.g. todo keywords, tags, footnotes) is recognized.
+// Relative links to html files are converted into file links to the org file of the same name.
+// To allow method chaining, errors are stored in document.Error rather than being returned.
+func (c *Configuration) ParseHTML(input io.Reader, path string) (d *Document) {
+	outlineSection := &Section{}
+	d = &Document{
+		Configuration:  c,
+		Outline:        Outline{outlineSection, outlineSection, 0},
+		BufferSettings: map[string]string{},
+		NamedNodes:     map[string]Node{},
+		Links:          map[string]string{},
+		Macros:         map[string]string{},
+		Path:           path,
+	}
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			d.setError(fmt.Errorf("could not parse html: %v", recovered))
+		}
+	}()
+	root, err := h.Parse(input)
+	if err != nil {
+		d.setError(err)
+		return d
+	}
+	body := findHTMLElement(root, atom.Body)
+	if body == nil {
+		return d
+	}
+	r := &htmlReader{d: d, offset: htmlHeadingOffset(body)}
+	nodes, index := r.blocks(body), 0
+	if keyword, ok := r.todoKeywords(); ok {
+		d.BufferSettings["TODO"] = keyword.Value
+		nodes = append([]Node{keyword}, nodes...)
+	}
+	d.Nodes = d.addSections(nestSections(nodes, &index))
+	return d
+}
+
+// blocks converts the children of n into block nodes. Consecutive inline content is grouped into paragraphs.
+func (r *htmlReader) blocks(n *h.Node) []Node {
+	nodes, inline := []Node{}, []Node{}
+	add := func(ns ...Node) {
+		if len(ns) == 0 {
+			return
+		} else if len(nodes) != 0 {
+			if _, ok := nodes[len(nodes)-1].(Paragraph); !ok || !isHTMLList(ns[0]) {
+				nodes = append(nodes, Paragraph{})
+			}
+		}
+		nodes = append(nodes, ns...)
+	}
+	flush := func() {
+		if children := trimHTMLInline(inline); len(children) != 0 {
+			add(Paragraph{children, Position{}})
+		}
+		inline = nil
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == h.ElementNode && htmlBlockElements[c.DataAtom] {
+			flush()
+			add(r.block(c)...)
+		} else {
+			inline = append(inline, r.inline(c)...)
+		}
+	}
+	flush()
+	return nodes
+}
+
+func (r *htmlReader) block(n *h.Node) []Node {
+	switch n.DataAtom {
+	case atom.Head, atom.Nav, atom.Noscript, atom.Script, atom.Style, atom.Template, atom.Title:
+		return nil
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		return []Node{r.heading(n)}
+	case atom.P:
+		if children := trimHTMLInline(r.inlineChildren(n)); len(children) != 0 {
+			return []Node{Paragraph{children, Position{}}}
+		}
+		return nil
+	case atom.Pre:
+		return []Node{r.pre(n)}
+	case atom.Blockquote:
+		return []Node{Block{"QUOTE", nil, r.blocks(n), nil, Position{}}}
+	case atom.Center:
+		return []Node{Block{"CENTER", nil, r.blocks(n), nil, Position{}}}
+	case atom.Ul, atom.Ol:
+		return []Node{r.list(n)}
+	case atom.Dl:
+		return []Node{r.descriptiveList(n)}
+	case atom.Table:
+		return []Node{r.table(n)}
+	case atom.Figure:
+		return r.figure(n)
+	case atom.Hr:
+		if hasHTMLClass(n, "footnotes-separatator") {
+			return nil
+		}
+		return []Node{HorizontalRule{}}
+	case atom.Div:
+		return r.div(n)
+	default:
+		return r.blocks(n)
+	}
+}
+
+func (r *htmlReader) heading(n *h.Node) Node {
+	if n.DataAtom == atom.H1 && hasHTMLClass(n, "title") {
+		title := String(spaceHTMLLineBreaks(trimHTMLInline(r.inlineChildren(n))))
+		r.d.BufferSettings["TITLE"] = title
+		return Keyword{"TITLE", title, Position{}}
+	}
+	lvl := int(n.Data[1]-'0') - r.offset
+	if lvl < 1 {
+		lvl = 1
+	}
+	headline, title := Headline{Lvl: lvl}, []Node{}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		switch {
+		case c.DataAtom == atom.Span && (hasHTMLClass(c, "todo") || hasHTMLClass(c, "done")):
+			headline.Status = strings.TrimSpace(htmlText(c))
+			r.addTodoKeyword(headline.Status, hasHTMLClass(c, "done"))
+		case c.DataAtom == atom.Span && hasHTMLClass(c, "priority"):
+			headline.Priority = strings.Trim(strings.TrimSpace(htmlText(c)), "[]")
+		case c.DataAtom == atom.Span && hasHTMLClass(c, "tags"):
+			for t := c.FirstChild; t != nil; t = t.NextSibling {
+				if tag := strings.TrimSpace(htmlText(t)); t.Type == h.ElementNode && tag != "" {
+					headline.Tags = append(headline.Tags, tag)
+				}
+			}
+		default:
+			title = append(title, r.inline(c)...)
+		}
+	}
+	headline.Title = spaceHTMLLineBreaks(trimHTMLInline(title))
+	if id := getHTMLAttribute(n, "id"); id != "" && !htmlHeadlineIDRegexp.MatchString(id) {
+		headline.Properties = &PropertyDrawer{[][]string{{"CUSTOM_ID", id}}, Position{}}
+	}
+	return headline
+}
+
+func (r *htmlReader) addTodoKeyword(status string, isDone bool) {
+	for _, k := range append(append([]string{}, r.todo...), r.done...) {
+		if k == status {
+			return
+		}
+	}
+	if isDone {
+		r.done = append(r.done, status)
+	} else {
+		r.todo = append(r.todo, status)
+	}
+}
+
+// todoKeywords returns a #+TODO keyword for the todo keywords of the headlines if they are not known to the
+// document (i.e. are not part of the default TODO setting).
+func (r *htmlReader) todoKeywords() (Keyword, bool) {
+	for i, ks := range [][]string{r.todo, r.done} {
+		for _, k := range ks {
+			if _, isDone, ok := r.d.TodoKeyword(k); !ok || isDone != (i == 1) {
+				return Keyword{"TODO", strings.Join(append(append(r.todo, "|"), r.done...), " "), Position{}}, true
+			}
+		}
+	}
+	return Keyword{}, false
+}
+
+func (r *htmlReader) pre(n *h.Node) Node {
+	if code := n.FirstChild; code != nil && code.NextSibling == nil && code.DataAtom == atom.Code {
+		for _, class := range strings.Fields(getHTMLAttribute(code, "class")) {
+			if m := htmlLanguageClassRegexp.FindStringSubmatch(class); m != nil {
+				return r.rawBlock("SRC", []string{m[1]}, htmlText(n))
+			}
+		}
+	}
+	return r.rawBlock("EXAMPLE", nil, htmlText(n))
+}
+
+func (r *htmlReader) rawBlock(name string, parameters []string, content string) Block {
+	rawText := strings.Trim(content, "\n")
+	if rawText != "" {
+		rawText += "\n"
+	}
+	return Block{name, parameters, r.d.parseRawInlineFrom(rawText, nil), nil, Position{}}
+}
+
+func (r *htmlReader) div(n *h.Node) []Node {
+	switch {
+	case hasHTMLClass(n, "src"):
+		var parameters []string
+		for _, class := range strings.Fields(getHTMLAttribute(n, "class")) {
+			if m := htmlLanguageClassRegexp.FindStringSubmatch(class); m != nil {
+				parameters = []string{m[1]}
+			}
+		}
+		pre := findHTMLElement(n, atom.Pre)
+		if pre == nil {
+			return r.blocks(n)
+		}
+		return []Node{r.rawBlock("SRC", parameters, htmlText(pre))}
+	case hasHTMLClass(n, "footnotes"):
+		return r.footnoteDefinitions(n)
+	}
+	for _, class := range strings.Fields(getHTMLAttribute(n, "class")) {
+		if m := htmlBlockClassRegexp.FindStringSubmatch(class); m != nil {
+			return []Node{Block{strings.ToUpper(m[1]), nil, r.blocks(n), nil, Position{}}}
+		}
+	}
+	return r.blocks(n)
+}
+
+// footnoteDefinitions converts the footnotes section created by HTMLWriter into footnote definitions.
+func (r *htmlReader) footnoteDefinitions(n *h.Node) []Node {
+	nodes := []Node{}
+	var walk func(*h.Node)
+	walk = func(n *h.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if !hasHTMLClass(c, "footnote-definition") {
+				walk(c)
+				continue
+			}
+			definition := FootnoteDefinition{}
+			for d := c.FirstChild; d != nil; d = d.NextSibling {
+				if d.DataAtom == atom.Sup {
+					definition.Name = strings.TrimSpace(htmlText(d))
+				} else if hasHTMLClass(d, "footnote-body") {
+					definition.Children = r.blocks(d)
+				}
+			}
+			if len(nodes) != 0 {
+				nodes = append(nodes, Paragraph{})
+			}
+			nodes = append(nodes, definition)
+		}
+	}
+	walk(n)
+	return nodes
+}
+
+func (r *htmlReader) list(n *h.Node) Node {
+	list, number := List{Kind: "unordered"}, 1
+	if n.DataAtom == atom.Ol {
+		list.Kind = "ordered"
+		if start, err := strconv.Atoi(getHTMLAttribute(n, "start")); err == nil {
+			number = start
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.DataAtom != atom.Li {
+			continue
+		}
+		item := ListItem{Bullet: "-", Status: htmlListItemStatus(c), Children: r.blocks(c)}
+		if list.Kind == "ordered" {
+			if value, err := strconv.Atoi(getHTMLAttribute(c, "value")); err == nil {
+				number = value
+			}
+			if len(list.Items) == 0 && number != 1 || getHTMLAttribute(c, "value") != "" {
+				item.Value = strconv.Itoa(number)
+			}
+			item.Bullet = strconv.Itoa(number) + "."
+			number++
+		}
+		list.Items = append(list.Items, item)
+	}
+	return list
+}
+
+func (r *htmlReader) descriptiveList(n *h.Node) Node {
+	list := List{Kind: "descriptive"}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		switch c.DataAtom {
+		case atom.Dt:
+			term := spaceHTMLLineBreaks(trimHTMLInline(r.inlineChildren(c)))
+			list.Items = append(list.Items, DescriptiveListItem{"-", htmlListItemStatus(c), term, nil, Position{}})
+		case atom.Dd:
+			if len(list.Items) == 0 {
+				list.Items = append(list.Items, DescriptiveListItem{Bullet: "-"})
+			}
+			item := list.Items[len(list.Items)-1].(DescriptiveListItem)
+			item.Details = append(item.Details, r.blocks(c)...)
+			list.Items[len(list.Items)-1] = item
+		}
+	}
+	return list
+}
+
+// table converts n into a table. Row groups (thead, tbody, tfoot) are separated by separator rows.
+func (r *htmlReader) table(n *h.Node) Node {
+	rows, caption := [][][]Node{}, []Node(nil)
+	var walk func(*h.Node)
+	walk = func(n *h.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			switch c.DataAtom {
+			case atom.Caption:
+				caption = spaceHTMLLineBreaks(trimHTMLInline(r.inlineChildren(c)))
+			case atom.Thead, atom.Tbody, atom.Tfoot:
+				if len(rows) != 0 && rows[len(rows)-1] != nil {
+					rows = append(rows, nil)
+				}
+				walk(c)
+			case atom.Tr:
+				row := [][]Node{}
+				for cell := c.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.DataAtom == atom.Td || cell.DataAtom == atom.Th {
+						row = append(row, spaceHTMLLineBreaks(trimHTMLInline(r.inlineChildren(cell))))
+					}
+				}
+				rows = append(rows, row)
+			}
+		}
+	}
+	walk(n)
+	for len(rows) != 0 && rows[len(rows)-1] == nil {
+		rows = rows[:len(rows)-1]
+	}
+	if len(caption) != 0 {
+		return NodeWithMeta{newTable(rows), Metadata{Caption: [][]Node{caption}}, Position{}}
+	}
+	return newTable(rows)
+}
+
+func (r *htmlReader) figure(n *h.Node) []Node {
+	nodes, caption := []Node{}, []Node(nil)
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.DataAtom == atom.Figcaption {
+			caption = spaceHTMLLineBreaks(trimHTMLInline(r.inlineChildren(c)))
+		} else if c.Type == h.ElementNode && htmlBlockElements[c.DataAtom] {
+			nodes = append(nodes, r.block(c)...)
+		} else if children := trimHTMLInline(r.inline(c)); len(children) != 0 {
+			nodes = append(nodes, Paragraph{children, Position{}})
+		}
+	}
+	if len(caption) == 0 {
+		return nodes
+	} else if len(nodes) != 1 {
+		return append(nodes, Paragraph{caption, Position{}})
+	}
+	return []Node{NodeWithMeta{nodes[0], Metadata{Caption: [][]Node{caption}}, Position{}}}
+}
+
+func (r *htmlReader) inlineChildren(n *h.Node) []Node {
+	nodes := []Node{}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		nodes = append(nodes, r.inline(c)...)
+	}
+	return nodes
+}
+
+func (r *htmlReader) inline(n *h.Node) []Node {
+	switch n.Type {
+	case h.TextNode:
+		return htmlTextNodes(n.Data)
+	case h.ElementNode:
+	default:
+		return nil
+	}
+	switch a := n.DataAtom; {
+	case htmlBlockElements[a]:
+		// block elements nested into inline elements (e.g. a div inside a span) are treated as inline content
+		if a == atom.Script || a == atom.Style || a == atom.Template || a == atom.Noscript {
+			return nil
+		}
+		return r.inlineChildren(n)
+	case a == atom.Br:
+		return []Node{ExplicitLineBreak{}}
+	case a == atom.Img, a == atom.Video:
+		protocol, url := orgLinkURL(getHTMLAttribute(n, "src"), ".html", ".htm")
+		return []Node{RegularLink{protocol, nil, url, false, Position{}}}
+	case a == atom.A:
+		return r.link(n)
+	case a == atom.Code && hasHTMLClass(n, "statistic"):
+		return []Node{StatisticToken{strings.Trim(htmlText(n), "[]"), Position{}}}
+	case a == atom.Code && hasHTMLClass(n, "verbatim"):
+		return htmlEmphasis("=", []Node{Text{htmlText(n), true, Position{}}})
+	case a == atom.Code:
+		return htmlEmphasis("~", []Node{Text{htmlText(n), true, Position{}}})
+	case a == atom.Sup && hasHTMLClass(n, "footnote-reference"):
+		return []Node{FootnoteLink{strings.TrimSpace(htmlText(n)), nil, Position{}}}
+	case a == atom.Span && hasHTMLClass(n, "timestamp"):
+		if _, timestamp := r.d.parseTimestamp(strings.TrimSpace(htmlText(n)), 0); timestamp != nil {
+			return []Node{timestamp}
+		}
+	case a == atom.Span && strings.Contains(getHTMLAttribute(n, "style"), "underline"):
+		return htmlEmphasis("_", r.inlineChildren(n))
+	case htmlEmphasisKinds[a] != "":
+		return htmlEmphasis(htmlEmphasisKinds[a], r.inlineChildren(n))
+	}
+	return r.inlineChildren(n)
+}
+
+func (r *htmlReader) link(n *h.Node) []Node {
+	href, hasHref := "", false
+	for _, attribute := range n.Attr {
+		if attribute.Key == "href" {
+			href, hasHref = attribute.Val, true
+		}
+	}
+	if !hasHref {
+		return r.inlineChildren(n)
+	}
+	protocol, url := orgLinkURL(href, ".html", ".htm")
+	description := spaceHTMLLineBreaks(trimHTMLInline(r.inlineChildren(n)))
+	if len(description) == 1 {
+		if l, ok := description[0].(RegularLink); ok && l.Description == nil {
+			description = []Node{Text{l.URL, false, Position{}}}
+		}
+	}
+	if s := String(description); len(description) == 0 || s == href || s == url {
+		description = nil
+	}
+	return []Node{RegularLink{protocol, description, url, false, Position{}}}
+}
+
+// htmlEmphasis wraps nodes into an emphasis of the given kind. As Org mode emphasis must not start or end
+// with whitespace, leading and trailing whitespace is moved outside of the emphasis.
+func htmlEmphasis(kind string, nodes []Node) []Node {
+	content := trimHTMLInline(nodes)
+	if len(content) == 0 {
+		return nodes
+	}
+	out := []Node{}
+	if t, ok := nodes[0].(Text); ok && strings.TrimLeftFunc(t.Content, unicode.IsSpace) != t.Content {
+		out = append(out, Text{" ", false, Position{}})
+	}
+	out = append(out, Emphasis{kind, content, Position{}})
+	if t, ok := nodes[len(nodes)-1].(Text); ok && strings.TrimRightFunc(t.Content, unicode.IsSpace) != t.Content {
+		out = append(out, Text{" ", false, Position{}})
+	}
+	return out
+}
+
+// htmlTextNodes collapses whitespace the way browsers do - runs of whitespace that contain a newline become
+// line breaks to keep the line structure of the input.
+func htmlTextNodes(s string) []Node {
+	nodes, whitespace := []Node{}, htmlWhitespaceRegexp.FindAllString(s, -1)
+	for i, part := range htmlWhitespaceRegexp.Split(s, -1) {
+		if part != "" {
+			nodes = append(nodes, Text{part, false, Position{}})
+		}
+		if i < len(whitespace) && strings.Contains(whitespace[i], "\n") {
+			nodes = append(nodes, LineBreak{1, false, Position{}})
+		} else if i < len(whitespace) {
+			nodes = append(nodes, Text{" ", false, Position{}})
+		}
+	}
+	return nodes
+}
+
+// trimHTMLInline removes leading and trailing whitespace, whitespace following explicit line breaks and
+// redundant whitespace between nodes. Adjacent text nodes are merged.
+func trimHTMLInline(nodes []Node) []Node {
+	out := []Node{}
+	isWhitespace := func(n Node) bool {
+		t, ok := n.(Text)
+		_, isLineBreak := n.(LineBreak)
+		return isLineBreak || ok && !t.IsRaw && strings.TrimSpace(t.Content) == ""
+	}
+	for _, n := range nodes {
+		if len(out) == 0 && isWhitespace(n) {
+			continue
+		} else if len(out) != 0 && isWhitespace(n) {
+			switch last := out[len(out)-1].(type) {
+			case ExplicitLineBreak, LineBreak:
+				continue
+			case Text:
+				if !last.IsRaw && strings.HasSuffix(last.Content, " ") {
+					if _, ok := n.(LineBreak); ok {
+						last.Content = strings.TrimRight(last.Content, " ")
+						out[len(out)-1] = last
+					} else {
+						continue
+					}
+				}
+			}
+		}
+		if t, ok := n.(Text); ok && len(out) != 0 && !t.IsRaw {
+			if last, ok := out[len(out)-1].(Text); ok && !last.IsRaw {
+				out[len(out)-1] = Text{last.Content + t.Content, false, Position{}}
+				continue
+			}
+		}
+		out = append(out, n)
+	}
+	for len(out) != 0 && isWhitespace(out[len(out)-1]) {
+		out = out[:len(out)-1]
+	}
+	if len(out) != 0 {
+		if t, ok := out[0].(Text); ok && !t.IsRaw {
+			out[0] = Text{strings.TrimLeftFunc(t.Content, unicode.IsSpace), false, Position{}}
+		}
+		if t, ok := out[len(out)-1].(Text); ok && !t.IsRaw {
+			out[len(out)-1] = Text{strings.TrimRightFunc(t.Content, unicode.IsSpace), false, Position{}}
+		}
+	}
+	return out
+}
+
+// spaceHTMLLineBreaks replaces line breaks with spaces for content that must fit onto a single line.
+func spaceHTMLLineBreaks(nodes []Node) []Node {
+	out := make([]Node, len(nodes))
+	for i, n := range nodes {
+		switch n.(type) {
+		case LineBreak, ExplicitLineBreak:
+			out[i] = Text{" ", false, Position{}}
+		default:
+			out[i] = n
+		}
+	}
+	return trimHTMLInline(out)
+}
+
+// htmlHeadingOffset returns the level of the highest heading (h1-h6) in n minus one, so that it becomes a
+// level 1 headline. The document title (h1 with class title, as created by HTMLWriter) is not a heading.
+func htmlHeadingOffset(n *h.Node) int {
+	offset := 5
+	var walk func(*h.Node)
+	walk = func(n *h.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			switch c.DataAtom {
+			case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+				if lvl := int(c.Data[1] - '1'); lvl < offset && !(c.DataAtom == atom.H1 && hasHTMLClass(c, "title")) {
+					offset = lvl
+				}
+			default:
+				walk(c)
+			}
+		}
+	}
+	walk(n)
+	return offset
+}
+
+func isHTMLList(n Node) bool {
+	_, ok := n.(List)
+	return ok
+}
+
+func htmlListItemStatus(n *h.Node) string {
+	for _, class := range strings.Fields(getHTMLAttribute(n, "class")) {
+		if status, ok := htmlListItemStatuses[class]; ok {
+			return status
+		}
+	}
+	return ""
+}
+
+func findHTMLElement(n *h.Node, a atom.Atom) *h.Node {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == h.ElementNode && c.DataAtom == a {
+			return c
+		} else if found := findHTMLElement(c, a); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func getHTMLAttribute(n *h.Node, key string) string {
+	for _, attribute := range n.Attr {
+		if attribute.Key == key {
+			return attribute.Val
+		}
+	}
+	return ""
+}
+
+func hasHTMLClass(n *h.Node, class string) bool {
+	if n.Type != h.ElementNode {
+		return false
+	}
+	for _, c := range strings.Fields(getHTMLAttribute(n, "class")) {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+// htmlText returns the text content of n.
+func htmlText(n *h.Node) string {
+	if n.Type == h.TextNode {
+		return n.Data
+	}
+	s := strings.Builder{}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		s.WriteString(htmlText(c))
+	}
+	return s.String()
+}
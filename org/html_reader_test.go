@@ -0,0 +1,74 @@
+package org
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var htmlRoundTripTests = []string{
+	"#+TITLE: A title\n\n* TODO [#A] Headline :foo:bar:\n** DONE nested\n:PROPERTIES:\n:CUSTOM_ID: my-id\n:END:\ntext\n",
+	"#+TODO: WAITING | CANCELED\n* WAITING headline\n* CANCELED headline\n",
+	"some /emphasis/, *bold*, +strike+, _underline_, =verbatim= and ~code~\nwith a second line and a_{sub} and b^{sup}\n",
+	"- unordered\n- [X] checked\n- [ ] unchecked\n\n1. one\n2. two\n3. [@3] three\n\n- term :: details\n- [-] other term :: more details\n",
+	"- item\n  - nested item\n  - another\n- back\n",
+	"#+BEGIN_SRC go\nfunc main() {\n\tfmt.Println(\"<hello>\")\n}\n#+END_SRC\n\n#+BEGIN_EXAMPLE\nexample & stuff\n#+END_EXAMPLE\n\n#+BEGIN_QUOTE\nquoted\n\nparagraphs\n#+END_QUOTE\n\n#+BEGIN_CENTER\ncentered\n#+END_CENTER\n\n#+BEGIN_NOTE\nnote\n#+END_NOTE\n",
+	"| a | b   |\n|---+-----|\n| 1 | 2   |\n| 3 | foo |\n|---+-----|\n| x | y   |\n",
+	"a [[https://example.com][link]] and [[https://example.com]] and [[file:other.org][other]] and [[#anchor][anchor]]\n\n[[file:image.png]]\n\n#+CAPTION: a caption\n[[file:image.png]]\n\n-----\n\nhard\\\\\nbreak\n",
+	"text with a footnote[fn:1] and another[fn:2]\n\n* Footnotes\n[fn:1] the definition\n\n[fn:2] second *definition*\n",
+	"<2021-03-04 Thu> and [2021-03-04 Thu 10:00] and statistics [1/2]\n",
+}
+
+func TestParseHTML(t *testing.T) {
+	paths, err := filepath.Glob("./testdata/html/*.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range paths {
+		expected := fileString(path[:len(path)-len(".html")] + ".org")
+		reader, writer := strings.NewReader(fileString(path)), NewOrgWriter()
+		actual, err := New().Silent().ParseHTML(reader, path).Write(writer)
+		if err != nil {
+			t.Errorf("%s\n got error: %s", path, err)
+			continue
+		}
+		if actual != expected {
+			t.Errorf("%s:\n%s'", path, diff(actual, expected))
+		} else {
+			t.Logf("%s: passed!", path)
+		}
+	}
+}
+
+func TestHTMLRoundTrip(t *testing.T) {
+	for _, input := range htmlRoundTripTests {
+		expected, err := New().Silent().Parse(strings.NewReader(input), "").Write(NewOrgWriter())
+		if err != nil {
+			t.Fatal(err)
+		}
+		html, err := New().Silent().Parse(strings.NewReader(input), "").Write(NewHTMLWriter())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual, err := New().Silent().ParseHTML(strings.NewReader(html), "").Write(NewOrgWriter()); err != nil {
+			t.Errorf("%q\n got error: %s", input, err)
+		} else if actual != expected {
+			t.Errorf("%q:\n%s'", input, diff(actual, expected))
+		}
+	}
+	paths, err := filepath.Glob("./testdata/html/*.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range paths {
+		expected, err := New().Silent().ParseHTML(strings.NewReader(fileString(path)), path).Write(NewHTMLWriter())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual, err := New().Silent().ParseHTML(strings.NewReader(expected), path).Write(NewHTMLWriter()); err != nil {
+			t.Errorf("%s\n got error: %s", path, err)
+		} else if actual != expected {
+			t.Errorf("%s:\n%s'", path, diff(actual, expected))
+		}
+	}
+}
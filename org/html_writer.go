@@ -3,7 +3,6 @@ package org
 import (
 	"fmt"
 	"html"
-	"log"
 	"reflect"
 	"regexp"
 	"strconv"
@@ -25,8 +24,9 @@ type HTMLWriter struct {
 	strings.Builder
 	document   *Document
 	htmlEscape bool
-	log        *log.Logger
 	footnotes  *footnotes
+	targets    *linkTargets // targets is built from document on first use - see linkTargets.
+	inLink     bool         // inLink is true while writing the description of a link - radio links cannot be nested in it.
 }
 
 type footnotes struct {
@@ -58,13 +58,13 @@ var listItemStatuses = map[string]string{
 }
 
 var cleanHeadlineTitleForHTMLAnchorRegexp = regexp.MustCompile(`</?a[^>]*>`) // nested a tags are not valid HTML
+var htmlStartTagRegexp = regexp.MustCompile(`^\s*<[a-zA-Z][a-zA-Z0-9]*(\s[^<>]*?)?>`)
 var tocHeadlineMaxLvlRegexp = regexp.MustCompile(`headlines\s+(\d+)`)
 
 func NewHTMLWriter() *HTMLWriter {
 	defaultConfig := New()
 	return &HTMLWriter{
 		document:   &Document{Configuration: defaultConfig},
-		log:        defaultConfig.Log,
 		htmlEscape: true,
 		HighlightCodeBlock: func(source, lang string, inline bool) string {
 			if inline {
@@ -95,10 +95,9 @@ func (w *HTMLWriter) WriterWithExtensions() Writer {
 }
 
 func (w *HTMLWriter) Before(d *Document) {
-	w.document = d
-	w.log = d.Log
+	w.document, w.targets = d, nil
 	if title := d.Get("TITLE"); title != "" && w.document.GetOption("title") != "nil" {
-		titleDocument := d.Parse(strings.NewReader(title), d.Path)
+		titleDocument := d.parseFragment(title)
 		if titleDocument.Error == nil {
 			title = w.WriteNodesAsString(titleDocument.Nodes...)
 		}
@@ -116,6 +115,19 @@ func (w *HTMLWriter) After(d *Document) {
 
 func (w *HTMLWriter) WriteComment(Comment)               {}
 func (w *HTMLWriter) WritePropertyDrawer(PropertyDrawer) {}
+func (w *HTMLWriter) WriteLogbookDrawer(d LogbookDrawer) { writeLogbookClocks(w, d) }
+
+func (w *HTMLWriter) WriteClock(c Clock) {
+	if w.document.GetOption("c") == "nil" {
+		return
+	}
+	w.WriteString(`<p class="clock"><span class="timestamp-wrapper"><span class="timestamp-kwd">CLOCK:</span> `)
+	w.WriteString(`<span class="timestamp">` + c.Timestamp.format("&lt;", "&gt;") + `</span></span>`)
+	if !c.IsRunning() {
+		w.WriteString(` <span class="timestamp-kwd">=&gt;</span> <span class="duration">` + strings.TrimSpace(formatClockDuration(c.Duration)) + `</span>`)
+	}
+	w.WriteString("</p>\n")
+}
 
 func (w *HTMLWriter) WriteBlock(b Block) {
 	content, params := w.blockContent(b.Name, b.Children), b.ParameterMap()
@@ -154,6 +166,8 @@ func (w *HTMLWriter) WriteBlock(b Block) {
 
 func (w *HTMLWriter) WriteResult(r Result) { WriteNodes(w, r.Node) }
 
+func (w *HTMLWriter) WriteDynamicBlock(b DynamicBlock) { WriteNodes(w, b.Children...) }
+
 func (w *HTMLWriter) WriteInlineBlock(b InlineBlock) {
 	content := w.blockContent(strings.ToUpper(b.Name), b.Children)
 	switch b.Name {
@@ -201,13 +215,7 @@ func (w *HTMLWriter) WriteFootnotes(d *Document) {
 	for i, definition := range w.footnotes.list {
 		id := i + 1
 		if definition == nil {
-			name := ""
-			for k, v := range w.footnotes.mapping {
-				if v == i {
-					name = k
-				}
-			}
-			w.log.Printf("Missing footnote definition for [fn:%s] (#%d)", name, id)
+			w.document.addDiagnostic(CodeUndefinedFootnote, Position{}, "undefined footnote [fn:%s]", w.footnotes.name(i))
 			continue
 		}
 		w.WriteString(`<div class="footnote-definition">` + "\n")
@@ -230,7 +238,7 @@ func (w *HTMLWriter) WriteOutline(d *Document, maxLvl int) {
 }
 
 func (w *HTMLWriter) writeSection(section *Section, maxLvl int) {
-	if (maxLvl != 0 && section.Headline.Lvl > maxLvl) || section.Headline.IsExcluded(w.document) {
+	if (maxLvl != 0 && section.Headline.Lvl > maxLvl) || section.IsExcluded(w.document) {
 		return
 	}
 	// NOTE: To satisfy hugo ExtractTOC() check we cannot use `<li>\n` here. Doesn't really matter, just a note.
@@ -260,7 +268,11 @@ func (w *HTMLWriter) WriteHeadline(h Headline) {
 	w.WriteString(fmt.Sprintf(`<div id="outline-container-%s" class="outline-%d">`, h.ID(), h.Lvl+1) + "\n")
 	w.WriteString(fmt.Sprintf(`<h%d id="%s">`, h.Lvl+1, h.ID()) + "\n")
 	if w.document.GetOption("todo") != "nil" && h.Status != "" {
-		w.WriteString(fmt.Sprintf(`<span class="todo">%s</span>`, h.Status) + "\n")
+		class := "todo"
+		if w.document.IsDone(h) {
+			class = "done"
+		}
+		w.WriteString(fmt.Sprintf(`<span class="%s %s">%s</span>`, class, h.Status, h.Status) + "\n")
 	}
 	if w.document.GetOption("pri") != "nil" && h.Priority != "" {
 		w.WriteString(fmt.Sprintf(`<span class="priority">[%s]</span>`, h.Priority) + "\n")
@@ -276,13 +288,50 @@ func (w *HTMLWriter) WriteHeadline(h Headline) {
 		w.WriteString(fmt.Sprintf(`<span class="tags">%s</span>`, strings.Join(tags, "&#xa0;")))
 	}
 	w.WriteString(fmt.Sprintf("\n</h%d>\n", h.Lvl+1))
-	if content := w.WriteNodesAsString(h.Children...); content != "" {
+	content := w.WriteNodesAsString(h.Children...)
+	if h.Planning != nil {
+		content = w.WriteNodesAsString(*h.Planning) + content
+	}
+	if content != "" {
 		w.WriteString(fmt.Sprintf(`<div id="outline-text-%s" class="outline-text-%d">`, h.ID(), h.Lvl+1) + "\n" + content + "</div>\n")
 	}
 	w.WriteString("</div>\n")
 }
 
+func (w *HTMLWriter) WritePlanning(p Planning) {
+	if w.document.GetOption("p") == "nil" || len(p.Entries) == 0 {
+		return
+	}
+	w.WriteString(`<p class="planning">`)
+	for i, e := range p.Entries {
+		if i != 0 {
+			w.WriteString(" ")
+		}
+		w.WriteString(fmt.Sprintf(`<span class="timestamp-wrapper %s">`, strings.ToLower(e.Keyword)))
+		w.WriteString(fmt.Sprintf(`<span class="timestamp-kwd">%s:</span> <span class="timestamp">%s</span></span>`, e.Keyword, e.Timestamp.format("&lt;", "&gt;")))
+	}
+	w.WriteString("</p>\n")
+}
+
+// WriteText writes the text - with occurrences of radio targets linked to them.
 func (w *HTMLWriter) WriteText(t Text) {
+	if !w.htmlEscape || t.IsRaw || w.inLink {
+		w.writeText(t)
+		return
+	}
+	ranges, ids := w.linkTargets().radioLinks(t.Content)
+	previous := 0
+	for i, r := range ranges {
+		w.writeText(Text{t.Content[previous:r[0]], false, Position{}})
+		w.WriteString(fmt.Sprintf(`<a href="#%s">`, html.EscapeString(ids[i])))
+		w.writeText(Text{t.Content[r[0]:r[1]], false, Position{}})
+		w.WriteString("</a>")
+		previous = r[1]
+	}
+	w.writeText(Text{t.Content[previous:], false, Position{}})
+}
+
+func (w *HTMLWriter) writeText(t Text) {
 	if !w.htmlEscape {
 		w.WriteString(t.Content)
 	} else if w.document.GetOption("e") == "nil" || t.IsRaw {
@@ -335,19 +384,14 @@ func (w *HTMLWriter) WriteTimestamp(t Timestamp) {
 	if w.document.GetOption("<") == "nil" {
 		return
 	}
-	w.WriteString(`<span class="timestamp">&lt;`)
-	if t.IsDate {
-		w.WriteString(t.Time.Format(datestampFormat))
-	} else {
-		w.WriteString(t.Time.Format(timestampFormat))
-	}
-	if t.Interval != "" {
-		w.WriteString(" " + t.Interval)
-	}
-	w.WriteString(`&gt;</span>`)
+	w.WriteString(`<span class="timestamp">` + t.format("&lt;", "&gt;") + `</span>`)
 }
 
 func (w *HTMLWriter) WriteRegularLink(l RegularLink) {
+	if w.document.isInternalLink(l) {
+		w.writeInternalLink(l)
+		return
+	}
 	url := html.EscapeString(l.URL)
 	if l.Protocol == "file" {
 		url = url[len("file:"):]
@@ -389,20 +433,65 @@ func (w *HTMLWriter) WriteRegularLink(l RegularLink) {
 	default:
 		description := url
 		if l.Description != nil {
-			description = w.WriteNodesAsString(l.Description...)
+			description = w.writeLinkDescription(l.Description)
 		}
 		w.WriteString(fmt.Sprintf(`<a href="%s">%s</a>`, url, description))
 	}
 }
 
+// writeInternalLink writes a link to the anchor of the headline, named element or target l points to.
+// Links that cannot be resolved are written as is and reported as diagnostics.
+func (w *HTMLWriter) writeInternalLink(l RegularLink) {
+	url := html.EscapeString(l.URL)
+	id, headline, err := w.linkTargets().resolve(l.URL)
+	if err != nil {
+		w.document.addDiagnostic(CodeBrokenLink, l.Position, "%s", err)
+	} else {
+		url = "#" + html.EscapeString(id)
+	}
+	description := html.EscapeString(l.URL)
+	if l.Description != nil {
+		description = w.writeLinkDescription(l.Description)
+	} else if headline != nil {
+		description = cleanHeadlineTitleForHTMLAnchorRegexp.ReplaceAllString(w.WriteNodesAsString(headline.Title...), "")
+	}
+	w.WriteString(fmt.Sprintf(`<a href="%s">%s</a>`, url, description))
+}
+
+func (w *HTMLWriter) writeLinkDescription(description []Node) string {
+	inLink := w.inLink
+	w.inLink = true
+	out := w.WriteNodesAsString(description...)
+	w.inLink = inLink
+	return out
+}
+
+func (w *HTMLWriter) WriteTarget(t Target) {
+	w.WriteString(fmt.Sprintf(`<span id="%s"></span>`, html.EscapeString(targetID(t.Name))))
+}
+
+// WriteRadioTarget writes the name of the radio target as the anchor radio links point to.
+func (w *HTMLWriter) WriteRadioTarget(t RadioTarget) {
+	w.WriteString(fmt.Sprintf(`<span id="%s">`, html.EscapeString(targetID(t.Name))))
+	w.writeText(Text{t.Name, false, t.Position})
+	w.WriteString("</span>")
+}
+
+func (w *HTMLWriter) linkTargets() *linkTargets {
+	if w.targets == nil {
+		w.targets = w.document.linkTargets()
+	}
+	return w.targets
+}
+
 func (w *HTMLWriter) WriteMacro(m Macro) {
 	if macro := w.document.Macros[m.Name]; macro != "" {
 		for i, param := range m.Parameters {
 			macro = strings.Replace(macro, fmt.Sprintf("$%d", i+1), param, -1)
 		}
-		macroDocument := w.document.Parse(strings.NewReader(macro), w.document.Path)
+		macroDocument := w.document.parseFragment(macro)
 		if macroDocument.Error != nil {
-			w.log.Printf("bad macro: %s -> %s: %v", m.Name, macro, macroDocument.Error)
+			w.document.addDiagnostic(CodeBadMacro, m.Position, "bad macro %s (%s): %s", m.Name, macro, macroDocument.Error)
 		}
 		WriteNodes(w, macroDocument.Nodes...)
 	}
@@ -511,8 +600,10 @@ func (w *HTMLWriter) WriteNodeWithMeta(n NodeWithMeta) {
 	w.WriteString(out)
 }
 
+// WriteNodeWithName sets the id derived from the name (see nameID) as the id of the first element of the named node -
+// or wraps it in a div with that id if the element already has an id.
 func (w *HTMLWriter) WriteNodeWithName(n NodeWithName) {
-	WriteNodes(w, n.Node)
+	w.WriteString(withHTMLID(w.WriteNodesAsString(n.Node), nameID(n.Name)))
 }
 
 func (w *HTMLWriter) WriteTable(t Table) {
@@ -562,13 +653,13 @@ func (w *HTMLWriter) writeTableColumns(columns []Column, tag string) {
 
 func (w *HTMLWriter) withHTMLAttributes(input string, kvs ...string) string {
 	if len(kvs)%2 != 0 {
-		w.log.Printf("withHTMLAttributes: Len of kvs must be even: %#v", kvs)
+		w.document.addDiagnostic(CodeBadHTMLAttributes, Position{}, "uneven number of html attributes: %#v", kvs)
 		return input
 	}
 	context := &h.Node{Type: h.ElementNode, Data: "body", DataAtom: atom.Body}
 	nodes, err := h.ParseFragment(strings.NewReader(strings.TrimSpace(input)), context)
 	if err != nil || len(nodes) != 1 {
-		w.log.Printf("withHTMLAttributes: Could not extend attributes of %s: %v (%s)", input, nodes, err)
+		w.document.addDiagnostic(CodeBadHTMLAttributes, Position{}, "could not add attributes to %s: %v (%v)", input, nodes, err)
 		return input
 	}
 	out, node := strings.Builder{}, nodes[0]
@@ -577,12 +668,22 @@ func (w *HTMLWriter) withHTMLAttributes(input string, kvs ...string) string {
 	}
 	err = h.Render(&out, nodes[0])
 	if err != nil {
-		w.log.Printf("withHTMLAttributes: Could not extend attributes of %s: %v (%s)", input, node, err)
+		w.document.addDiagnostic(CodeBadHTMLAttributes, Position{}, "could not add attributes to %s: %v (%v)", input, node, err)
 		return input
 	}
 	return out.String()
 }
 
+// withHTMLID adds the id attribute to the first tag of the html input. Unlike withHTMLAttributes it does not
+// reparse and render the input and works for inputs with multiple top level elements.
+func withHTMLID(input, id string) string {
+	id = html.EscapeString(id)
+	if m := htmlStartTagRegexp.FindStringIndex(input); m != nil && !strings.Contains(input[m[0]:m[1]], " id=") {
+		return input[:m[0]] + strings.Replace(input[m[0]:m[1]], ">", fmt.Sprintf(` id="%s">`, id), 1) + input[m[1]:]
+	}
+	return fmt.Sprintf(`<div id="%s">`+"\n%s</div>\n", id, input)
+}
+
 func (w *HTMLWriter) blockContent(name string, children []Node) string {
 	if isRawTextBlock(name) {
 		builder, htmlEscape := w.Builder, w.htmlEscape
@@ -632,6 +733,16 @@ func (fs *footnotes) add(f FootnoteLink) int {
 	return i
 }
 
+// name returns the name of the i-th footnote.
+func (fs *footnotes) name(i int) string {
+	for k, v := range fs.mapping {
+		if v == i {
+			return k
+		}
+	}
+	return ""
+}
+
 func (fs *footnotes) updateDefinition(f FootnoteDefinition) {
 	if i, ok := fs.mapping[f.Name]; ok {
 		fs.list[i] = &f
@@ -13,42 +13,58 @@ import (
 type Text struct {
 	Content string
 	IsRaw   bool
+	Position
 }
 
 type LineBreak struct {
 	Count                      int
 	BetweenMultibyteCharacters bool
+	Position
 }
-type ExplicitLineBreak struct{}
+type ExplicitLineBreak struct{ Position }
 
-type StatisticToken struct{ Content string }
+type StatisticToken struct {
+	Content string
+	Position
+}
 
 type Timestamp struct {
-	Time     time.Time
-	IsDate   bool
-	Interval string
+	Time        time.Time // Time is the (start) time of the timestamp.
+	EndTime     time.Time // EndTime is the end of a time range (10:00-12:00) or date range (<...>--<...>). It is zero otherwise.
+	IsDate      bool      // IsDate is true if the timestamp does not specify a time of day.
+	IsDateRange bool      // IsDateRange is true if the range was written as two timestamps (<...>--<...>).
+	EndIsDate   bool      // EndIsDate is true if the end of a date range does not specify a time of day.
+	Inactive    bool      // Inactive is true for [inactive] and false for <active> timestamps.
+	Repeater    string    // Repeater is the repeater of the timestamp, e.g. +1w, ++1w or .+1w.
+	Warning     string    // Warning is the warning delay of the timestamp, e.g. -3d or --3d.
+	Interval    string    // Deprecated: Interval is set to Repeater for compatibility - use Repeater instead.
+	Position
 }
 
 type Emphasis struct {
 	Kind    string
 	Content []Node
+	Position
 }
 
 type InlineBlock struct {
 	Name       string
 	Parameters []string
 	Children   []Node
+	Position
 }
 
 type LatexFragment struct {
 	OpeningPair string
 	ClosingPair string
 	Content     []Node
+	Position
 }
 
 type FootnoteLink struct {
 	Name       string
 	Definition *FootnoteDefinition
+	Position
 }
 
 type RegularLink struct {
@@ -56,11 +72,25 @@ type RegularLink struct {
 	Description []Node
 	URL         string
 	AutoLink    bool
+	Position
+}
+
+// Target is a dedicated target (<<name>>) - internal links to name ([[name]]) point to it.
+type Target struct {
+	Name string
+	Position
+}
+
+// RadioTarget is a radio target (<<<name>>>) - every occurrence of name in the text of the document links to it.
+type RadioTarget struct {
+	Name string
+	Position
 }
 
 type Macro struct {
 	Name       string
 	Parameters []string
+	Position
 }
 
 var validURLCharacters = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-._~:/?#[]@!$&'()*+,;="
@@ -69,12 +99,18 @@ var imageExtensionRegexp = regexp.MustCompile(`^[.](png|gif|jpe?g|svg|tiff?)$`)
 var videoExtensionRegexp = regexp.MustCompile(`^[.](webm|mp4)$`)
 
 var subScriptSuperScriptRegexp = regexp.MustCompile(`^([_^]){([^{}]+?)}`)
-var timestampRegexp = regexp.MustCompile(`^<(\d{4}-\d{2}-\d{2})( [A-Za-z]+)?( \d{2}:\d{2})?( \+\d+[dwmy])?>`)
+var timestampRegexp = regexp.MustCompile(`^([<\[])(\d{4}-\d{2}-\d{2})((?: +[^\s>\]]+)*) *([>\]])`)
+var timestampTimeRegexp = regexp.MustCompile(`^(\d{1,2}:\d{2})(-(\d{1,2}:\d{2}))?$`)
+var timestampRepeaterRegexp = regexp.MustCompile(`^(\+|\+\+|\.\+)\d+[hdwmy]$`)
+var timestampWarningRegexp = regexp.MustCompile(`^--?\d+[hdwmy]$`)
+var timestampDayRegexp = regexp.MustCompile(`^[^\d\s+\-.][^\s]*$`)
 var footnoteRegexp = regexp.MustCompile(`^\[fn:([\w-]*?)(:(.*?))?\]`)
 var statisticsTokenRegexp = regexp.MustCompile(`^\[(\d+/\d+|\d+%)\]`)
 var latexFragmentRegexp = regexp.MustCompile(`(?s)^\\begin{(\w+)}(.*)\\end{(\w+)}`)
 var inlineBlockRegexp = regexp.MustCompile(`src_(\w+)(\[(.*)\])?{(.*)}`)
 var inlineExportBlockRegexp = regexp.MustCompile(`@@(\w+):(.*?)@@`)
+var targetRegexp = regexp.MustCompile(`^<<([^<>\s]|[^<>\s][^<>\n]*[^<>\s])>>`)
+var radioTargetRegexp = regexp.MustCompile(`^<<<([^<>\s]|[^<>\s][^<>\n]*[^<>\s])>>>`)
 var macroRegexp = regexp.MustCompile(`{{{(.*)\((.*)\)}}}`)
 
 var timestampFormat = "2006-01-02 Mon 15:04"
@@ -87,6 +123,45 @@ var latexFragmentPairs = map[string]string{
 	`$`:  `$`,
 }
 
+// parseInlineFrom parses input with positions relative to the sourceMap m.
+func (d *Document) parseInlineFrom(input string, m sourceMap) []Node {
+	originalSource, originalOffset := d.inlineSource, d.inlineOffset
+	d.inlineSource, d.inlineOffset = m, 0
+	nodes := d.parseInline(input)
+	d.inlineSource, d.inlineOffset = originalSource, originalOffset
+	return nodes
+}
+
+// parseRawInlineFrom is the raw text equivalent of parseInlineFrom.
+func (d *Document) parseRawInlineFrom(input string, m sourceMap) []Node {
+	originalSource, originalOffset := d.inlineSource, d.inlineOffset
+	d.inlineSource, d.inlineOffset = m, 0
+	nodes := d.parseRawInline(input)
+	d.inlineSource, d.inlineOffset = originalSource, originalOffset
+	return nodes
+}
+
+// parseInlineAt parses input, which starts at offset in the input currently being parsed by parseInline.
+func (d *Document) parseInlineAt(input string, offset int) []Node {
+	d.inlineOffset += offset
+	nodes := d.parseInline(input)
+	d.inlineOffset -= offset
+	return nodes
+}
+
+// parseRawInlineAt is the raw text equivalent of parseInlineAt.
+func (d *Document) parseRawInlineAt(input string, offset int) []Node {
+	d.inlineOffset += offset
+	nodes := d.parseRawInline(input)
+	d.inlineOffset -= offset
+	return nodes
+}
+
+// inlinePosition returns the Position of input[start:end] of the input currently being parsed by parseInline.
+func (d *Document) inlinePosition(start, end int) Position {
+	return d.inlineSource.position(d.inlineOffset+start, d.inlineOffset+end)
+}
+
 func (d *Document) parseInline(input string) (nodes []Node) {
 	previous, current := 0, 0
 	for current < len(input) {
@@ -107,7 +182,7 @@ func (d *Document) parseInline(input string) (nodes []Node) {
 		case '{':
 			consumed, node = d.parseMacro(input, current)
 		case '<':
-			consumed, node = d.parseTimestamp(input, current)
+			consumed, node = d.parseTargetOrTimestamp(input, current)
 		case '\\':
 			consumed, node = d.parseExplicitLineBreakOrLatexFragment(input, current)
 		case '$':
@@ -120,7 +195,7 @@ func (d *Document) parseInline(input string) (nodes []Node) {
 		current -= rewind
 		if consumed != 0 {
 			if current > previous {
-				nodes = append(nodes, Text{input[previous:current], false})
+				nodes = append(nodes, Text{input[previous:current], false, d.inlinePosition(previous, current)})
 			}
 			if node != nil {
 				nodes = append(nodes, node)
@@ -133,7 +208,7 @@ func (d *Document) parseInline(input string) (nodes []Node) {
 	}
 
 	if previous < len(input) {
-		nodes = append(nodes, Text{input[previous:], false})
+		nodes = append(nodes, Text{input[previous:], false, d.inlinePosition(previous, len(input))})
 	}
 	return nodes
 }
@@ -144,7 +219,7 @@ func (d *Document) parseRawInline(input string) (nodes []Node) {
 		if input[current] == '\n' {
 			consumed, node := d.parseLineBreak(input, current)
 			if current > previous {
-				nodes = append(nodes, Text{input[previous:current], true})
+				nodes = append(nodes, Text{input[previous:current], true, d.inlinePosition(previous, current)})
 			}
 			nodes = append(nodes, node)
 			current += consumed
@@ -154,7 +229,7 @@ func (d *Document) parseRawInline(input string) (nodes []Node) {
 		}
 	}
 	if previous < len(input) {
-		nodes = append(nodes, Text{input[previous:], true})
+		nodes = append(nodes, Text{input[previous:], true, d.inlinePosition(previous, len(input))})
 	}
 	return nodes
 }
@@ -165,22 +240,31 @@ func (d *Document) parseLineBreak(input string, start int) (int, Node) {
 	}
 	_, beforeLen := utf8.DecodeLastRuneInString(input[:start])
 	_, afterLen := utf8.DecodeRuneInString(input[i:])
-	return i - start, LineBreak{i - start, beforeLen > 1 && afterLen > 1}
+	return i - start, LineBreak{i - start, beforeLen > 1 && afterLen > 1, d.inlinePosition(start, i)}
 }
 
 func (d *Document) parseInlineBlock(input string, start int) (int, int, Node) {
 	if !(strings.HasSuffix(input[:start], "src") && (start-4 < 0 || unicode.IsSpace(rune(input[start-4])))) {
 		return 0, 0, nil
 	}
-	if m := inlineBlockRegexp.FindStringSubmatch(input[start-3:]); m != nil {
-		return 3, len(m[0]), InlineBlock{"src", strings.Fields(m[1] + " " + m[3]), d.parseRawInline(m[4])}
+	if m := inlineBlockRegexp.FindStringSubmatchIndex(input[start-3:]); m != nil {
+		s := func(i int) string {
+			if m[2*i] == -1 {
+				return ""
+			}
+			return input[start-3+m[2*i] : start-3+m[2*i+1]]
+		}
+		content := d.parseRawInlineAt(s(4), start-3+m[8])
+		pos := d.inlinePosition(start-3+m[0], start-3+m[1])
+		return 3, m[1] - m[0], InlineBlock{"src", strings.Fields(s(1) + " " + s(3)), content, pos}
 	}
 	return 0, 0, nil
 }
 
 func (d *Document) parseInlineExportBlock(input string, start int) (int, Node) {
 	if m := inlineExportBlockRegexp.FindStringSubmatch(input[start:]); m != nil {
-		return len(m[0]), InlineBlock{"export", m[1:2], d.parseRawInline(m[2])}
+		content := d.parseRawInlineAt(m[2], start+len("@@"+m[1]+":"))
+		return len(m[0]), InlineBlock{"export", m[1:2], content, d.inlinePosition(start, start+len(m[0]))}
 	}
 	return 0, nil
 }
@@ -191,7 +275,7 @@ func (d *Document) parseExplicitLineBreakOrLatexFragment(input string, start int
 	case input[start+1] == '\\' && start != 0 && input[start-1] != '\n':
 		for i := start + 2; i <= len(input)-1 && unicode.IsSpace(rune(input[i])); i++ {
 			if input[i] == '\n' {
-				return i + 1 - start, ExplicitLineBreak{}
+				return i + 1 - start, ExplicitLineBreak{d.inlinePosition(start, i+1)}
 			}
 		}
 	case input[start+1] == '(' || input[start+1] == '[':
@@ -201,7 +285,9 @@ func (d *Document) parseExplicitLineBreakOrLatexFragment(input string, start int
 			if open, content, close := m[1], m[2], m[3]; open == close {
 				openingPair, closingPair := `\begin{`+open+`}`, `\end{`+close+`}`
 				i := strings.Index(input[start:], closingPair)
-				return i + len(closingPair), LatexFragment{openingPair, closingPair, d.parseRawInline(content)}
+				content := d.parseRawInlineAt(content, start+len(openingPair))
+				pos := d.inlinePosition(start, start+i+len(closingPair))
+				return i + len(closingPair), LatexFragment{openingPair, closingPair, content, pos}
 			}
 		}
 	}
@@ -218,15 +304,17 @@ func (d *Document) parseLatexFragment(input string, start int, pairLength int) (
 	openingPair := input[start : start+pairLength]
 	closingPair := latexFragmentPairs[openingPair]
 	if i := strings.Index(input[start+pairLength:], closingPair); i != -1 {
-		content := d.parseRawInline(input[start+pairLength : start+pairLength+i])
-		return i + pairLength + pairLength, LatexFragment{openingPair, closingPair, content}
+		content := d.parseRawInlineAt(input[start+pairLength:start+pairLength+i], start+pairLength)
+		pos := d.inlinePosition(start, start+i+pairLength+pairLength)
+		return i + pairLength + pairLength, LatexFragment{openingPair, closingPair, content, pos}
 	}
 	return 0, nil
 }
 
 func (d *Document) parseSubOrSuperScript(input string, start int) (int, Node) {
 	if m := subScriptSuperScriptRegexp.FindStringSubmatch(input[start:]); m != nil {
-		return len(m[2]) + 3, Emphasis{m[1] + "{}", []Node{Text{m[2], false}}}
+		text := Text{m[2], false, d.inlinePosition(start+2, start+2+len(m[2]))}
+		return len(m[2]) + 3, Emphasis{m[1] + "{}", []Node{text}, d.inlinePosition(start, start+len(m[2])+3)}
 	}
 	return 0, nil
 }
@@ -244,6 +332,8 @@ func (d *Document) parseSubScriptOrEmphasisOrInlineBlock(input string, start int
 func (d *Document) parseOpeningBracket(input string, start int) (int, Node) {
 	if len(input[start:]) >= 2 && input[start] == '[' && input[start+1] == '[' {
 		return d.parseRegularLink(input, start)
+	} else if timestampRegexp.MatchString(input[start:]) {
+		return d.parseTimestamp(input, start)
 	} else if footnoteRegexp.MatchString(input[start:]) {
 		return d.parseFootnoteReference(input, start)
 	} else if statisticsTokenRegexp.MatchString(input[start:]) {
@@ -252,9 +342,18 @@ func (d *Document) parseOpeningBracket(input string, start int) (int, Node) {
 	return 0, nil
 }
 
+func (d *Document) parseTargetOrTimestamp(input string, start int) (int, Node) {
+	if m := radioTargetRegexp.FindStringSubmatch(input[start:]); m != nil {
+		return len(m[0]), RadioTarget{m[1], d.inlinePosition(start, start+len(m[0]))}
+	} else if m := targetRegexp.FindStringSubmatch(input[start:]); m != nil {
+		return len(m[0]), Target{m[1], d.inlinePosition(start, start+len(m[0]))}
+	}
+	return d.parseTimestamp(input, start)
+}
+
 func (d *Document) parseMacro(input string, start int) (int, Node) {
 	if m := macroRegexp.FindStringSubmatch(input[start:]); m != nil {
-		return len(m[0]), Macro{m[1], strings.Split(m[2], ",")}
+		return len(m[0]), Macro{m[1], strings.Split(m[2], ","), d.inlinePosition(start, start+len(m[0]))}
 	}
 	return 0, nil
 }
@@ -265,9 +364,12 @@ func (d *Document) parseFootnoteReference(input string, start int) (int, Node) {
 		if name == "" && definition == "" {
 			return 0, nil
 		}
-		link := FootnoteLink{name, nil}
+		link := FootnoteLink{name, nil, d.inlinePosition(start, start+len(m[0]))}
 		if definition != "" {
-			link.Definition = &FootnoteDefinition{name, []Node{Paragraph{d.parseInline(definition)}}, true}
+			offset := start + len("[fn:"+name+":")
+			pos := d.inlinePosition(offset, offset+len(definition))
+			paragraph := Paragraph{d.parseInlineAt(definition, offset), pos}
+			link.Definition = &FootnoteDefinition{name, []Node{paragraph}, true, pos}
 		}
 		return len(m[0]), link
 	}
@@ -276,7 +378,7 @@ func (d *Document) parseFootnoteReference(input string, start int) (int, Node) {
 
 func (d *Document) parseStatisticToken(input string, start int) (int, Node) {
 	if m := statisticsTokenRegexp.FindStringSubmatch(input[start:]); m != nil {
-		return len(m[1]) + 2, StatisticToken{m[1]}
+		return len(m[1]) + 2, StatisticToken{m[1], d.inlinePosition(start, start+len(m[1])+2)}
 	}
 	return 0, nil
 }
@@ -304,7 +406,8 @@ func (d *Document) parseAutoLink(input string, start int) (int, int, Node) {
 	if path == "://" {
 		return 0, 0, nil
 	}
-	return len(protocol), len(path + protocol), RegularLink{protocol, nil, protocol + path, true}
+	pos := d.inlinePosition(start-len(protocol), end)
+	return len(protocol), len(path + protocol), RegularLink{protocol, nil, protocol + path, true, pos}
 }
 
 func (d *Document) parseRegularLink(input string, start int) (int, Node) {
@@ -319,7 +422,7 @@ func (d *Document) parseRegularLink(input string, start int) (int, Node) {
 	rawLinkParts := strings.Split(input[2:end], "][")
 	description, link := ([]Node)(nil), rawLinkParts[0]
 	if len(rawLinkParts) == 2 {
-		link, description = rawLinkParts[0], d.parseInline(rawLinkParts[1])
+		link, description = rawLinkParts[0], d.parseInlineAt(rawLinkParts[1], start+len("[["+link+"]["))
 	}
 	if strings.ContainsRune(link, '\n') {
 		return 0, nil
@@ -329,23 +432,84 @@ func (d *Document) parseRegularLink(input string, start int) (int, Node) {
 	if len(linkParts) == 2 {
 		protocol = linkParts[0]
 	}
-	return consumed, RegularLink{protocol, description, link, false}
+	return consumed, RegularLink{protocol, description, link, false, d.inlinePosition(start, start+consumed)}
 }
 
 func (d *Document) parseTimestamp(input string, start int) (int, Node) {
-	if m := timestampRegexp.FindStringSubmatch(input[start:]); m != nil {
-		ddmmyy, hhmm, interval, isDate := m[1], m[3], strings.TrimSpace(m[4]), false
-		if hhmm == "" {
-			hhmm, isDate = "00:00", true
+	consumed, timestamp, ok := parseSingleTimestamp(input[start:])
+	if !ok {
+		return 0, nil
+	}
+	if rest := input[start+consumed:]; strings.HasPrefix(rest, "--") && timestamp.EndTime.IsZero() {
+		if n, end, ok := parseSingleTimestamp(rest[2:]); ok && end.Inactive == timestamp.Inactive && end.EndTime.IsZero() {
+			timestamp.EndTime, timestamp.EndIsDate, timestamp.IsDateRange = end.Time, end.IsDate, true
+			consumed += 2 + n
+		}
+	}
+	timestamp.Position = d.inlinePosition(start, start+consumed)
+	return consumed, timestamp
+}
+
+func parseSingleTimestamp(input string) (int, Timestamp, bool) {
+	m := timestampRegexp.FindStringSubmatch(input)
+	if m == nil || (m[1] == "<") != (m[4] == ">") {
+		return 0, Timestamp{}, false
+	}
+	timestamp := Timestamp{Inactive: m[1] == "[", IsDate: true}
+	hhmm, endHHMM := "00:00", ""
+	for i, field := range strings.Fields(m[3]) {
+		if mt := timestampTimeRegexp.FindStringSubmatch(field); mt != nil && timestamp.IsDate {
+			hhmm, endHHMM, timestamp.IsDate = mt[1], mt[3], false
+		} else if timestampRepeaterRegexp.MatchString(field) && timestamp.Repeater == "" {
+			timestamp.Repeater, timestamp.Interval = field, field
+		} else if timestampWarningRegexp.MatchString(field) && timestamp.Warning == "" {
+			timestamp.Warning = field
+		} else if i != 0 || !timestampDayRegexp.MatchString(field) {
+			return 0, Timestamp{}, false
 		}
-		t, err := time.Parse(timestampFormat, fmt.Sprintf("%s Mon %s", ddmmyy, hhmm))
+	}
+	t, err := time.Parse(timestampFormat, fmt.Sprintf("%s Mon %s", m[2], hhmm))
+	if err != nil {
+		return 0, Timestamp{}, false
+	}
+	timestamp.Time = t
+	if endHHMM != "" {
+		end, err := time.Parse(timestampFormat, fmt.Sprintf("%s Mon %s", m[2], endHHMM))
 		if err != nil {
-			return 0, nil
+			return 0, Timestamp{}, false
 		}
-		timestamp := Timestamp{t, isDate, interval}
-		return len(m[0]), timestamp
+		timestamp.EndTime = end
 	}
-	return 0, nil
+	return len(m[0]), timestamp, true
+}
+
+// format returns the Org mode representation of the timestamp using open and close as brackets
+// for active timestamps. Inactive timestamps always use square brackets.
+func (t Timestamp) format(open, close string) string {
+	if t.Inactive {
+		open, close = "[", "]"
+	}
+	s := t.Time.Format(timestampFormat)
+	if t.IsDate {
+		s = t.Time.Format(datestampFormat)
+	}
+	if !t.EndTime.IsZero() && !t.IsDateRange {
+		s += t.EndTime.Format("-15:04")
+	}
+	if t.Repeater != "" {
+		s += " " + t.Repeater
+	} else if t.Interval != "" {
+		s += " " + t.Interval
+	}
+	if t.Warning != "" {
+		s += " " + t.Warning
+	}
+	s = open + s + close
+	if !t.EndTime.IsZero() && t.IsDateRange {
+		end := Timestamp{Time: t.EndTime, IsDate: t.EndIsDate, Inactive: t.Inactive}
+		s += "--" + end.format(open, close)
+	}
+	return s
 }
 
 func (d *Document) parseEmphasis(input string, start int, isRaw bool) (int, Node) {
@@ -360,9 +524,9 @@ func (d *Document) parseEmphasis(input string, start int, isRaw bool) (int, Node
 
 		if input[i] == marker && i != start+1 && hasValidPostAndBorderChars(input, i) {
 			if isRaw {
-				return i + 1 - start, Emphasis{input[start : start+1], d.parseRawInline(input[start+1 : i])}
+				return i + 1 - start, Emphasis{input[start : start+1], d.parseRawInlineAt(input[start+1:i], start+1), d.inlinePosition(start, i+1)}
 			}
-			return i + 1 - start, Emphasis{input[start : start+1], d.parseInline(input[start+1 : i])}
+			return i + 1 - start, Emphasis{input[start : start+1], d.parseInlineAt(input[start+1:i], start+1), d.inlinePosition(start, i+1)}
 		}
 	}
 	return 0, nil
@@ -413,6 +577,8 @@ func (n Text) String() string              { return orgWriter.WriteNodesAsString
 func (n LineBreak) String() string         { return orgWriter.WriteNodesAsString(n) }
 func (n ExplicitLineBreak) String() string { return orgWriter.WriteNodesAsString(n) }
 func (n StatisticToken) String() string    { return orgWriter.WriteNodesAsString(n) }
+func (n Target) String() string            { return orgWriter.WriteNodesAsString(n) }
+func (n RadioTarget) String() string       { return orgWriter.WriteNodesAsString(n) }
 func (n Emphasis) String() string          { return orgWriter.WriteNodesAsString(n) }
 func (n InlineBlock) String() string       { return orgWriter.WriteNodesAsString(n) }
 func (n LatexFragment) String() string     { return orgWriter.WriteNodesAsString(n) }
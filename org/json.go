@@ -0,0 +1,432 @@
+package org
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// JSONWriter exports the AST of a document as JSON. The output can be turned back into a Document using Configuration.ParseJSON.
+//
+// The document is written as an object with the keys path, settings (#+KEY: VALUE), links (#+LINK), macros (#+MACRO) and nodes.
+// Each node is written as an object with a "type" key (the name of the go type, e.g. "Headline") followed by
+// the exported fields of the node in lowerCamelCase (e.g. "lvl", "title", "children", "position").
+// Nested nodes ([]Node, Node) are written the same way, timestamps are written as RFC 3339 strings and durations as
+// go duration strings (e.g. "1h30m0s").
+type JSONWriter struct {
+	ExtendingWriter Writer
+	Indent          string // Indent is used to pretty print the output. No indentation is applied if Indent is empty.
+
+	strings.Builder
+	nodes []Node
+}
+
+type jsonDocument struct {
+	Path     string            `json:"path"`
+	Settings map[string]string `json:"settings"`
+	Links    map[string]string `json:"links"`
+	Macros   map[string]string `json:"macros"`
+	Nodes    []json.RawMessage `json:"nodes"`
+}
+
+// jsonObject is a json object that keeps the order of its fields.
+type jsonObject []jsonField
+
+type jsonField struct {
+	key   string
+	value interface{}
+}
+
+type jsonDecoder struct {
+	namedNodes map[string]Node
+}
+
+var nodeTypes = map[string]reflect.Type{}
+
+var timeType = reflect.TypeOf(time.Time{})
+var durationType = reflect.TypeOf(time.Duration(0))
+var columnInfoPointerType = reflect.TypeOf(&ColumnInfo{})
+
+func init() {
+	for _, n := range []Node{
+		Keyword{}, Include{}, Comment{}, NodeWithMeta{}, NodeWithName{}, Headline{}, Planning{}, Block{}, DynamicBlock{},
+		Result{}, InlineBlock{}, Example{}, Drawer{}, PropertyDrawer{}, LogbookDrawer{}, Clock{}, List{}, ListItem{},
+		DescriptiveListItem{}, Table{}, HorizontalRule{}, Paragraph{}, Text{}, Emphasis{}, LatexFragment{}, StatisticToken{},
+		ExplicitLineBreak{}, LineBreak{}, RegularLink{}, Target{}, RadioTarget{}, Macro{}, Timestamp{}, FootnoteLink{},
+		FootnoteDefinition{},
+	} {
+		nodeTypes[reflect.TypeOf(n).Name()] = reflect.TypeOf(n)
+	}
+}
+
+func NewJSONWriter() *JSONWriter {
+	return &JSONWriter{Indent: "  "}
+}
+
+func (w *JSONWriter) WriterWithExtensions() Writer {
+	if w.ExtendingWriter != nil {
+		return w.ExtendingWriter
+	}
+	return w
+}
+
+// WriteNodesAsString returns the nodes as a json array.
+func (w *JSONWriter) WriteNodesAsString(nodes ...Node) string {
+	original := w.nodes
+	w.nodes = nil
+	WriteNodes(w, nodes...)
+	out, err := w.marshal(w.nodes)
+	w.nodes = original
+	if err != nil {
+		panic(err)
+	}
+	return string(out)
+}
+
+func (w *JSONWriter) Before(d *Document) {}
+
+func (w *JSONWriter) After(d *Document) {
+	nodes := make([]json.RawMessage, len(w.nodes))
+	for i, n := range w.nodes {
+		out, err := json.Marshal(encodeJSON(reflect.ValueOf(&n).Elem()))
+		if err != nil {
+			panic(err)
+		}
+		nodes[i] = out
+	}
+	out, err := w.marshal(jsonDocument{d.Path, d.BufferSettings, d.Links, d.Macros, nodes})
+	if err != nil {
+		panic(err)
+	}
+	w.WriteString(string(out) + "\n")
+}
+
+func (w *JSONWriter) marshal(v interface{}) ([]byte, error) {
+	if nodes, ok := v.([]Node); ok {
+		v = encodeJSON(reflect.ValueOf(nodes))
+	}
+	if w.Indent == "" {
+		return json.Marshal(v)
+	}
+	return json.MarshalIndent(v, "", w.Indent)
+}
+
+func (w *JSONWriter) add(n Node) { w.nodes = append(w.nodes, n) }
+
+func (w *JSONWriter) WriteKeyword(n Keyword)                         { w.add(n) }
+func (w *JSONWriter) WriteInclude(n Include)                         { w.add(n) }
+func (w *JSONWriter) WriteComment(n Comment)                         { w.add(n) }
+func (w *JSONWriter) WriteNodeWithMeta(n NodeWithMeta)               { w.add(n) }
+func (w *JSONWriter) WriteNodeWithName(n NodeWithName)               { w.add(n) }
+func (w *JSONWriter) WriteHeadline(n Headline)                       { w.add(n) }
+func (w *JSONWriter) WritePlanning(n Planning)                       { w.add(n) }
+func (w *JSONWriter) WriteBlock(n Block)                             { w.add(n) }
+func (w *JSONWriter) WriteDynamicBlock(n DynamicBlock)               { w.add(n) }
+func (w *JSONWriter) WriteResult(n Result)                           { w.add(n) }
+func (w *JSONWriter) WriteInlineBlock(n InlineBlock)                 { w.add(n) }
+func (w *JSONWriter) WriteExample(n Example)                         { w.add(n) }
+func (w *JSONWriter) WriteDrawer(n Drawer)                           { w.add(n) }
+func (w *JSONWriter) WritePropertyDrawer(n PropertyDrawer)           { w.add(n) }
+func (w *JSONWriter) WriteLogbookDrawer(n LogbookDrawer)             { w.add(n) }
+func (w *JSONWriter) WriteClock(n Clock)                             { w.add(n) }
+func (w *JSONWriter) WriteList(n List)                               { w.add(n) }
+func (w *JSONWriter) WriteListItem(n ListItem)                       { w.add(n) }
+func (w *JSONWriter) WriteDescriptiveListItem(n DescriptiveListItem) { w.add(n) }
+func (w *JSONWriter) WriteTable(n Table)                             { w.add(n) }
+func (w *JSONWriter) WriteHorizontalRule(n HorizontalRule)           { w.add(n) }
+func (w *JSONWriter) WriteParagraph(n Paragraph)                     { w.add(n) }
+func (w *JSONWriter) WriteText(n Text)                               { w.add(n) }
+func (w *JSONWriter) WriteEmphasis(n Emphasis)                       { w.add(n) }
+func (w *JSONWriter) WriteLatexFragment(n LatexFragment)             { w.add(n) }
+func (w *JSONWriter) WriteStatisticToken(n StatisticToken)           { w.add(n) }
+func (w *JSONWriter) WriteExplicitLineBreak(n ExplicitLineBreak)     { w.add(n) }
+func (w *JSONWriter) WriteLineBreak(n LineBreak)                     { w.add(n) }
+func (w *JSONWriter) WriteRegularLink(n RegularLink)                 { w.add(n) }
+func (w *JSONWriter) WriteTarget(n Target)                           { w.add(n) }
+func (w *JSONWriter) WriteRadioTarget(n RadioTarget)                 { w.add(n) }
+func (w *JSONWriter) WriteMacro(n Macro)                             { w.add(n) }
+func (w *JSONWriter) WriteTimestamp(n Timestamp)                     { w.add(n) }
+func (w *JSONWriter) WriteFootnoteLink(n FootnoteLink)               { w.add(n) }
+func (w *JSONWriter) WriteFootnoteDefinition(n FootnoteDefinition)   { w.add(n) }
+
+// ParseJSON reads a document that was written by JSONWriter. If path is empty, the path stored in the json is used.
+// To allow method chaining, errors are stored in document.Error rather than being returned.
+func (c *Configuration) ParseJSON(input io.Reader, path string) (d *Document) {
+	outlineSection := &Section{}
+	d = &Document{
+		Configuration:  c,
+		Outline:        Outline{outlineSection, outlineSection, 0},
+		BufferSettings: map[string]string{},
+		NamedNodes:     map[string]Node{},
+		Links:          map[string]string{},
+		Macros:         map[string]string{},
+		Path:           path,
+	}
+	data, err := ioutil.ReadAll(input)
+	if err != nil {
+		d.setError(err)
+		return d
+	}
+	jd := jsonDocument{}
+	if err := json.Unmarshal(data, &jd); err != nil {
+		d.setError(fmt.Errorf("could not parse json: %w", err))
+		return d
+	}
+	if d.Path == "" {
+		d.Path = jd.Path
+	}
+	for k, v := range jd.Settings {
+		d.BufferSettings[k] = v
+	}
+	for k, v := range jd.Links {
+		d.Links[k] = v
+	}
+	for k, v := range jd.Macros {
+		d.Macros[k] = v
+	}
+	decoder := &jsonDecoder{d.NamedNodes}
+	for _, raw := range jd.Nodes {
+		n, err := decoder.decodeNode(raw)
+		if err != nil {
+			d.setError(err)
+			return d
+		}
+		d.Nodes = append(d.Nodes, n)
+	}
+	d.Nodes = d.addSections(d.Nodes)
+	return d
+}
+
+// UnmarshalNodes reads nodes that were written by JSONWriter.WriteNodesAsString.
+func UnmarshalNodes(data []byte) ([]Node, error) {
+	nodes := []Node{}
+	v := reflect.ValueOf(&nodes).Elem()
+	if err := (&jsonDecoder{map[string]Node{}}).decode(data, v); err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
+
+// addSections rebuilds the Outline from the headlines in nodes.
+func (d *Document) addSections(nodes []Node) []Node {
+	for i, n := range nodes {
+		if h, ok := n.(Headline); ok {
+			headline := &h
+			index := h.Index
+			d.addHeadline(headline)
+			headline.Index = index
+			headline.Children = d.addSections(headline.Children)
+			nodes[i] = *headline
+		}
+	}
+	return nodes
+}
+
+func (o jsonObject) MarshalJSON() ([]byte, error) {
+	b := &bytes.Buffer{}
+	b.WriteString("{")
+	for i, f := range o {
+		if i != 0 {
+			b.WriteString(",")
+		}
+		key, _ := json.Marshal(f.key)
+		value, err := json.Marshal(f.value)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(key)
+		b.WriteString(":")
+		b.Write(value)
+	}
+	b.WriteString("}")
+	return b.Bytes(), nil
+}
+
+func encodeJSON(v reflect.Value) interface{} {
+	switch {
+	case v.Type() == timeType:
+		return v.Interface().(time.Time).Format(time.RFC3339Nano)
+	case v.Type() == durationType:
+		return v.Interface().(time.Duration).String()
+	}
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		if include, ok := v.Interface().(Include); ok {
+			return jsonObject{
+				{"type", "Include"},
+				{"keyword", encodeJSON(reflect.ValueOf(include.Keyword))},
+				{"resolved", encodeJSON(reflect.ValueOf(&[]Node{include.Resolve()}).Elem().Index(0))},
+			}
+		}
+		elem := v.Elem()
+		return append(jsonObject{{"type", elem.Type().Name()}}, encodeJSON(elem).(jsonObject)...)
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		return encodeJSON(v.Elem())
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+		values := make([]interface{}, v.Len())
+		for i := range values {
+			values[i] = encodeJSON(v.Index(i))
+		}
+		return values
+	case reflect.Struct:
+		o := jsonObject{}
+		for i := 0; i < v.NumField(); i++ {
+			if f := v.Type().Field(i); isJSONField(f) {
+				o = append(o, jsonField{jsonKey(f.Name), encodeJSON(v.Field(i))})
+			}
+		}
+		return o
+	default:
+		return v.Interface()
+	}
+}
+
+func (d *jsonDecoder) decodeNode(data []byte) (Node, error) {
+	var n Node
+	if err := d.decode(data, reflect.ValueOf(&n).Elem()); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+func (d *jsonDecoder) decode(data []byte, v reflect.Value) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+	switch {
+	case v.Type() == timeType:
+		s, t := "", time.Time{}
+		err := json.Unmarshal(data, &s)
+		if err == nil {
+			t, err = time.Parse(time.RFC3339Nano, s)
+		}
+		v.Set(reflect.ValueOf(t))
+		return err
+	case v.Type() == durationType:
+		s, duration := "", time.Duration(0)
+		err := json.Unmarshal(data, &s)
+		if err == nil {
+			duration, err = time.ParseDuration(s)
+		}
+		v.Set(reflect.ValueOf(duration))
+		return err
+	}
+	switch v.Kind() {
+	case reflect.Interface:
+		return d.decodeInterface(data, v)
+	case reflect.Ptr:
+		elem := reflect.New(v.Type().Elem())
+		if err := d.decode(data, elem.Elem()); err != nil {
+			return err
+		}
+		v.Set(elem)
+		return nil
+	case reflect.Slice:
+		raws := []json.RawMessage{}
+		if err := json.Unmarshal(data, &raws); err != nil {
+			return err
+		}
+		slice := reflect.MakeSlice(v.Type(), len(raws), len(raws))
+		for i, raw := range raws {
+			if err := d.decode(raw, slice.Index(i)); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+		return nil
+	case reflect.Struct:
+		fields := map[string]json.RawMessage{}
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return err
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if f := v.Type().Field(i); isJSONField(f) {
+				if raw, ok := fields[jsonKey(f.Name)]; ok {
+					if err := d.decode(raw, v.Field(i)); err != nil {
+						return fmt.Errorf("%s.%s: %w", v.Type().Name(), jsonKey(f.Name), err)
+					}
+				}
+			}
+		}
+		if t, ok := v.Addr().Interface().(*Table); ok {
+			t.linkColumnInfos()
+		}
+		return nil
+	default:
+		return json.Unmarshal(data, v.Addr().Interface())
+	}
+}
+
+func (d *jsonDecoder) decodeInterface(data []byte, v reflect.Value) error {
+	header := struct {
+		Type     string          `json:"type"`
+		Resolved json.RawMessage `json:"resolved"`
+	}{}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return err
+	}
+	t, ok := nodeTypes[header.Type]
+	if !ok {
+		return fmt.Errorf("unknown node type %q", header.Type)
+	}
+	n := reflect.New(t).Elem()
+	if err := d.decode(data, n); err != nil {
+		return err
+	}
+	switch node := n.Interface().(type) {
+	case Include:
+		resolved, err := d.decodeNode(header.Resolved)
+		if err != nil {
+			return err
+		}
+		node.Resolve = func() Node { return resolved }
+		v.Set(reflect.ValueOf(node))
+		return nil
+	case NodeWithName:
+		d.namedNodes[node.Name] = node.Node
+	}
+	v.Set(n)
+	return nil
+}
+
+func (t *Table) linkColumnInfos() {
+	for _, row := range t.Rows {
+		for i := range row.Columns {
+			if i < len(t.ColumnInfos) {
+				row.Columns[i].ColumnInfo = &t.ColumnInfos[i]
+			}
+		}
+	}
+}
+
+func isJSONField(f reflect.StructField) bool {
+	return f.PkgPath == "" && f.Type.Kind() != reflect.Func && f.Type != columnInfoPointerType
+}
+
+// jsonKey returns the lowerCamelCase version of a go field name, e.g. HTMLAttributes -> htmlAttributes.
+func jsonKey(name string) string {
+	rs := []rune(name)
+	for i := 0; i < len(rs) && unicode.IsUpper(rs[i]); i++ {
+		if i != 0 && i+1 < len(rs) && unicode.IsLower(rs[i+1]) {
+			break
+		}
+		rs[i] = unicode.ToLower(rs[i])
+	}
+	return string(rs)
+}
@@ -0,0 +1,51 @@
+package org
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	for _, path := range orgTestFiles() {
+		d := New().Silent().Parse(strings.NewReader(fileString(path)), path)
+		out, err := d.Write(NewJSONWriter())
+		if err != nil {
+			t.Errorf("%s\n got error: %s", path, err)
+			continue
+		}
+		jd := New().Silent().ParseJSON(strings.NewReader(out), "")
+		if jd.Error != nil {
+			t.Errorf("%s\n got error: %s", path, jd.Error)
+			continue
+		}
+		for _, tc := range []struct {
+			extension string
+			writer    func() Writer
+		}{
+			{".html", func() Writer { return NewHTMLWriter() }},
+			{".pretty_org", func() Writer { return NewOrgWriter() }},
+		} {
+			expected := fileString(path[:len(path)-len(".org")] + tc.extension)
+			actual, err := jd.Write(tc.writer())
+			if err != nil {
+				t.Errorf("%s\n got error: %s", path, err)
+			} else if actual != expected {
+				t.Errorf("%s (%s):\n%s'", path, tc.extension, diff(actual, expected))
+			}
+		}
+	}
+}
+
+func TestUnmarshalNodes(t *testing.T) {
+	d := New().Silent().Parse(strings.NewReader("* TODO headline :tag:\n| a | b |\n| 1 | 2 |\n"), "")
+	nodes, err := UnmarshalNodes([]byte(NewJSONWriter().WriteNodesAsString(d.Nodes...)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := NewOrgWriter().WriteNodesAsString(nodes...), NewOrgWriter().WriteNodesAsString(d.Nodes...); actual != expected {
+		t.Errorf("got\n%s\nexpected\n%s", actual, expected)
+	}
+	if p, ok := nodes[0].(Headline).Children[0].(Table); !ok || p.Rows[1].Columns[0].ColumnInfo != &p.ColumnInfos[0] {
+		t.Errorf("expected table columns to reference the column infos of the table: %#v", nodes[0])
+	}
+}
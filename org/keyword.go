@@ -7,26 +7,33 @@ import (
 	"strings"
 )
 
-type Comment struct{ Content string }
+type Comment struct {
+	Content string
+	Position
+}
 
 type Keyword struct {
 	Key   string
 	Value string
+	Position
 }
 
 type NodeWithName struct {
 	Name string
 	Node Node
+	Position
 }
 
 type NodeWithMeta struct {
 	Node Node
 	Meta Metadata
+	Position
 }
 
 type Metadata struct {
-	Caption        [][]Node
-	HTMLAttributes [][]string
+	Caption         [][]Node
+	HTMLAttributes  [][]string
+	LatexAttributes [][]string
 }
 
 type Include struct {
@@ -50,18 +57,19 @@ func lexKeywordOrComment(line string) (token, bool) {
 }
 
 func (d *Document) parseComment(i int, stop stopFn) (int, Node) {
-	return 1, Comment{d.tokens[i].content}
+	return 1, Comment{d.tokens[i].content, d.blockPosition(i, 1)}
 }
 
 func (d *Document) parseKeyword(i int, stop stopFn) (int, Node) {
 	k := parseKeyword(d.tokens[i])
+	k.Position = d.blockPosition(i, 1)
 	switch k.Key {
 	case "NAME":
 		return d.parseNodeWithName(k, i, stop)
 	case "SETUPFILE":
-		return d.loadSetupFile(k)
+		return d.loadSetupFile(k, i)
 	case "INCLUDE":
-		return d.parseInclude(k)
+		return d.parseInclude(k, i)
 	case "LINK":
 		if parts := strings.SplitN(k.Value, " ", 2); len(parts) == 2 {
 			d.Links[parts[0]] = parts[1]
@@ -72,7 +80,7 @@ func (d *Document) parseKeyword(i int, stop stopFn) (int, Node) {
 			d.Macros[parts[0]] = parts[1]
 		}
 		return 1, k
-	case "CAPTION", "ATTR_HTML":
+	case "CAPTION", "ATTR_HTML", "ATTR_LATEX":
 		consumed, node := d.parseAffiliated(i, stop)
 		if consumed != 0 {
 			return consumed, node
@@ -97,7 +105,7 @@ func (d *Document) parseNodeWithName(k Keyword, i int, stop stopFn) (int, Node)
 		return 0, nil
 	}
 	d.NamedNodes[k.Value] = node
-	return consumed + 1, NodeWithName{k.Value, node}
+	return consumed + 1, NodeWithName{k.Value, node, d.blockPosition(i, consumed+1)}
 }
 
 func (d *Document) parseAffiliated(i int, stop stopFn) (int, Node) {
@@ -105,25 +113,12 @@ func (d *Document) parseAffiliated(i int, stop stopFn) (int, Node) {
 	for ; !stop(d, i) && d.tokens[i].kind == "keyword"; i++ {
 		switch k := parseKeyword(d.tokens[i]); k.Key {
 		case "CAPTION":
-			meta.Caption = append(meta.Caption, d.parseInline(k.Value))
+			m := d.lineSourceMap(i, []string{k.Value}, []int{d.suffixColumn(i, d.tokens[i].matches[4])})
+			meta.Caption = append(meta.Caption, d.parseInlineFrom(k.Value, m))
 		case "ATTR_HTML":
-			attributes, rest := []string{}, k.Value
-			for {
-				if k, m := "", attributeRegexp.FindStringSubmatch(rest); m != nil {
-					k, rest = m[1], m[2]
-					attributes = append(attributes, k)
-					if v, m := "", attributeRegexp.FindStringSubmatchIndex(rest); m != nil {
-						v, rest = rest[:m[0]], rest[m[0]:]
-						attributes = append(attributes, v)
-					} else {
-						attributes = append(attributes, strings.TrimSpace(rest))
-						break
-					}
-				} else {
-					break
-				}
-			}
-			meta.HTMLAttributes = append(meta.HTMLAttributes, attributes)
+			meta.HTMLAttributes = append(meta.HTMLAttributes, parseAttributes(k.Value))
+		case "ATTR_LATEX":
+			meta.LatexAttributes = append(meta.LatexAttributes, parseAttributes(k.Value))
 		default:
 			return 0, nil
 		}
@@ -136,49 +131,103 @@ func (d *Document) parseAffiliated(i int, stop stopFn) (int, Node) {
 		return 0, nil
 	}
 	i += consumed
-	return i - start, NodeWithMeta{node, meta}
+	return i - start, NodeWithMeta{node, meta, d.blockPosition(start, i-start)}
+}
+
+// parseAttributes parses the value of an #+ATTR_* keyword (e.g. ":width 100% :class foo")
+// into a flat list of alternating keys and values.
+func parseAttributes(value string) []string {
+	attributes, rest := []string{}, value
+	for {
+		if k, m := "", attributeRegexp.FindStringSubmatch(rest); m != nil {
+			k, rest = m[1], m[2]
+			attributes = append(attributes, k)
+			if v, m := "", attributeRegexp.FindStringSubmatchIndex(rest); m != nil {
+				v, rest = rest[:m[0]], rest[m[0]:]
+				attributes = append(attributes, v)
+			} else {
+				attributes = append(attributes, strings.TrimSpace(rest))
+				break
+			}
+		} else {
+			break
+		}
+	}
+	return attributes
 }
 
 func parseKeyword(t token) Keyword {
 	k, v := t.matches[2], t.matches[4]
-	return Keyword{strings.ToUpper(k), strings.TrimSpace(v)}
+	return Keyword{strings.ToUpper(k), strings.TrimSpace(v), Position{}}
 }
 
-func (d *Document) parseInclude(k Keyword) (int, Node) {
-	resolve := func() Node {
-		d.Log.Printf("Bad include %#v", k)
-		return k
+// parseInclude reports malformed includes right away - the included file is only read (and reported if it cannot be)
+// when the include is resolved (see CheckIncludes).
+func (d *Document) parseInclude(k Keyword, i int) (int, Node) {
+	resolve, pos := func() Node { return k }, d.blockPosition(i, 1)
+	path, kind, lang, ok := d.includeFile(k.Value)
+	if !ok {
+		d.addDiagnostic(CodeBadInclude, pos, "bad include %q", k.Value)
+		return 1, Include{k, resolve}
 	}
-	if m := includeFileRegexp.FindStringSubmatch(k.Value); m != nil {
-		path, kind, lang := m[1], m[2], m[3]
-		if !filepath.IsAbs(path) {
-			path = filepath.Join(filepath.Dir(d.Path), path)
-		}
-		resolve = func() Node {
-			bs, err := d.ReadFile(path)
-			if err != nil {
-				d.Log.Printf("Bad include %#v: %s", k, err)
-				return k
-			}
-			return Block{strings.ToUpper(kind), []string{lang}, d.parseRawInline(string(bs)), nil}
+	resolve = func() Node {
+		bs, err := d.ReadFile(path)
+		if err != nil {
+			d.addDiagnostic(CodeBadInclude, pos, "bad include %q: %s", k.Value, err)
+			return k
 		}
+		return Block{strings.ToUpper(kind), []string{lang}, d.parseRawInlineFrom(string(bs), nil), nil, k.Position}
 	}
 	return 1, Include{k, resolve}
 }
 
-func (d *Document) loadSetupFile(k Keyword) (int, Node) {
+// includeFile returns the path (relative to the document), kind (src, example or export) and language
+// of the file included by the #+INCLUDE value - ok is false if value is malformed.
+func (d *Document) includeFile(value string) (path, kind, lang string, ok bool) {
+	m := includeFileRegexp.FindStringSubmatch(value)
+	if m == nil {
+		return "", "", "", false
+	}
+	path, kind, lang = m[1], m[2], m[3]
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(d.Path), path)
+	}
+	return path, kind, lang, true
+}
+
+// CheckIncludes reads the files of all includes of the document and reports the ones that cannot be read.
+// Includes are resolved lazily when the document is written - Parse only checks them up front if CodeBadInclude
+// is listed in Strict.
+func (d *Document) CheckIncludes() {
+	d.checkIncludes(d.addDiagnostic)
+}
+
+func (d *Document) checkIncludes(report func(code string, pos Position, format string, args ...interface{})) {
+	Inspect(d.Nodes, func(n Node) bool {
+		if include, ok := n.(Include); ok {
+			if path, _, _, ok := d.includeFile(include.Value); ok {
+				if _, err := d.ReadFile(path); err != nil {
+					report(CodeBadInclude, include.Position, "bad include %q: %s", include.Value, err)
+				}
+			}
+		}
+		return true
+	})
+}
+
+func (d *Document) loadSetupFile(k Keyword, i int) (int, Node) {
 	path := k.Value
 	if !filepath.IsAbs(path) {
 		path = filepath.Join(filepath.Dir(d.Path), path)
 	}
 	bs, err := d.ReadFile(path)
 	if err != nil {
-		d.Log.Printf("Bad setup file: %#v: %s", k, err)
+		d.addDiagnostic(CodeBadSetupFile, d.blockPosition(i, 1), "bad setup file %q: %s", k.Value, err)
 		return 1, k
 	}
 	setupDocument := d.Configuration.Parse(bytes.NewReader(bs), path)
 	if err := setupDocument.Error; err != nil {
-		d.Log.Printf("Bad setup file: %#v: %s", k, err)
+		d.addDiagnostic(CodeBadSetupFile, d.blockPosition(i, 1), "bad setup file %q: %s", k.Value, err)
 		return 1, k
 	}
 	for k, v := range setupDocument.BufferSettings {
@@ -0,0 +1,623 @@
+package org
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	u "net/url"
+)
+
+// LaTeXWriter exports an org document into a complete LaTeX document.
+// The document class and preamble can be customized using #+LATEX_CLASS, #+LATEX_CLASS_OPTIONS and #+LATEX_HEADER;
+// tables, images and blocks can be customized using #+ATTR_LATEX (:environment, :align, :width, :placement, :options).
+type LaTeXWriter struct {
+	ExtendingWriter Writer
+	// Minted makes the writer export source blocks using the minted package rather than verbatim.
+	Minted bool
+
+	strings.Builder
+	document            *Document
+	escape              bool
+	listDepth           int
+	footnoteDefinitions map[string]*FootnoteDefinition
+	footnotes           map[string]int
+	footnoteCount       int
+	attributes          [][]string
+}
+
+var latexSectionCommands = map[string][]string{
+	"article": []string{"section", "subsection", "subsubsection", "paragraph", "subparagraph"},
+	"report":  []string{"chapter", "section", "subsection", "subsubsection", "paragraph", "subparagraph"},
+	"book":    []string{"part", "chapter", "section", "subsection", "subsubsection", "paragraph", "subparagraph"},
+}
+
+var latexDefaultPackages = []string{
+	`\usepackage[utf8]{inputenc}`,
+	`\usepackage[T1]{fontenc}`,
+	`\usepackage{graphicx}`,
+	`\usepackage{longtable}`,
+	`\usepackage[normalem]{ulem}`,
+	`\usepackage{amsmath}`,
+	`\usepackage{amssymb}`,
+	`\usepackage{hyperref}`,
+}
+
+var emphasisLatexCommands = map[string][]string{
+	"/":   []string{`\emph{`, `}`},
+	"*":   []string{`\textbf{`, `}`},
+	"+":   []string{`\sout{`, `}`},
+	"_":   []string{`\uline{`, `}`},
+	"_{}": []string{`\textsubscript{`, `}`},
+	"^{}": []string{`\textsuperscript{`, `}`},
+}
+
+var latexListEnvironments = map[string]string{
+	"unordered":   "itemize",
+	"ordered":     "enumerate",
+	"descriptive": "description",
+}
+
+var latexListItemStatuses = map[string]string{
+	" ": `$\square$`,
+	"-": `$\boxminus$`,
+	"X": `$\boxtimes$`,
+}
+
+var latexEnumerateCounters = []string{"enumi", "enumii", "enumiii", "enumiv"}
+
+var latexEscaper = strings.NewReplacer(
+	`\`, `\textbackslash{}`, `{`, `\{`, `}`, `\}`, `$`, `\$`, `&`, `\&`, `#`, `\#`, `%`, `\%`, `_`, `\_`,
+	`~`, `\textasciitilde{}`, `^`, `\textasciicircum{}`)
+var latexURLEscaper = strings.NewReplacer(`#`, `\#`, `%`, `\%`, `{`, `\{`, `}`, `\}`)
+
+func NewLaTeXWriter() *LaTeXWriter {
+	defaultConfig := New()
+	return &LaTeXWriter{
+		document:            &Document{Configuration: defaultConfig},
+		escape:              true,
+		footnoteDefinitions: map[string]*FootnoteDefinition{},
+		footnotes:           map[string]int{},
+	}
+}
+
+func (w *LaTeXWriter) WriterWithExtensions() Writer {
+	if w.ExtendingWriter != nil {
+		return w.ExtendingWriter
+	}
+	return w
+}
+
+func (w *LaTeXWriter) WriteNodesAsString(nodes ...Node) string {
+	original := w.Builder
+	w.Builder = strings.Builder{}
+	WriteNodes(w, nodes...)
+	out := w.String()
+	w.Builder = original
+	return out
+}
+
+func (w *LaTeXWriter) Before(d *Document) {
+	w.document = d
+	collectFootnoteDefinitions(d.Nodes, w.footnoteDefinitions)
+	class, classOptions := "article", d.Get("LATEX_CLASS_OPTIONS")
+	if c := strings.TrimSpace(d.Get("LATEX_CLASS")); c != "" {
+		class = c
+	}
+	w.WriteString(fmt.Sprintf(`\documentclass%s{%s}`+"\n", classOptions, class))
+	w.WriteString(strings.Join(latexDefaultPackages, "\n") + "\n")
+	if w.Minted {
+		w.WriteString(`\usepackage{minted}` + "\n")
+	}
+	for _, key := range []string{"LATEX_HEADER", "LATEX_HEADER_EXTRA"} {
+		if header := d.Get(key); header != "" {
+			w.WriteString(header + "\n")
+		}
+	}
+	title := d.Get("TITLE")
+	if title != "" && d.GetOption("title") != "nil" {
+		titleDocument := d.parseFragment(title)
+		if titleDocument.Error == nil {
+			title = strings.TrimSpace(w.WriteNodesAsString(titleDocument.Nodes...))
+		} else {
+			title = latexEscaper.Replace(title)
+		}
+		w.WriteString(`\title{` + title + "}\n")
+		w.WriteString(`\author{` + latexEscaper.Replace(d.Get("AUTHOR")) + "}\n")
+		w.WriteString(`\date{` + latexEscaper.Replace(d.Get("DATE")) + "}\n")
+	} else {
+		title = ""
+	}
+	w.WriteString(`\begin{document}` + "\n\n")
+	if title != "" {
+		w.WriteString(`\maketitle` + "\n")
+	}
+	if toc := d.GetOption("toc"); toc != "nil" {
+		if maxLvl, err := strconv.Atoi(toc); err == nil && maxLvl != 0 {
+			w.WriteString(fmt.Sprintf(`\setcounter{tocdepth}{%d}`+"\n", maxLvl))
+		}
+		w.WriteString(`\tableofcontents` + "\n\n")
+	}
+}
+
+func (w *LaTeXWriter) After(d *Document) {
+	out := multipleBlankLinesRegexp.ReplaceAllString(strings.TrimRight(w.String(), "\n")+"\n\n", "\n\n")
+	w.Builder.Reset()
+	w.WriteString(out + `\end{document}` + "\n")
+}
+
+func (w *LaTeXWriter) WriteKeyword(k Keyword) {
+	switch k.Key {
+	case "LATEX", "TEX":
+		w.WriteString(k.Value + "\n")
+	case "TOC":
+		if m := tocHeadlineMaxLvlRegexp.FindStringSubmatch(k.Value); m != nil {
+			if maxLvl, _ := strconv.Atoi(m[1]); maxLvl != 0 {
+				w.WriteString(fmt.Sprintf(`\setcounter{tocdepth}{%d}`+"\n", maxLvl))
+			}
+			w.WriteString(`\tableofcontents` + "\n\n")
+		}
+	}
+}
+
+func (w *LaTeXWriter) WriteInclude(i Include) { WriteNodes(w, i.Resolve()) }
+
+func (w *LaTeXWriter) WriteComment(Comment)               {}
+func (w *LaTeXWriter) WritePropertyDrawer(PropertyDrawer) {}
+func (w *LaTeXWriter) WriteLogbookDrawer(d LogbookDrawer) { writeLogbookClocks(w, d) }
+
+func (w *LaTeXWriter) WriteNodeWithMeta(n NodeWithMeta) {
+	w.writeNodeWithMeta(n, "")
+}
+
+func (w *LaTeXWriter) WriteNodeWithName(n NodeWithName) {
+	if meta, ok := n.Node.(NodeWithMeta); ok && len(meta.Meta.Caption) != 0 {
+		w.writeNodeWithMeta(meta, n.Name)
+		return
+	}
+	w.WriteString(`\phantomsection\label{` + n.Name + "}\n")
+	WriteNodes(w, n.Node)
+}
+
+// writeNodeWithMeta writes n with its #+ATTR_LATEX attributes. Captioned nodes are wrapped in a float
+// (table for tables, figure for everything else) - in which case label is used as the label of the float.
+func (w *LaTeXWriter) writeNodeWithMeta(n NodeWithMeta, label string) {
+	attributes := w.attributes
+	w.attributes = n.Meta.LatexAttributes
+	out := w.WriteNodesAsString(n.Node)
+	if len(n.Meta.Caption) != 0 {
+		captions := []string{}
+		for _, caption := range n.Meta.Caption {
+			captions = append(captions, strings.TrimSpace(w.WriteNodesAsString(caption...)))
+		}
+		environment := "figure"
+		if _, isTable := n.Node.(Table); isTable {
+			environment = "table"
+			out = strings.TrimSuffix(strings.TrimPrefix(out, `\begin{center}`+"\n"), `\end{center}`+"\n\n")
+		} else if p, ok := n.Node.(Paragraph); ok && len(p.Children) == 1 && isImageOrVideoLink(p.Children[0]) {
+			out = w.WriteNodesAsString(p.Children[0]) + "\n"
+		}
+		placement := "[htbp]"
+		if p := w.attribute("placement"); p != "" {
+			placement = p
+		}
+		w.WriteString(fmt.Sprintf(`\begin{%s}%s`+"\n"+`\centering`+"\n", environment, placement))
+		w.WriteString(strings.TrimRight(out, "\n") + "\n")
+		w.WriteString(`\caption{` + strings.Join(captions, " ") + "}\n")
+		if label != "" {
+			w.WriteString(`\label{` + label + "}\n")
+		}
+		w.WriteString(fmt.Sprintf(`\end{%s}`+"\n\n", environment))
+	} else {
+		w.WriteString(out)
+	}
+	w.attributes = attributes
+}
+
+// attribute returns the value of the #+ATTR_LATEX attribute :key of the node that is currently being written.
+func (w *LaTeXWriter) attribute(key string) string {
+	value := ""
+	for _, attributes := range w.attributes {
+		for i := 0; i+1 < len(attributes); i += 2 {
+			if attributes[i] == ":"+key {
+				value = attributes[i+1]
+			}
+		}
+	}
+	return value
+}
+
+func (w *LaTeXWriter) WriteHeadline(h Headline) {
+	if h.IsExcluded(w.document) {
+		return
+	}
+	title := ""
+	if w.document.GetOption("todo") != "nil" && h.Status != "" {
+		title += `\textbf{` + latexEscaper.Replace(h.Status) + "} "
+	}
+	if w.document.GetOption("pri") != "nil" && h.Priority != "" {
+		title += `\framebox{\#` + latexEscaper.Replace(h.Priority) + "} "
+	}
+	title += strings.TrimSpace(w.WriteNodesAsString(h.Title...))
+	if w.document.GetOption("tags") != "nil" && len(h.Tags) != 0 {
+		title += `\hfill{}\textsc{` + latexEscaper.Replace(strings.Join(h.Tags, ":")) + "}"
+	}
+	commands, ok := latexSectionCommands[strings.TrimSpace(w.document.Get("LATEX_CLASS"))]
+	if !ok {
+		commands = latexSectionCommands["article"]
+	}
+	command := commands[len(commands)-1]
+	if h.Lvl <= len(commands) {
+		command = commands[h.Lvl-1]
+	}
+	if w.document.GetOption("num") == "nil" {
+		command += "*"
+	}
+	w.WriteString(fmt.Sprintf(`\%s{%s}`+"\n"+`\label{%s}`+"\n\n", command, title, h.ID()))
+	if h.Planning != nil {
+		WriteNodes(w, *h.Planning)
+	}
+	WriteNodes(w, h.Children...)
+}
+
+func (w *LaTeXWriter) WritePlanning(p Planning) {
+	if w.document.GetOption("p") == "nil" || len(p.Entries) == 0 {
+		return
+	}
+	entries := []string{}
+	for _, e := range p.Entries {
+		entries = append(entries, `\textbf{`+e.Keyword+`:} \textit{`+latexEscaper.Replace(e.Timestamp.format("<", ">"))+"}")
+	}
+	w.WriteString(`\noindent` + strings.Join(entries, " ") + "\n\n")
+}
+
+func (w *LaTeXWriter) WriteClock(c Clock) {
+	if w.document.GetOption("c") == "nil" {
+		return
+	}
+	w.WriteString(`\noindent\textbf{CLOCK:} \textit{` + latexEscaper.Replace(c.Timestamp.format("<", ">")) + "}")
+	if !c.IsRunning() {
+		w.WriteString(" => " + strings.TrimSpace(formatClockDuration(c.Duration)))
+	}
+	w.WriteString("\n\n")
+}
+
+func (w *LaTeXWriter) WriteBlock(b Block) {
+	content, params := w.blockContent(b.Name, b.Children), b.ParameterMap()
+	switch b.Name {
+	case "SRC":
+		if params[":exports"] == "results" || params[":exports"] == "none" {
+			break
+		}
+		lang := ""
+		if len(b.Parameters) >= 1 {
+			lang = strings.ToLower(b.Parameters[0])
+		}
+		w.writeVerbatim(content, lang)
+	case "EXAMPLE":
+		w.writeVerbatim(content, "")
+	case "EXPORT":
+		if len(b.Parameters) >= 1 {
+			switch strings.ToLower(b.Parameters[0]) {
+			case "latex", "tex":
+				w.WriteString(content + "\n\n")
+			}
+		}
+	case "VERSE":
+		lines := []string{}
+		for _, line := range strings.Split(strings.TrimSpace(content), "\n") {
+			if strings.TrimSpace(line) != "" {
+				lines = append(lines, line+` \\`)
+			} else if len(lines) != 0 && lines[len(lines)-1] != `\vspace*{1em}` {
+				lines = append(lines, `\vspace*{1em}`)
+			}
+		}
+		if len(lines) != 0 {
+			lines[len(lines)-1] = strings.TrimSuffix(lines[len(lines)-1], ` \\`)
+		}
+		w.writeEnvironment("verse", "", strings.Join(lines, "\n"))
+	default:
+		w.writeEnvironment(strings.ToLower(b.Name), w.attribute("options"), content)
+	}
+	if b.Result != nil && params[":exports"] != "code" && params[":exports"] != "none" {
+		WriteNodes(w, b.Result)
+	}
+}
+
+func (w *LaTeXWriter) writeVerbatim(content, lang string) {
+	if w.Minted && lang != "" {
+		options := w.attribute("options")
+		if options != "" && !strings.HasPrefix(options, "[") {
+			options = "[" + options + "]"
+		}
+		w.WriteString(fmt.Sprintf(`\begin{minted}%s{%s}`+"\n%s\n"+`\end{minted}`+"\n\n", options, lang, content))
+		return
+	}
+	w.WriteString(`\begin{verbatim}` + "\n" + content + "\n" + `\end{verbatim}` + "\n\n")
+}
+
+func (w *LaTeXWriter) writeEnvironment(name, options, content string) {
+	w.WriteString(`\begin{` + name + "}" + options + "\n" + strings.TrimSpace(content) + "\n" + `\end{` + name + "}\n\n")
+}
+
+func (w *LaTeXWriter) WriteDynamicBlock(b DynamicBlock) { WriteNodes(w, b.Children...) }
+
+func (w *LaTeXWriter) WriteResult(r Result) { WriteNodes(w, r.Node) }
+
+func (w *LaTeXWriter) WriteInlineBlock(b InlineBlock) {
+	content := w.blockContent(strings.ToUpper(b.Name), b.Children)
+	switch b.Name {
+	case "src":
+		w.WriteString(`\texttt{` + latexEscaper.Replace(content) + "}")
+	case "export":
+		switch strings.ToLower(b.Parameters[0]) {
+		case "latex", "tex":
+			w.WriteString(content)
+		}
+	}
+}
+
+func (w *LaTeXWriter) WriteExample(e Example) {
+	lines := []string{}
+	for _, n := range e.Children {
+		lines = append(lines, w.blockContent("EXAMPLE", []Node{n}))
+	}
+	w.writeVerbatim(strings.Join(lines, "\n"), "")
+}
+
+func (w *LaTeXWriter) WriteDrawer(d Drawer) { WriteNodes(w, d.Children...) }
+
+func (w *LaTeXWriter) WriteList(l List) {
+	environment := latexListEnvironments[l.Kind]
+	w.WriteString(`\begin{` + environment + "}\n")
+	w.listDepth++
+	for _, item := range l.Items {
+		if item, ok := item.(ListItem); ok && l.Kind == "ordered" && item.Value != "" && w.listDepth <= len(latexEnumerateCounters) {
+			if v, err := strconv.Atoi(item.Value); err == nil {
+				w.WriteString(fmt.Sprintf(`\setcounter{%s}{%d}`+"\n", latexEnumerateCounters[w.listDepth-1], v-1))
+			}
+		}
+		WriteNodes(w, item)
+	}
+	w.listDepth--
+	w.WriteString(`\end{` + environment + "}\n\n")
+}
+
+func (w *LaTeXWriter) WriteListItem(li ListItem) {
+	w.WriteString(`\item`)
+	if status, ok := latexListItemStatuses[li.Status]; ok {
+		w.WriteString("[" + status + "]")
+	}
+	w.WriteString(" " + strings.TrimSpace(w.WriteNodesAsString(li.Children...)) + "\n")
+}
+
+func (w *LaTeXWriter) WriteDescriptiveListItem(di DescriptiveListItem) {
+	term := "?"
+	if len(di.Term) != 0 {
+		term = strings.TrimSpace(w.WriteNodesAsString(di.Term...))
+	}
+	if status, ok := latexListItemStatuses[di.Status]; ok {
+		term = status + " " + term
+	}
+	w.WriteString(`\item[{` + term + `}] ` + strings.TrimSpace(w.WriteNodesAsString(di.Details...)) + "\n")
+}
+
+func (w *LaTeXWriter) WriteTable(t Table) {
+	environment, align := "tabular", w.attribute("align")
+	if e := w.attribute("environment"); e != "" {
+		environment = e
+	}
+	if align == "" {
+		for _, info := range t.ColumnInfos {
+			switch info.Align {
+			case "right":
+				align += "r"
+			case "center":
+				align += "c"
+			default:
+				align += "l"
+			}
+		}
+	}
+	begin := `\begin{` + environment + "}"
+	if width := w.attribute("width"); width != "" && (environment == "tabularx" || environment == "tabular*") {
+		begin += "{" + width + "}"
+	}
+	begin += "{" + align + "}\n"
+	rows := ""
+	for _, row := range t.Rows {
+		if len(row.Columns) == 0 {
+			rows += `\hline` + "\n"
+			continue
+		} else if row.IsSpecial {
+			continue
+		}
+		cells := []string{}
+		for _, column := range row.Columns {
+			cells = append(cells, strings.TrimSpace(strings.ReplaceAll(w.WriteNodesAsString(column.Children...), "\n", " ")))
+		}
+		rows += strings.Join(cells, " & ") + ` \\` + "\n"
+	}
+	table := begin + rows + `\end{` + environment + "}\n"
+	if environment == "longtable" {
+		w.WriteString(table + "\n")
+	} else {
+		w.WriteString(`\begin{center}` + "\n" + table + `\end{center}` + "\n\n")
+	}
+}
+
+func (w *LaTeXWriter) WriteHorizontalRule(HorizontalRule) {
+	w.WriteString(`\noindent\rule{\textwidth}{0.5pt}` + "\n\n")
+}
+
+func (w *LaTeXWriter) WriteParagraph(p Paragraph) {
+	if len(p.Children) == 0 {
+		return
+	}
+	if len(p.Children) == 1 && isImageOrVideoLink(p.Children[0]) {
+		w.WriteString(`\begin{center}` + "\n" + w.WriteNodesAsString(p.Children[0]) + "\n" + `\end{center}` + "\n\n")
+		return
+	}
+	w.WriteString(w.WriteNodesAsString(p.Children...) + "\n\n")
+}
+
+func (w *LaTeXWriter) WriteText(t Text) {
+	content := t.Content
+	if !w.escape || t.IsRaw {
+		w.WriteString(content)
+		return
+	}
+	if w.document.GetOption("e") != "nil" {
+		content = htmlEntityReplacer.Replace(content)
+	}
+	w.WriteString(latexEscaper.Replace(content))
+}
+
+func (w *LaTeXWriter) WriteEmphasis(e Emphasis) {
+	switch e.Kind {
+	case "~", "=":
+		w.WriteString(`\texttt{` + latexEscaper.Replace(w.blockContent("SRC", e.Content)) + "}")
+		return
+	}
+	commands, ok := emphasisLatexCommands[e.Kind]
+	if !ok {
+		panic(fmt.Sprintf("bad emphasis %#v", e))
+	}
+	w.WriteString(commands[0])
+	WriteNodes(w, e.Content...)
+	w.WriteString(commands[1])
+}
+
+func (w *LaTeXWriter) WriteLatexFragment(l LatexFragment) {
+	w.WriteString(l.OpeningPair + w.blockContent("SRC", l.Content) + l.ClosingPair)
+}
+
+func (w *LaTeXWriter) WriteStatisticToken(s StatisticToken) {
+	w.WriteString("[" + latexEscaper.Replace(s.Content) + "]")
+}
+
+func (w *LaTeXWriter) WriteExplicitLineBreak(ExplicitLineBreak) { w.WriteString(`\\` + "\n") }
+
+func (w *LaTeXWriter) WriteLineBreak(l LineBreak) {
+	if w.document.GetOption("ealb") == "nil" || !l.BetweenMultibyteCharacters {
+		w.WriteString(strings.Repeat("\n", l.Count))
+	}
+}
+
+func (w *LaTeXWriter) WriteRegularLink(l RegularLink) {
+	url := w.linkURL(l)
+	switch l.Kind() {
+	case "image":
+		options := w.attribute("options")
+		if width := w.attribute("width"); width != "" {
+			options = strings.Trim(options+",width="+width, ",")
+		} else if options == "" {
+			options = `width=.9\linewidth`
+		}
+		if l.Description == nil {
+			w.WriteString(fmt.Sprintf(`\includegraphics[%s]{%s}`, options, url))
+		} else {
+			description := strings.TrimPrefix(String(l.Description), "file:")
+			w.WriteString(fmt.Sprintf(`\href{%s}{\includegraphics[%s]{%s}}`, latexURLEscaper.Replace(url), options, description))
+		}
+	default:
+		description := ""
+		if l.Description != nil {
+			description = w.WriteNodesAsString(l.Description...)
+		}
+		if l.Protocol == "" && strings.HasPrefix(url, "#") {
+			if description == "" {
+				description = latexEscaper.Replace(url)
+			}
+			w.WriteString(fmt.Sprintf(`\hyperref[%s]{%s}`, url[1:], description))
+		} else if description == "" {
+			w.WriteString(`\url{` + latexURLEscaper.Replace(url) + "}")
+		} else {
+			w.WriteString(fmt.Sprintf(`\href{%s}{%s}`, latexURLEscaper.Replace(url), description))
+		}
+	}
+}
+
+func (w *LaTeXWriter) WriteTarget(Target) {}
+
+func (w *LaTeXWriter) WriteRadioTarget(t RadioTarget) { w.WriteText(Text{t.Name, false, t.Position}) }
+
+func (w *LaTeXWriter) WriteMacro(m Macro) {
+	if macro := w.document.Macros[m.Name]; macro != "" {
+		for i, param := range m.Parameters {
+			macro = strings.Replace(macro, fmt.Sprintf("$%d", i+1), param, -1)
+		}
+		macroDocument := w.document.parseFragment(macro)
+		if macroDocument.Error != nil {
+			w.document.addDiagnostic(CodeBadMacro, m.Position, "bad macro %s (%s): %s", m.Name, macro, macroDocument.Error)
+		}
+		w.WriteString(strings.TrimSpace(w.WriteNodesAsString(macroDocument.Nodes...)))
+	}
+}
+
+func (w *LaTeXWriter) WriteTimestamp(t Timestamp) {
+	if w.document.GetOption("<") == "nil" {
+		return
+	}
+	w.WriteString(`\textit{` + latexEscaper.Replace(t.format("<", ">")) + "}")
+}
+
+// WriteFootnoteLink writes the footnote definition inline as \footnote. Further references
+// to an already written footnote are written as \footnotemark.
+func (w *LaTeXWriter) WriteFootnoteLink(l FootnoteLink) {
+	if w.document.GetOption("f") == "nil" {
+		return
+	}
+	if i, ok := w.footnotes[l.Name]; ok && l.Name != "" {
+		w.WriteString(fmt.Sprintf(`\footnotemark[%d]`, i))
+		return
+	}
+	w.footnoteCount++
+	if l.Name != "" {
+		w.footnotes[l.Name] = w.footnoteCount
+	}
+	definition := l.Definition
+	if definition == nil {
+		definition = w.footnoteDefinitions[l.Name]
+	}
+	if definition == nil {
+		w.document.addDiagnostic(CodeUndefinedFootnote, l.Position, "undefined footnote [fn:%s]", l.Name)
+		w.WriteString(`\footnotemark{}`)
+		return
+	}
+	w.WriteString(`\footnote{` + strings.TrimSpace(w.WriteNodesAsString(definition.Children...)) + "}")
+}
+
+func (w *LaTeXWriter) WriteFootnoteDefinition(FootnoteDefinition) {}
+
+func (w *LaTeXWriter) blockContent(name string, children []Node) string {
+	builder, escape := w.Builder, w.escape
+	w.Builder, w.escape = strings.Builder{}, false
+	WriteNodes(w, children...)
+	out := w.String()
+	w.Builder, w.escape = builder, escape
+	if isRawTextBlock(name) {
+		return strings.TrimRight(out, " \t\n")
+	}
+	return out
+}
+
+func (w *LaTeXWriter) linkURL(l RegularLink) string {
+	url := l.URL
+	if l.Protocol == "file" {
+		url = url[len("file:"):]
+	}
+	if isRelative := l.Protocol == "file" || l.Protocol == ""; isRelative && strings.HasSuffix(url, ".org") {
+		url = strings.TrimSuffix(url, ".org") + ".pdf"
+	}
+	if prefix := w.document.Links[l.Protocol]; prefix != "" {
+		if tag := strings.TrimPrefix(l.URL, l.Protocol+":"); strings.Contains(prefix, "%s") || strings.Contains(prefix, "%h") {
+			url = strings.ReplaceAll(strings.ReplaceAll(prefix, "%s", tag), "%h", u.QueryEscape(tag))
+		} else {
+			url = prefix + tag
+		}
+	} else if prefix := w.document.Links[l.URL]; prefix != "" {
+		url = strings.ReplaceAll(strings.ReplaceAll(prefix, "%s", ""), "%h", "")
+	}
+	return url
+}
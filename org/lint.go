@@ -0,0 +1,260 @@
+package org
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Codes of the diagnostics reported by Lint.
+const (
+	CodeDuplicateCustomID       = "duplicate-custom-id"       // Multiple headlines have the same CUSTOM_ID property.
+	CodeUnusedFootnote          = "unused-footnote"           // A footnote is defined but never referenced.
+	CodeUnknownKeyword          = "unknown-keyword"           // A #+KEYWORD is not known to Org mode.
+	CodeMisplacedPropertyDrawer = "misplaced-property-drawer" // A property drawer does not directly follow a headline (or its planning).
+	CodeInvalidTimestamp        = "invalid-timestamp"         // Something looks like a timestamp but is not a valid one.
+	CodeMismatchedBlock         = "mismatched-block"          // A #+BEGIN_ line has no matching #+END_ line or the other way around.
+	CodeListIndentation         = "list-indentation"          // A list item is indented between the items of its enclosing lists.
+	CodeUnknownLinkAbbreviation = "unknown-link-abbreviation" // A link uses a protocol that is neither built in nor defined with #+LINK.
+)
+
+// lintErrors contains the codes of diagnostics that are errors by default when reported by Lint.
+// Writers report broken links as warnings - so documents with broken links can still be written.
+var lintErrors = map[string]bool{
+	CodeDuplicateCustomID: true,
+	CodeBrokenLink:        true,
+	CodeBadInclude:        true,
+	CodeInvalidTimestamp:  true,
+	CodeMismatchedBlock:   true,
+}
+
+var knownKeywords = map[string]bool{
+	"ARCHIVE": true, "AUTHOR": true, "BIND": true, "CALL": true, "CATEGORY": true, "COLUMNS": true, "CONSTANTS": true,
+	"CREATOR": true, "DATE": true, "DESCRIPTION": true, "DRAFT": true, "EMAIL": true, "EXCLUDE_TAGS": true,
+	"EXPORT_FILE_NAME": true, "FILETAGS": true, "HEADER": true, "HTML": true, "HTML_CONTAINER": true, "HTML_DOCTYPE": true,
+	"HTML_HEAD": true, "HTML_HEAD_EXTRA": true, "HTML_LINK_HOME": true, "HTML_LINK_UP": true, "HTML_MATHJAX": true,
+	"INCLUDE": true, "INDEX": true, "INFOJS_OPT": true, "KEYWORDS": true, "LANGUAGE": true, "LATEX": true,
+	"LATEX_CLASS": true, "LATEX_CLASS_OPTIONS": true, "LATEX_COMPILER": true, "LATEX_HEADER": true,
+	"LATEX_HEADER_EXTRA": true, "LINK": true, "MACRO": true, "MARKDOWN": true, "MD": true, "NAME": true, "OPTIONS": true,
+	"PLOT": true, "PRIORITIES": true, "PROPERTY": true, "SELECT_TAGS": true, "SEQ_TODO": true, "SETUPFILE": true,
+	"STARTUP": true, "SUBTITLE": true, "TAGS": true, "TBLFM": true, "TBLNAME": true, "TEMPLATE": true, "TEX": true,
+	"TITLE": true, "TOC": true, "TODO": true, "TYP_TODO": true,
+}
+
+var knownLinkProtocols = map[string]bool{
+	"attachment": true, "bbdb": true, "doi": true, "docview": true, "elisp": true, "file": true, "file+emacs": true,
+	"file+sys": true, "ftp": true, "gnus": true, "help": true, "http": true, "https": true, "id": true, "info": true,
+	"irc": true, "mailto": true, "mhe": true, "news": true, "rmail": true, "shell": true, "tel": true,
+}
+
+var timestampCandidateRegexp = regexp.MustCompile(`[<\[]\d{4}-\d{2}-\d{2}[^<>\[\]\n]*[>\]]`)
+
+type linter struct {
+	*Document
+	diagnostics []Diagnostic
+}
+
+// Lint checks the document for common mistakes (see org-lint) and returns them together with the Diagnostics
+// found during parsing - sorted by position. Unlike writing, Lint reads the files of all includes (see CheckIncludes).
+// The document is not modified.
+func (d *Document) Lint() []Diagnostic {
+	l := &linter{Document: d}
+	l.checkNodes()
+	l.checkIncludes(l.add)
+	l.checkLines()
+	mismatched := map[int]bool{}
+	for _, diagnostic := range l.diagnostics {
+		if diagnostic.Code == CodeMismatchedBlock {
+			mismatched[diagnostic.Position.Start.Line] = true
+		}
+	}
+	// diagnostics already reported during parsing or writing (e.g. missing includes) are not reported again
+	diagnostics, reported := []Diagnostic{}, map[string]bool{}
+	key := func(d Diagnostic) string { return fmt.Sprint(d.Position, d.Code, d.Message) }
+	for _, diagnostic := range d.Diagnostics {
+		if diagnostic.Code != CodeUnparsableToken || !mismatched[diagnostic.Position.Start.Line] {
+			diagnostics, reported[key(diagnostic)] = append(diagnostics, diagnostic), true
+		}
+	}
+	for _, diagnostic := range l.diagnostics {
+		if !reported[key(diagnostic)] {
+			diagnostics = append(diagnostics, diagnostic)
+		}
+	}
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		pi, pj := diagnostics[i].Position, diagnostics[j].Position
+		if pi.IsValid() != pj.IsValid() {
+			return pi.IsValid()
+		}
+		return pi.Start.Offset < pj.Start.Offset
+	})
+	return diagnostics
+}
+
+func (l *linter) add(code string, pos Position, format string, args ...interface{}) {
+	diagnostic := l.newDiagnostic(code, pos, format, args...)
+	if lintErrors[code] {
+		diagnostic.Severity = SeverityError
+	}
+	l.diagnostics = append(l.diagnostics, diagnostic)
+}
+
+func (l *linter) checkNodes() {
+	customIDs := map[string]bool{}
+	definitions, references := []FootnoteDefinition{}, map[string]bool{}
+	links := []RegularLink{}
+	Walk(l.Nodes, func(n Node, path Path) error {
+		switch n := n.(type) {
+		case Headline:
+			if id, ok := n.Properties.Get("CUSTOM_ID"); ok {
+				if customIDs[id] {
+					l.add(CodeDuplicateCustomID, n.Properties.Position, "duplicate CUSTOM_ID %q", id)
+				}
+				customIDs[id] = true
+			}
+		case PropertyDrawer:
+			if !l.isWellPlaced(n, path) {
+				l.add(CodeMisplacedPropertyDrawer, n.Position, "property drawer does not directly follow a headline")
+			}
+		case Keyword:
+			if !knownKeywords[n.Key] && !strings.HasPrefix(n.Key, "ATTR_") {
+				l.add(CodeUnknownKeyword, n.Position, "unknown keyword #+%s", n.Key)
+			}
+		case FootnoteDefinition:
+			if !n.Inline {
+				definitions = append(definitions, n)
+			}
+		case FootnoteLink:
+			references[n.Name] = true
+		case RegularLink:
+			links = append(links, n)
+		case Text:
+			if !n.IsRaw {
+				l.checkTimestamps(n)
+			}
+		}
+		return nil
+	})
+	for _, definition := range definitions {
+		if !references[definition.Name] {
+			l.add(CodeUnusedFootnote, definition.Position, "footnote [fn:%s] is never referenced", definition.Name)
+		}
+	}
+	targets := l.linkTargets()
+	for _, link := range links {
+		if link.Protocol != "" && !knownLinkProtocols[link.Protocol] && l.Links[link.Protocol] == "" {
+			l.add(CodeUnknownLinkAbbreviation, link.Position, "unknown link abbreviation %q", link.Protocol)
+		} else if l.isInternalLink(link) {
+			if _, _, err := targets.resolve(link.URL); err != nil {
+				l.add(CodeBrokenLink, link.Position, "%s", err)
+			}
+		}
+	}
+}
+
+// isWellPlaced reports whether the property drawer n is the property drawer of a headline or of the document itself
+// (i.e. only preceded by keywords, comments and blank lines).
+func (l *linter) isWellPlaced(n PropertyDrawer, path Path) bool {
+	if h, ok := path.Parent().(Headline); ok {
+		return h.Properties != nil && h.Properties.Position == n.Position
+	} else if len(path) != 0 {
+		return false
+	}
+	for _, node := range l.Nodes {
+		switch node := node.(type) {
+		case PropertyDrawer:
+			return node.Position == n.Position
+		case Keyword, Comment:
+		case Paragraph:
+			if len(node.Children) != 0 {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// checkTimestamps reports text that looks like a timestamp - as text is only left unparsed if it is not a valid one.
+func (l *linter) checkTimestamps(t Text) {
+	for _, m := range timestampCandidateRegexp.FindAllStringIndex(t.Content, -1) {
+		candidate := t.Content[m[0]:m[1]]
+		if _, _, ok := parseSingleTimestamp(candidate); ok {
+			continue
+		}
+		pos := t.Position
+		if prefix := t.Content[:m[0]]; pos.IsValid() && !strings.Contains(prefix, "\n") {
+			pos = Position{pos.Start.add(m[0]), pos.Start.add(m[1])}
+		}
+		l.add(CodeInvalidTimestamp, pos, "invalid timestamp %s", candidate)
+	}
+}
+
+// checkLines checks the block and list structure of the parse input. As the parser falls back to treating
+// mismatched lines as text and re-tokenizes list items, the raw lines are checked rather than the nodes.
+func (l *linter) checkLines() {
+	type block struct {
+		name string
+		line int
+	}
+	blocks, bullets := []block{}, []int{}
+	for i, line := range l.lines {
+		t := tokenize(line)
+		inRawBlock := len(blocks) != 0 && isRawTextBlock(blocks[len(blocks)-1].name)
+		switch {
+		case t.kind == "beginBlock" && !inRawBlock:
+			blocks = append(blocks, block{t.content, i})
+		case t.kind == "beginDynamicBlock" && !inRawBlock:
+			blocks = append(blocks, block{":", i})
+		case (t.kind == "endBlock" || t.kind == "endDynamicBlock") && (!inRawBlock || blocks[len(blocks)-1].name == t.content):
+			name := t.content
+			if t.kind == "endDynamicBlock" {
+				name = ":"
+			}
+			j := len(blocks) - 1
+			for ; j >= 0 && blocks[j].name != name; j-- {
+			}
+			if j < 0 {
+				l.add(CodeMismatchedBlock, l.linePosition(i), "%s without matching #+BEGIN%s", strings.TrimSpace(line), blockSuffix(name))
+				continue
+			}
+			for _, b := range blocks[j+1:] {
+				l.add(CodeMismatchedBlock, l.linePosition(b.line), "#+BEGIN%s without matching #+END%s", blockSuffix(b.name), blockSuffix(b.name))
+			}
+			blocks = blocks[:j]
+		case inRawBlock:
+		case isListToken(t):
+			popped := false
+			for len(bullets) != 0 && bullets[len(bullets)-1] > t.lvl {
+				bullets, popped = bullets[:len(bullets)-1], true
+			}
+			if len(bullets) != 0 && bullets[len(bullets)-1] == t.lvl {
+				continue
+			} else if popped {
+				l.add(CodeListIndentation, l.linePosition(i), "list item is indented between the items of its enclosing lists")
+			}
+			bullets = append(bullets, t.lvl)
+		case t.kind != "text" || t.content != "":
+			for len(bullets) != 0 && bullets[len(bullets)-1] >= t.lvl {
+				bullets = bullets[:len(bullets)-1]
+			}
+		}
+	}
+	for _, b := range blocks {
+		l.add(CodeMismatchedBlock, l.linePosition(b.line), "#+BEGIN%s without matching #+END%s", blockSuffix(b.name), blockSuffix(b.name))
+	}
+}
+
+func (l *linter) linePosition(i int) Position {
+	line := l.lines[i]
+	return Position{l.point(i, len(line)-len(strings.TrimLeft(line, " \t"))), l.point(i, len(line))}
+}
+
+func blockSuffix(name string) string {
+	if name == ":" {
+		return ":"
+	}
+	return "_" + name
+}
@@ -0,0 +1,89 @@
+package org
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	input := `#+TITLE: lint
+#+FOO: bar
+#+LINK: gh https://github.com/%s
+:PROPERTIES:
+:ID: document
+:END:
+
+* one
+:PROPERTIES:
+:CUSTOM_ID: dup
+:END:
+<<target>> [[target]] [[missing]] [[#dup]] [[#nope]] [[*two]] [[*three]] [[gh:x]] [[foo:bar]] [[./file.org]]
+<2021-13-45 Mon> <2021-01-01 Fri>
+:PROPERTIES:
+:X: y
+:END:
+* two
+:PROPERTIES:
+:CUSTOM_ID: dup
+:END:
+reference[fn:1]
+
+[fn:1] used
+[fn:2] unused
+
+- a
+    - b
+  - c
+- d
+
+#+BEGIN_QUOTE
+#+BEGIN_SRC org
+#+END_QUOTE
+#+END_SRC
+#+END_EXAMPLE
+#+BEGIN_CENTER
+`
+	d := New().Silent().Parse(strings.NewReader(input), "./testdata/lint.org")
+	actual := []string{}
+	for _, diagnostic := range d.Lint() {
+		actual = append(actual, diagnostic.String())
+	}
+	expected := []string{
+		`2:1: warning: unknown keyword #+FOO (unknown-keyword)`,
+		`12:23: error: no target, name or headline "missing" for link [[missing]] (broken-link)`,
+		`12:44: error: no headline with CUSTOM_ID "nope" for link [[#nope]] (broken-link)`,
+		`12:63: error: no headline "three" for link [[*three]] (broken-link)`,
+		`12:83: warning: unknown link abbreviation "foo" (unknown-link-abbreviation)`,
+		`13:1: error: invalid timestamp <2021-13-45 Mon> (invalid-timestamp)`,
+		`14:1: warning: property drawer does not directly follow a headline (misplaced-property-drawer)`,
+		`18:1: error: duplicate CUSTOM_ID "dup" (duplicate-custom-id)`,
+		`24:1: warning: footnote [fn:2] is never referenced (unused-footnote)`,
+		`28:3: warning: list item is indented between the items of its enclosing lists (list-indentation)`,
+		`31:1: error: #+BEGIN_QUOTE without matching #+END_QUOTE (mismatched-block)`,
+		`35:1: error: #+END_EXAMPLE without matching #+BEGIN_EXAMPLE (mismatched-block)`,
+		`36:1: error: #+BEGIN_CENTER without matching #+END_CENTER (mismatched-block)`,
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("got diagnostics\n%s\nexpected\n%s", strings.Join(actual, "\n"), strings.Join(expected, "\n"))
+	}
+	if len(d.Diagnostics) != 3 || d.Error != nil {
+		t.Errorf("expected lint to not modify the document, got %v", d.Diagnostics)
+	}
+
+	d = New().Silent().Parse(strings.NewReader("#+TEX: \\newpage\n#+MD: <br>\n#+MARKDOWN: <br>\n"), "./testdata/lint.org")
+	if diagnostics := d.Lint(); len(diagnostics) != 0 {
+		t.Errorf("expected export keywords of all writers to be known, got %v", diagnostics)
+	}
+
+	d = New().Silent().Parse(strings.NewReader("#+INCLUDE: \"does-not-exist.org\" src go\n"), "./testdata/lint.org")
+	missing := `1:1: error: bad include "\"does-not-exist.org\" src go": open testdata/does-not-exist.org: no such file or directory (bad-include)`
+	if diagnostics := d.Lint(); len(diagnostics) != 1 || diagnostics[0].String() != missing {
+		t.Errorf("expected lint to report the missing include, got %v", diagnostics)
+	}
+	d.Write(NewHTMLWriter())
+	if diagnostics := d.Lint(); len(diagnostics) != 1 {
+		t.Errorf("expected lint to not report the missing include twice, got %v", diagnostics)
+	}
+
+}
@@ -10,6 +10,7 @@ import (
 type List struct {
 	Kind  string
 	Items []Node
+	Position
 }
 
 type ListItem struct {
@@ -17,6 +18,7 @@ type ListItem struct {
 	Status   string
 	Value    string
 	Children []Node
+	Position
 }
 
 type DescriptiveListItem struct {
@@ -24,6 +26,7 @@ type DescriptiveListItem struct {
 	Status  string
 	Term    []Node
 	Details []Node
+	Position
 }
 
 var unorderedListRegexp = regexp.MustCompile(`^(\s*)([+*-])(\s+(.*)|$)`)
@@ -62,7 +65,7 @@ func listKind(t token) (string, string) {
 }
 
 func (d *Document) parseList(i int, parentStop stopFn) (int, Node) {
-	start, lvl := i, d.tokens[i].lvl
+	start, startPoint, lvl := i, d.blockStart(i), d.tokens[i].lvl
 	listMainKind, kind := listKind(d.tokens[i])
 	list := List{Kind: kind}
 	stop := func(*Document, int) bool {
@@ -74,14 +77,15 @@ func (d *Document) parseList(i int, parentStop stopFn) (int, Node) {
 	}
 	for !stop(d, i) {
 		consumed, node := d.parseListItem(list, i, parentStop)
-		i += consumed
 		list.Items = append(list.Items, node)
+		i += consumed
 	}
+	list.Position = Position{startPoint, d.blockEnd(start, i-start)}
 	return i - start, list
 }
 
 func (d *Document) parseListItem(l List, i int, parentStop stopFn) (int, Node) {
-	start, nodes, bullet := i, []Node{}, d.tokens[i].matches[2]
+	start, startPoint, nodes, bullet := i, d.blockStart(i), []Node{}, d.tokens[i].matches[2]
 	minIndent, dterm, content, status, value := d.tokens[i].lvl+len(bullet), "", d.tokens[i].content, "", ""
 	originalBaseLvl := d.baseLvl
 	d.baseLvl = minIndent + 1
@@ -91,6 +95,7 @@ func (d *Document) parseListItem(l List, i int, parentStop stopFn) (int, Node) {
 	if m := listItemStatusRegexp.FindStringSubmatch(content); m != nil {
 		status, content = m[1], content[len("[ ] "):]
 	}
+	dtermColumn := d.suffixColumn(i, content)
 	if l.Kind == "descriptive" {
 		if m := descriptiveListItemRegexp.FindStringIndex(content); m != nil {
 			dterm, content = content[:m[0]], content[m[1]:]
@@ -112,10 +117,12 @@ func (d *Document) parseListItem(l List, i int, parentStop stopFn) (int, Node) {
 		nodes = append(nodes, node)
 	}
 	d.baseLvl = originalBaseLvl
+	pos := Position{startPoint, d.blockEnd(start, i-start)}
 	if l.Kind == "descriptive" {
-		return i - start, DescriptiveListItem{bullet, status, d.parseInline(dterm), nodes}
+		term := d.parseInlineFrom(dterm, d.lineSourceMap(start, []string{dterm}, []int{dtermColumn}))
+		return i - start, DescriptiveListItem{bullet, status, term, nodes, pos}
 	}
-	return i - start, ListItem{bullet, status, value, nodes}
+	return i - start, ListItem{bullet, status, value, nodes, pos}
 }
 
 func (n List) String() string                { return orgWriter.WriteNodesAsString(n) }
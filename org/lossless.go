@@ -0,0 +1,240 @@
+package org
+
+import (
+	"hash/fnv"
+	"reflect"
+	"strings"
+)
+
+// source is the parse input retained by documents that were parsed in lossless mode (see Configuration.Lossless).
+// It allows OrgWriter to write nodes that were not modified after parsing exactly as they were parsed.
+type source struct {
+	input        string
+	lineOffsets  []int
+	fingerprints map[sourceKey]uint64 // fingerprints contains a hash of the pretty printed Org mode string of each parsed block node.
+	childLines   map[sourceKey][2]int // childLines contains the first and last line of the block children of each parsed block node.
+}
+
+type sourceKey struct {
+	kind reflect.Type
+	Position
+	shallow bool // shallow is true for the fingerprints of nodes without their block children.
+}
+
+func newSource(input string, lineOffsets []int, nodes []Node) *source {
+	s := &source{input, lineOffsets, map[sourceKey]uint64{}, map[sourceKey][2]int{}}
+	s.addFingerprints(nodes)
+	return s
+}
+
+func (s *source) addFingerprints(nodes []Node) {
+	for _, n := range nodes {
+		if n == nil {
+			continue
+		}
+		children, shell := blockChildren(n)
+		if p := n.Pos(); p.IsValid() {
+			s.fingerprints[sourceKey{reflect.TypeOf(n), p, false}] = fingerprint(n)
+			if shell != nil {
+				key := sourceKey{reflect.TypeOf(n), p, true}
+				s.fingerprints[key] = fingerprint(shell)
+				for _, c := range children {
+					if c == nil || !c.Pos().IsValid() {
+						continue
+					} else if lines, ok := s.childLines[key]; ok {
+						s.childLines[key] = [2]int{lines[0], c.Pos().End.Line - 1}
+					} else {
+						s.childLines[key] = [2]int{c.Pos().Start.Line - 1, c.Pos().End.Line - 1}
+					}
+				}
+			}
+		}
+		s.addFingerprints(children)
+	}
+}
+
+// isUnmodified reports whether n is equal to the node that was parsed from its position. If shallow is true,
+// only n itself is compared (i.e. the copy of it without block children returned by blockChildren).
+func (s *source) isUnmodified(n Node, shallow bool) bool {
+	f, ok := s.fingerprints[sourceKey{reflect.TypeOf(n), n.Pos(), shallow}]
+	if shallow {
+		_, n = blockChildren(n)
+	}
+	return ok && f == fingerprint(n)
+}
+
+// lines returns the lines [i, j) of the input - including their original line endings.
+func (s *source) lines(i, j int) string {
+	if i >= len(s.lineOffsets) {
+		return ""
+	}
+	end := len(s.input)
+	if j < len(s.lineOffsets) {
+		end = s.lineOffsets[j]
+	}
+	return s.input[s.lineOffsets[i]:end]
+}
+
+// hasChildLines reports whether n was parsed with block children (see childLines).
+func (s *source) hasChildLines(n Node) bool {
+	_, ok := s.childLines[sourceKey{reflect.TypeOf(n), n.Pos(), true}]
+	return ok
+}
+
+func (s *source) isBlankLine(i int) bool { return strings.TrimSpace(s.lines(i, i+1)) == "" }
+
+// startsLine reports whether only whitespace precedes p on its line - i.e. whether the lines of a node starting
+// at p can be written without including parts of another node.
+func (s *source) startsLine(p Point) bool {
+	return p.Line <= len(s.lineOffsets) && strings.TrimLeft(s.input[s.lineOffsets[p.Line-1]:p.Offset], " \t") == ""
+}
+
+// startLines reports whether all nodes that were parsed from the source start a line.
+func (s *source) startLines(nodes []Node) bool {
+	for _, n := range nodes {
+		if n != nil && n.Pos().IsValid() && !s.startsLine(n.Pos().Start) {
+			return false
+		}
+	}
+	return true
+}
+
+func fingerprint(n Node) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(String([]Node{n})))
+	return h.Sum64()
+}
+
+// blockChildren returns the nodes that are written on lines of their own as part of n - and a copy of n without them.
+// The copy is nil if n does not contain any such nodes.
+func blockChildren(n Node) ([]Node, Node) {
+	children := []Node{}
+	switch n := n.(type) {
+	case Headline:
+		if n.Planning != nil {
+			children = append(children, *n.Planning)
+		}
+		if n.Properties != nil {
+			children = append(children, *n.Properties)
+		}
+		children = append(children, n.Children...)
+		n.Planning, n.Properties, n.Children = nil, nil, nil
+		return children, n
+	case Block:
+		if !isRawTextBlock(n.Name) {
+			children, n.Children = append(children, n.Children...), nil
+		}
+		if n.Result != nil {
+			children, n.Result = append(children, n.Result), nil
+		}
+		return children, n
+	case DynamicBlock:
+		children, n.Children = append(children, n.Children...), nil
+		return children, n
+	case Result:
+		children, n.Node = append(children, n.Node), nil
+		return children, n
+	case NodeWithMeta:
+		children, n.Node = append(children, n.Node), nil
+		return children, n
+	case NodeWithName:
+		children, n.Node = append(children, n.Node), nil
+		return children, n
+	case Drawer:
+		children, n.Children = append(children, n.Children...), nil
+		return children, n
+	case LogbookDrawer:
+		children, n.Children = append(children, n.Children...), nil
+		return children, n
+	case List:
+		children, n.Items = append(children, n.Items...), nil
+		return children, n
+	case ListItem:
+		children, n.Children = append(children, n.Children...), nil
+		return children, n
+	case DescriptiveListItem:
+		children, n.Details = append(children, n.Details...), nil
+		return children, n
+	case FootnoteDefinition:
+		children, n.Children = append(children, n.Children...), nil
+		return children, n
+	}
+	return nil, nil
+}
+
+// writeSource writes n if the document was parsed in lossless mode and n was parsed from the source:
+// Unmodified nodes are written exactly as they were parsed. Modified nodes are written by OrgWriter - apart from
+// the lines of unmodified parent nodes that surround their (modified) children. Blank lines between nodes are
+// written as they were parsed in either case.
+func (w *OrgWriter) writeSource(n Node) bool {
+	p := n.Pos()
+	if w.source == nil || !p.IsValid() || w.skipSource {
+		w.skipSource = false
+		return false
+	}
+	start, end := p.Start.Line-1, p.End.Line-1
+	if start > w.line {
+		w.writeSourceLines(w.line, start, true)
+		w.line = start
+	}
+	if startsLine := w.source.startsLine(p.Start); startsLine && w.source.isUnmodified(n, false) {
+		w.writeSourceLines(start, end+1, false)
+	} else if children, _ := blockChildren(n); startsLine && w.source.startLines(children) && w.source.isUnmodified(n, true) && w.source.hasChildLines(n) {
+		w.writeSourceChildren(n, children, end)
+	} else {
+		indent := w.indent
+		if startsLine {
+			w.indent = w.source.input[w.source.lineOffsets[start]:p.Start.Offset]
+		}
+		w.skipSource = true
+		WriteNodes(w, n)
+		w.skipSource, w.indent = false, indent
+	}
+	if end+1 > w.line {
+		w.line = end + 1
+	}
+	return true
+}
+
+// writeSourceChildren writes the (possibly modified) children of an unmodified parent node n that ends on line end -
+// and the lines of n before and after the children it was parsed with.
+func (w *OrgWriter) writeSourceChildren(n Node, children []Node, end int) {
+	lines := w.source.childLines[sourceKey{reflect.TypeOf(n), n.Pos(), true}]
+	w.writeSourceLines(w.line, lines[0], false)
+	w.line = lines[0]
+	WriteNodes(w, children...)
+	w.writeSourceLines(lines[1]+1, end+1, false)
+}
+
+// writeSourceLines writes the lines [i, j) of the source - or only the blank lines at the end of them.
+// As nodes can be moved around, a missing final line ending is added - and removed again in After if the
+// output still ends with the last line.
+func (w *OrgWriter) writeSourceLines(i, j int, onlyBlank bool) {
+	for k := i; onlyBlank && k < j; k++ {
+		if !w.source.isBlankLine(k) {
+			i = k + 1
+		}
+	}
+	if i >= j {
+		return
+	}
+	lines := w.source.lines(i, j)
+	if lines != "" && !strings.HasSuffix(lines, "\n") {
+		lines += "\n"
+	}
+	w.WriteString(lines)
+}
+
+func (w *OrgWriter) writeSourceTrailer() {
+	if w.source == nil {
+		return
+	}
+	w.writeSourceLines(w.line, len(w.source.lineOffsets), true)
+	if input := w.source.input; input != "" && !strings.HasSuffix(input, "\n") {
+		lastLine := input[strings.LastIndex(input, "\n")+1:]
+		if out := w.String(); strings.HasSuffix(out, "\n"+lastLine+"\n") || out == lastLine+"\n" {
+			w.Builder.Reset()
+			w.WriteString(out[:len(out)-1])
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package org
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLosslessRoundTrip(t *testing.T) {
+	inputs := []string{
+		"",
+		"no final newline",
+		"* headline    :tag:\n\n\n  indented   paragraph  \n\r\n+ item\n+    item 2\n\n\n",
+		"| a |b|\n|-|-|\n|  1|2 |\n\n#+begin_src go\n  x\n#+end_src\n",
+	}
+	for _, path := range orgTestFiles() {
+		inputs = append(inputs, fileString(path))
+	}
+	for _, input := range inputs {
+		c := New().Silent()
+		c.Lossless = true
+		actual, err := c.Parse(strings.NewReader(input), "./testdata/lossless.org").Write(NewOrgWriter())
+		if err != nil {
+			t.Errorf("%q\n got error: %s", input, err)
+		} else if actual != input {
+			t.Errorf("%q:\n%s", input, diff(actual, input))
+		}
+	}
+}
+
+func TestLosslessModifications(t *testing.T) {
+	input := "#+TITLE:   lossless\n\n* TODO headline  :tag:\n  SCHEDULED: <2021-01-01 Fri>\n\n  some    text\n\n** child   \n   - item   one\n   -  [ ] item two\n\n     second   paragraph\n* other\n"
+	tests := []struct {
+		name     string
+		modify   func(d *Document)
+		expected string
+	}{
+		{"unmodified", func(d *Document) {}, input},
+		{
+			"headline",
+			func(d *Document) {
+				h := d.Nodes[2].(Headline)
+				h.Status = "DONE"
+				d.Nodes[2] = h
+			},
+			strings.Replace(input, "* TODO headline  :tag:", "* DONE headline"+strings.Repeat(" ", 57)+":tag:", 1),
+		},
+		{
+			"nested list item",
+			func(d *Document) {
+				child := d.Nodes[2].(Headline).Children[2].(Headline)
+				item := child.Children[0].(List).Items[1].(ListItem)
+				item.Status = "X"
+				child.Children[0].(List).Items[1] = item
+			},
+			strings.Replace(input, "   -  [ ] item two", "   - [X] item two", 1),
+		},
+		{
+			"appended nodes",
+			func(d *Document) {
+				d.Nodes = append(d.Nodes, Paragraph{[]Node{Text{"new   paragraph", false, Position{}}}, Position{}})
+			},
+			input + "new   paragraph\n",
+		},
+		{
+			"swapped subtrees",
+			func(d *Document) { d.Nodes[2], d.Nodes[3] = d.Nodes[3], d.Nodes[2] },
+			"#+TITLE:   lossless\n\n* other\n" + input[strings.Index(input, "* TODO"):strings.Index(input, "* other")],
+		},
+	}
+	for _, test := range tests {
+		c := New().Silent()
+		c.Lossless = true
+		d := c.Parse(strings.NewReader(input), "./testdata/lossless.org")
+		test.modify(d)
+		if actual, err := d.Write(NewOrgWriter()); err != nil {
+			t.Errorf("%s: got error: %s", test.name, err)
+		} else if actual != test.expected {
+			t.Errorf("%s:\n%s", test.name, diff(actual, test.expected))
+		}
+	}
+}
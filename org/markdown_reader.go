@@ -0,0 +1,746 @@
+package org
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// markdownReader converts CommonMark into the nodes the org parser creates for the equivalent Org mode input.
+type markdownReader struct {
+	d          *Document
+	references map[string]markdownReference
+}
+
+type markdownReference struct {
+	url, title string
+}
+
+var markdownFenceRegexp = regexp.MustCompile("^( {0,3})(`{3,}|~{3,})[ \t]*(.*)$")
+var markdownATXHeadingRegexp = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*)|[ \t]*$)`)
+var markdownATXClosingRegexp = regexp.MustCompile(`(?:^|[ \t]+)#+[ \t]*$`)
+var markdownSetextUnderlineRegexp = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*$`)
+var markdownBlockquoteRegexp = regexp.MustCompile(`^ {0,3}> ?(.*)$`)
+var markdownListItemRegexp = regexp.MustCompile(`^( {0,3})([-+*]|[0-9]{1,9}[.)])([ \t]+(.*)|[ \t]*$)`)
+var markdownTaskRegexp = regexp.MustCompile(`^\[([ xX])\][ \t]+`)
+var markdownFootnoteDefinitionRegexp = regexp.MustCompile(`^ {0,3}\[\^([^\]\s]+)\]:[ \t]*(.*)$`)
+var markdownReferenceDefinitionRegexp = regexp.MustCompile(`^ {0,3}\[((?:[^\]\\]|\\.)+)\]:[ \t]*(<[^>]*>|\S+)(?:[ \t]+("[^"]*"|'[^']*'|\([^)]*\)))?[ \t]*$`)
+var markdownHTMLBlockRegexp = regexp.MustCompile(`(?i)^ {0,3}(<!--|<\?|<![a-z]|</?(address|article|aside|blockquote|body|details|dialog|dd|div|dl|dt|fieldset|figcaption|figure|footer|form|h[1-6]|head|header|hr|html|iframe|li|main|nav|ol|p|pre|script|section|style|summary|table|tbody|td|tfoot|th|thead|tr|ul)(\s|/?>|$))`)
+var markdownTableDelimiterRegexp = regexp.MustCompile(`^ {0,3}\|?[ \t]*:?-+:?[ \t]*(\|[ \t]*:?-+:?[ \t]*)*\|?[ \t]*$`)
+var markdownEntityRegexp = regexp.MustCompile(`^&(#[0-9]{1,7}|#[xX][0-9a-fA-F]{1,6}|[a-zA-Z][a-zA-Z0-9]{1,31});`)
+var markdownAutoLinkRegexp = regexp.MustCompile(`^<([a-zA-Z][a-zA-Z0-9+.-]{1,31}:[^\s<>]*)>`)
+var markdownEmailAutoLinkRegexp = regexp.MustCompile(`^<([^\s@<>]+@[^\s@<>]+\.[^\s@<>]+)>`)
+var markdownInlineHTMLRegexp = regexp.MustCompile(`^(<!--(?s:.*?)-->|</?[a-zA-Z][a-zA-Z0-9-]*(?:\s[^<>]*)?/?>)`)
+
+// ParseMarkdown parses CommonMark input (including the GFM table, task list, strikethrough and footnote extensions)
+// into a Document. The document consists of the same nodes that Parse creates for the equivalent Org mode input -
+// e.g. to convert markdown into Org mode using OrgWriter.
+// Relative links to markdown files are converted into file links to the org file of the same name.
+// To allow method chaining, errors are stored in document.Error rather than being returned.
+func (c *Configuration) ParseMarkdown(input io.Reader, path string) (d *Document) {
+	outlineSection := &Section{}
+	d = &Document{
+		Configuration:  c,
+		Outline:        Outline{outlineSection, outlineSection, 0},
+		BufferSettings: map[string]string{},
+		NamedNodes:     map[string]Node{},
+		Links:          map[string]string{},
+		Macros:         map[string]string{},
+		Path:           path,
+	}
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			d.setError(fmt.Errorf("could not parse markdown: %v", recovered))
+		}
+	}()
+	data, err := ioutil.ReadAll(input)
+	if err != nil {
+		d.setError(err)
+		return d
+	}
+	lines := strings.Split(strings.TrimRight(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n"), "\n")
+	r := &markdownReader{d, map[string]markdownReference{}}
+	r.collectReferences(lines)
+	index := 0
+	d.Nodes = d.addSections(nestSections(r.parseBlocks(lines), &index))
+	return d
+}
+
+// collectReferences collects the link reference definitions ([label]: url "title") of the input, as links can
+// reference definitions that come after them.
+func (r *markdownReader) collectReferences(lines []string) {
+	fence := ""
+	for _, line := range lines {
+		if m := markdownFenceRegexp.FindStringSubmatch(line); m != nil && (fence == "" || strings.HasPrefix(m[2], fence) && m[3] == "") {
+			if fence == "" {
+				fence = m[2]
+			} else {
+				fence = ""
+			}
+		} else if m := markdownReferenceDefinitionRegexp.FindStringSubmatch(line); m != nil && fence == "" {
+			label := normalizeMarkdownLabel(m[1])
+			if _, ok := r.references[label]; !ok {
+				title := m[3]
+				if len(title) >= 2 {
+					title = title[1 : len(title)-1]
+				}
+				r.references[label] = markdownReference{strings.TrimSuffix(strings.TrimPrefix(m[2], "<"), ">"), title}
+			}
+		}
+	}
+}
+
+// parseBlocks parses lines into block nodes. Blank lines between blocks are kept as empty paragraphs - just like
+// the org parser does.
+func (r *markdownReader) parseBlocks(lines []string) []Node {
+	nodes, blank := []Node{}, false
+	for i := 0; i < len(lines); {
+		if isBlankMarkdownLine(lines[i]) {
+			blank, i = true, i+1
+			continue
+		}
+		consumed, node := r.parseBlock(lines, i)
+		i += consumed
+		if node == nil {
+			continue
+		}
+		if blank && len(nodes) != 0 {
+			nodes = append(nodes, Paragraph{})
+		}
+		blank, nodes = false, append(nodes, node)
+	}
+	return nodes
+}
+
+func (r *markdownReader) parseBlock(lines []string, i int) (int, Node) {
+	line := lines[i]
+	if m := markdownFenceRegexp.FindStringSubmatch(line); m != nil && !(m[2][0] == '`' && strings.Contains(m[3], "`")) {
+		return r.parseFencedCode(lines, i, m)
+	} else if m := markdownATXHeadingRegexp.FindStringSubmatch(line); m != nil {
+		title := markdownATXClosingRegexp.ReplaceAllString(strings.TrimSpace(m[2]), "")
+		return 1, Headline{Lvl: len(m[1]), Title: r.parseInline(title)}
+	} else if isMarkdownThematicBreak(line) {
+		return 1, HorizontalRule{}
+	} else if markdownBlockquoteRegexp.MatchString(line) {
+		return r.parseBlockquote(lines, i)
+	} else if markdownListItemRegexp.MatchString(line) {
+		return r.parseList(lines, i)
+	} else if m := markdownFootnoteDefinitionRegexp.FindStringSubmatch(line); m != nil {
+		return r.parseFootnoteDefinition(lines, i, m)
+	} else if markdownReferenceDefinitionRegexp.MatchString(line) {
+		return 1, nil
+	} else if markdownHTMLBlockRegexp.MatchString(line) {
+		j := i
+		for ; j < len(lines) && !isBlankMarkdownLine(lines[j]); j++ {
+		}
+		return j - i, r.rawBlock("EXPORT", []string{"html"}, lines[i:j])
+	} else if markdownIndentation(line) >= 4 {
+		return r.parseIndentedCode(lines, i)
+	} else if i+1 < len(lines) && markdownTableDelimiterRegexp.MatchString(lines[i+1]) && strings.Contains(line, "|") {
+		if consumed, node := r.parseTable(lines, i); consumed != 0 {
+			return consumed, node
+		}
+	}
+	return r.parseParagraph(lines, i)
+}
+
+func (r *markdownReader) parseParagraph(lines []string, i int) (int, Node) {
+	start, content := i, []string{strings.TrimLeft(lines[i], " \t")}
+	for i++; i < len(lines) && !isBlankMarkdownLine(lines[i]); i++ {
+		if m := markdownSetextUnderlineRegexp.FindStringSubmatch(lines[i]); m != nil {
+			lvl := 1
+			if m[1][0] == '-' {
+				lvl = 2
+			}
+			return i + 1 - start, Headline{Lvl: lvl, Title: r.parseInline(strings.TrimSpace(strings.Join(content, "\n")))}
+		} else if r.interruptsParagraph(lines[i]) {
+			break
+		}
+		content = append(content, strings.TrimLeft(lines[i], " \t"))
+	}
+	return i - start, Paragraph{r.parseInline(strings.TrimRight(strings.Join(content, "\n"), " ")), Position{}}
+}
+
+// interruptsParagraph returns true if line starts a block that can interrupt a paragraph.
+func (r *markdownReader) interruptsParagraph(line string) bool {
+	if m := markdownListItemRegexp.FindStringSubmatch(line); m != nil {
+		n, err := strconv.Atoi(m[2][:len(m[2])-1])
+		return strings.TrimSpace(m[4]) != "" && (err != nil || n == 1)
+	}
+	return markdownFenceRegexp.MatchString(line) || markdownATXHeadingRegexp.MatchString(line) ||
+		isMarkdownThematicBreak(line) || markdownBlockquoteRegexp.MatchString(line) ||
+		markdownHTMLBlockRegexp.MatchString(line) || markdownFootnoteDefinitionRegexp.MatchString(line)
+}
+
+func (r *markdownReader) parseFencedCode(lines []string, i int, m []string) (int, Node) {
+	start, indent, fence, content := i, len(m[1]), m[2], []string{}
+	closing := regexp.MustCompile("^ {0,3}" + regexp.QuoteMeta(fence[:1]) + "{" + strconv.Itoa(len(fence)) + ",}[ \t]*$")
+	for i++; i < len(lines) && !closing.MatchString(lines[i]); i++ {
+		content = append(content, trimMarkdownIndentation(lines[i], indent))
+	}
+	consumed := i + 1 - start
+	if i == len(lines) {
+		consumed = i - start
+	}
+	if info := strings.Fields(m[3]); len(info) != 0 {
+		return consumed, r.rawBlock("SRC", []string{info[0]}, content)
+	}
+	return consumed, r.rawBlock("EXAMPLE", nil, content)
+}
+
+func (r *markdownReader) parseIndentedCode(lines []string, i int) (int, Node) {
+	start, content := i, []string{}
+	for ; i < len(lines) && (isBlankMarkdownLine(lines[i]) || markdownIndentation(lines[i]) >= 4); i++ {
+		if isBlankMarkdownLine(lines[i]) {
+			content = append(content, "")
+		} else {
+			content = append(content, trimMarkdownIndentation(lines[i], 4))
+		}
+	}
+	for len(content) != 0 && content[len(content)-1] == "" {
+		content, i = content[:len(content)-1], i-1
+	}
+	return i - start, r.rawBlock("EXAMPLE", nil, content)
+}
+
+func (r *markdownReader) rawBlock(name string, parameters []string, lines []string) Block {
+	rawText := ""
+	if len(lines) != 0 {
+		rawText = strings.Join(lines, "\n") + "\n"
+	}
+	return Block{name, parameters, r.d.parseRawInlineFrom(rawText, nil), nil, Position{}}
+}
+
+func (r *markdownReader) parseBlockquote(lines []string, i int) (int, Node) {
+	start, content := i, []string{}
+	for ; i < len(lines); i++ {
+		if m := markdownBlockquoteRegexp.FindStringSubmatch(lines[i]); m != nil {
+			content = append(content, m[1])
+		} else if isLazyMarkdownContinuation(content, lines[i]) && !r.interruptsParagraph(lines[i]) {
+			content = append(content, strings.TrimLeft(lines[i], " \t"))
+		} else {
+			break
+		}
+	}
+	return i - start, Block{"QUOTE", nil, r.parseBlocks(content), nil, Position{}}
+}
+
+func (r *markdownReader) parseList(lines []string, i int) (int, Node) {
+	start, first := i, markdownListItemRegexp.FindStringSubmatch(lines[i])
+	list := List{Kind: "unordered"}
+	if isOrderedMarkdownMarker(first[2]) {
+		list.Kind = "ordered"
+	}
+	for i < len(lines) {
+		m := markdownListItemRegexp.FindStringSubmatch(lines[i])
+		if m == nil || !isSameMarkdownList(first[2], m[2]) {
+			break
+		}
+		width, content := len(m[1])+len(m[2])+1, m[4]
+		if spaces := markdownIndentation(m[3]); content != "" && spaces <= 4 {
+			width = len(m[1]) + len(m[2]) + spaces
+		} else if content != "" {
+			content = m[3][1:]
+		}
+		itemLines := []string{content}
+		j := i + 1
+		for ; j < len(lines); j++ {
+			if line := lines[j]; isBlankMarkdownLine(line) {
+				itemLines = append(itemLines, "")
+			} else if markdownIndentation(line) >= width {
+				itemLines = append(itemLines, trimMarkdownIndentation(line, width))
+			} else if isLazyMarkdownContinuation(itemLines, line) && !r.interruptsParagraph(line) && !markdownListItemRegexp.MatchString(line) {
+				itemLines = append(itemLines, strings.TrimLeft(line, " \t"))
+			} else {
+				break
+			}
+		}
+		blank := false
+		for len(itemLines) > 1 && itemLines[len(itemLines)-1] == "" {
+			itemLines, j, blank = itemLines[:len(itemLines)-1], j-1, true
+		}
+		item := ListItem{Bullet: m[2]}
+		if item.Bullet == "*" {
+			item.Bullet = "-"
+		}
+		if list.Kind == "ordered" && len(list.Items) == 0 {
+			if n, _ := strconv.Atoi(m[2][:len(m[2])-1]); n != 1 {
+				item.Value = strconv.Itoa(n)
+			}
+		}
+		if m := markdownTaskRegexp.FindStringSubmatch(itemLines[0]); m != nil {
+			item.Status, itemLines[0] = strings.ToUpper(m[1]), itemLines[0][len(m[0]):]
+		}
+		item.Children = r.parseBlocks(itemLines)
+		i = j
+		if next := j + 1; blank && next < len(lines) {
+			if m := markdownListItemRegexp.FindStringSubmatch(lines[next]); m != nil && isSameMarkdownList(first[2], m[2]) {
+				item.Children, i = append(item.Children, Paragraph{}), next
+			}
+		}
+		list.Items = append(list.Items, item)
+	}
+	return i - start, list
+}
+
+func (r *markdownReader) parseFootnoteDefinition(lines []string, i int, m []string) (int, Node) {
+	start, content := i, []string{m[2]}
+	for i++; i < len(lines); i++ {
+		if line := lines[i]; isBlankMarkdownLine(line) {
+			content = append(content, "")
+		} else if markdownIndentation(line) >= 4 {
+			content = append(content, trimMarkdownIndentation(line, 4))
+		} else if isLazyMarkdownContinuation(content, line) && !r.interruptsParagraph(line) {
+			content = append(content, strings.TrimLeft(line, " \t"))
+		} else {
+			break
+		}
+	}
+	for len(content) > 1 && content[len(content)-1] == "" {
+		content, i = content[:len(content)-1], i-1
+	}
+	return i - start, FootnoteDefinition{markdownFootnoteName(m[1]), r.parseBlocks(content), false, Position{}}
+}
+
+func (r *markdownReader) parseTable(lines []string, i int) (int, Node) {
+	start, header, delimiters := i, splitMarkdownTableRow(lines[i]), splitMarkdownTableRow(lines[i+1])
+	if len(header) != len(delimiters) {
+		return 0, nil
+	}
+	cells := [][][]Node{r.parseTableCells(header, len(header))}
+	for i += 2; i < len(lines) && !isBlankMarkdownLine(lines[i]) && !r.interruptsParagraph(lines[i]); i++ {
+		cells = append(cells, r.parseTableCells(splitMarkdownTableRow(lines[i]), len(header)))
+	}
+	alignments, isAligned := make([][]Node, len(delimiters)), false
+	for j, d := range delimiters {
+		cookie := ""
+		switch left, right := strings.HasPrefix(d, ":"), strings.HasSuffix(d, ":"); {
+		case left && right:
+			cookie = "<c>"
+		case right:
+			cookie = "<r>"
+		case left:
+			cookie = "<l>"
+		}
+		if cookie != "" {
+			alignments[j], isAligned = []Node{Text{cookie, false, Position{}}}, true
+		}
+	}
+	rows := [][][]Node{cells[0], nil}
+	if isAligned {
+		rows = append([][][]Node{alignments}, rows...)
+	}
+	table := newTable(append(rows, cells[1:]...))
+	return i - start, table
+}
+
+func (r *markdownReader) parseTableCells(row []string, n int) [][]Node {
+	cells := make([][]Node, n)
+	for i := range cells {
+		if i < len(row) {
+			cells[i] = r.parseInline(strings.ReplaceAll(row[i], `\|`, `\vert{}`))
+		}
+	}
+	return cells
+}
+
+// parseInline parses the inline markup of a paragraph (or heading, table cell, ...).
+func (r *markdownReader) parseInline(input string) []Node {
+	nodes, text := []Node{}, strings.Builder{}
+	flush := func() {
+		if text.Len() != 0 {
+			nodes = append(nodes, Text{text.String(), false, Position{}})
+			text.Reset()
+		}
+	}
+	for i := 0; i < len(input); {
+		consumed, node := 0, (Node)(nil)
+		switch c := input[i]; c {
+		case '\\':
+			if i+1 < len(input) && input[i+1] == '\n' {
+				consumed, node = 2, ExplicitLineBreak{}
+			} else if i+1 < len(input) && unicode.IsPunct(rune(input[i+1])) || i+1 < len(input) && unicode.IsSymbol(rune(input[i+1])) {
+				text.WriteByte(input[i+1])
+				i += 2
+				continue
+			}
+		case '`':
+			consumed, node = parseMarkdownCodeSpan(input, i)
+		case '*', '_':
+			consumed, node = r.parseEmphasis(input, i)
+		case '~':
+			if strings.HasPrefix(input[i:], "~~") && i+2 < len(input) && !unicode.IsSpace(rune(input[i+2])) {
+				if end := markdownEmphasisCloser(input, i+2, '~', 2); end != -1 {
+					consumed, node = end+2-i, Emphasis{"+", r.parseInline(input[i+2 : end]), Position{}}
+				}
+			}
+		case '!':
+			if i+1 < len(input) && input[i+1] == '[' {
+				consumed, node = r.parseLink(input, i+1, true)
+				if consumed != 0 {
+					consumed++
+				}
+			}
+		case '[':
+			consumed, node = r.parseLink(input, i, false)
+		case '<':
+			consumed, node = parseMarkdownAngleBrackets(input, i)
+		case '&':
+			if m := markdownEntityRegexp.FindString(input[i:]); m != "" {
+				text.WriteString(html.UnescapeString(m))
+				i += len(m)
+				continue
+			}
+		case '\n':
+			content := text.String()
+			trimmed := strings.TrimRight(content, " ")
+			text.Reset()
+			text.WriteString(trimmed)
+			if len(content)-len(trimmed) >= 2 {
+				consumed, node = 1, ExplicitLineBreak{}
+			} else {
+				consumed, node = r.d.parseLineBreak(input, i)
+			}
+		}
+		if consumed == 0 {
+			text.WriteByte(input[i])
+			i++
+			continue
+		}
+		flush()
+		nodes = append(nodes, node)
+		i += consumed
+	}
+	flush()
+	return nodes
+}
+
+func (r *markdownReader) parseEmphasis(input string, start int) (int, Node) {
+	c, n := input[start], 0
+	for n = 0; start+n < len(input) && input[start+n] == c; n++ {
+	}
+	if start+n >= len(input) || unicode.IsSpace(rune(input[start+n])) {
+		return 0, nil
+	} else if before, _ := utf8.DecodeLastRuneInString(input[:start]); c == '_' && start != 0 && isMarkdownWordRune(before) {
+		return 0, nil
+	}
+	if n > 3 {
+		n = 3
+	}
+	for ; n >= 1; n-- {
+		end := markdownEmphasisCloser(input, start+n, c, n)
+		if end == -1 {
+			continue
+		}
+		content := r.parseInline(input[start+n : end])
+		switch n {
+		case 1:
+			return end + n - start, Emphasis{"/", content, Position{}}
+		case 2:
+			return end + n - start, Emphasis{"*", content, Position{}}
+		default:
+			return end + n - start, Emphasis{"/", []Node{Emphasis{"*", content, Position{}}}, Position{}}
+		}
+	}
+	return 0, nil
+}
+
+// parseLink parses [description](url "title"), [description][label], [label][] and [label] links, images (if
+// isImage - i.e. the link was prefixed with !) and [^footnote] references.
+func (r *markdownReader) parseLink(input string, start int, isImage bool) (int, Node) {
+	end := markdownBracketEnd(input, start)
+	if end == -1 {
+		return 0, nil
+	}
+	label, rest := input[start+1:end], input[end+1:]
+	if strings.HasPrefix(label, "^") && !isImage && !strings.ContainsAny(label, " \t\n") && len(label) > 1 {
+		return end + 1 - start, FootnoteLink{markdownFootnoteName(label[1:]), nil, Position{}}
+	}
+	url, consumed := "", end+1-start
+	if n, destination, ok := parseMarkdownLinkDestination(rest); ok {
+		url, consumed = destination, consumed+n
+	} else if labelEnd := markdownBracketEnd(rest, 0); strings.HasPrefix(rest, "[") && labelEnd != -1 {
+		reference := rest[1:labelEnd]
+		if reference == "" {
+			reference = label
+		}
+		ref, ok := r.references[normalizeMarkdownLabel(reference)]
+		if !ok {
+			return 0, nil
+		}
+		url, consumed = ref.url, consumed+labelEnd+1
+	} else if ref, ok := r.references[normalizeMarkdownLabel(label)]; ok {
+		url = ref.url
+	} else {
+		return 0, nil
+	}
+	protocol, url := orgLinkURL(url, ".md", ".markdown")
+	if isImage {
+		return consumed, RegularLink{protocol, nil, url, false, Position{}}
+	}
+	description := r.parseInline(label)
+	if len(description) == 1 {
+		if l, ok := description[0].(RegularLink); ok && l.Description == nil {
+			description = []Node{Text{l.URL, false, Position{}}}
+		}
+	}
+	if len(description) == 0 {
+		description = nil
+	}
+	return consumed, RegularLink{protocol, description, url, false, Position{}}
+}
+
+// markdownEmphasisCloser returns the index of the delimiter run of n c's that closes the emphasis starting at start.
+// Nested delimiter runs of a different length are skipped.
+func markdownEmphasisCloser(input string, start int, c byte, n int) int {
+	for j := start; j < len(input); {
+		switch input[j] {
+		case '\\':
+			j += 2
+		case '`':
+			if consumed, _ := parseMarkdownCodeSpan(input, j); consumed != 0 {
+				j += consumed
+			} else {
+				j++
+			}
+		case c:
+			m := 0
+			for ; j+m < len(input) && input[j+m] == c; m++ {
+			}
+			before, _ := utf8.DecodeLastRuneInString(input[:j])
+			after, _ := utf8.DecodeRuneInString(input[j+m:])
+			if m == n && j > start && !unicode.IsSpace(before) && !(c == '_' && isMarkdownWordRune(after)) {
+				return j
+			} else if m != n && j+m < len(input) && !unicode.IsSpace(after) {
+				if k := markdownEmphasisCloser(input, j+m, c, m); k != -1 {
+					j = k + m
+					continue
+				}
+			}
+			j += m
+		default:
+			j++
+		}
+	}
+	return -1
+}
+
+func parseMarkdownCodeSpan(input string, start int) (int, Node) {
+	n := 0
+	for ; start+n < len(input) && input[start+n] == '`'; n++ {
+	}
+	for j := start + n; j < len(input); {
+		if input[j] != '`' {
+			j++
+			continue
+		}
+		m := 0
+		for ; j+m < len(input) && input[j+m] == '`'; m++ {
+		}
+		if m != n {
+			j += m
+			continue
+		}
+		content := strings.ReplaceAll(input[start+n:j], "\n", " ")
+		if len(content) >= 2 && content[0] == ' ' && content[len(content)-1] == ' ' && strings.TrimSpace(content) != "" {
+			content = content[1 : len(content)-1]
+		}
+		kind := "~"
+		if strings.Contains(content, "~") {
+			kind = "="
+		}
+		return j + m - start, Emphasis{kind, []Node{Text{content, true, Position{}}}, Position{}}
+	}
+	return 0, nil
+}
+
+func parseMarkdownAngleBrackets(input string, start int) (int, Node) {
+	if m := markdownAutoLinkRegexp.FindStringSubmatch(input[start:]); m != nil {
+		protocol, url := orgLinkURL(m[1], ".md", ".markdown")
+		return len(m[0]), RegularLink{protocol, nil, url, false, Position{}}
+	} else if m := markdownEmailAutoLinkRegexp.FindStringSubmatch(input[start:]); m != nil {
+		return len(m[0]), RegularLink{"mailto", nil, "mailto:" + m[1], false, Position{}}
+	} else if m := markdownInlineHTMLRegexp.FindString(input[start:]); m != "" {
+		return len(m), InlineBlock{"export", []string{"html"}, []Node{Text{m, true, Position{}}}, Position{}}
+	}
+	return 0, nil
+}
+
+// parseMarkdownLinkDestination parses the (url "title") part of an inline link.
+func parseMarkdownLinkDestination(input string) (int, string, bool) {
+	if !strings.HasPrefix(input, "(") {
+		return 0, "", false
+	}
+	i := 1
+	skipSpace := func() {
+		for ; i < len(input) && unicode.IsSpace(rune(input[i])); i++ {
+		}
+	}
+	skipSpace()
+	url := ""
+	if i < len(input) && input[i] == '<' {
+		end := strings.IndexAny(input[i:], ">\n")
+		if end == -1 || input[i+end] != '>' {
+			return 0, "", false
+		}
+		url, i = input[i+1:i+end], i+end+1
+	} else {
+		depth, j := 0, i
+		for ; j < len(input) && !unicode.IsSpace(rune(input[j])); j++ {
+			if input[j] == '\\' {
+				j++
+			} else if input[j] == '(' {
+				depth++
+			} else if input[j] == ')' {
+				if depth == 0 {
+					break
+				}
+				depth--
+			}
+		}
+		if j > len(input) {
+			return 0, "", false
+		}
+		url, i = input[i:j], j
+	}
+	skipSpace()
+	if i < len(input) && strings.IndexByte(`"'(`, input[i]) != -1 {
+		closing := map[byte]byte{'"': '"', '\'': '\'', '(': ')'}[input[i]]
+		end := strings.IndexByte(input[i+1:], closing)
+		if end == -1 {
+			return 0, "", false
+		}
+		i += end + 2
+		skipSpace()
+	}
+	if i >= len(input) || input[i] != ')' {
+		return 0, "", false
+	}
+	return i + 1, url, true
+}
+
+func markdownBracketEnd(input string, start int) int {
+	if start >= len(input) || input[start] != '[' {
+		return -1
+	}
+	depth := 0
+	for i := start; i < len(input); i++ {
+		switch input[i] {
+		case '\\':
+			i++
+		case '`':
+			if consumed, _ := parseMarkdownCodeSpan(input, i); consumed != 0 {
+				i += consumed - 1
+			}
+		case '[':
+			depth++
+		case ']':
+			if depth--; depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// splitMarkdownTableRow splits a GFM table row into its trimmed cells.
+func splitMarkdownTableRow(row string) []string {
+	row = strings.TrimSpace(row)
+	row = strings.TrimPrefix(row, "|")
+	if strings.HasSuffix(row, "|") && !strings.HasSuffix(row, `\|`) {
+		row = row[:len(row)-1]
+	}
+	cells, start := []string{}, 0
+	for i := 0; i < len(row); i++ {
+		switch row[i] {
+		case '\\':
+			i++
+		case '`':
+			if consumed, _ := parseMarkdownCodeSpan(row, i); consumed != 0 {
+				i += consumed - 1
+			}
+		case '|':
+			cells, start = append(cells, strings.TrimSpace(row[start:i])), i+1
+		}
+	}
+	return append(cells, strings.TrimSpace(row[start:]))
+}
+
+func isMarkdownThematicBreak(line string) bool {
+	if markdownIndentation(line) >= 4 {
+		return false
+	}
+	s := strings.Join(strings.Fields(line), "")
+	return len(s) >= 3 && strings.Trim(s, s[:1]) == "" && strings.Contains("-*_", s[:1])
+}
+
+// isLazyMarkdownContinuation returns true if line can continue the paragraph at the end of lines
+// without the indentation (or > prefix) required by its container.
+func isLazyMarkdownContinuation(lines []string, line string) bool {
+	return len(lines) != 0 && lines[len(lines)-1] != "" && !isBlankMarkdownLine(line) &&
+		markdownIndentation(lines[len(lines)-1]) < 4 && !markdownFenceRegexp.MatchString(lines[len(lines)-1])
+}
+
+func isOrderedMarkdownMarker(marker string) bool { return unicode.IsDigit(rune(marker[0])) }
+
+func isSameMarkdownList(marker, other string) bool {
+	if isOrderedMarkdownMarker(marker) {
+		return isOrderedMarkdownMarker(other) && marker[len(marker)-1] == other[len(other)-1]
+	}
+	return marker == other
+}
+
+func isBlankMarkdownLine(line string) bool { return strings.TrimSpace(line) == "" }
+
+func isMarkdownWordRune(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }
+
+// markdownIndentation returns the width of the indentation of line (using a tab stop of 4).
+func markdownIndentation(line string) int {
+	n := 0
+	for _, c := range line {
+		switch c {
+		case ' ':
+			n++
+		case '\t':
+			n += 4 - n%4
+		default:
+			return n
+		}
+	}
+	return n
+}
+
+// trimMarkdownIndentation removes up to n columns of indentation from line. Tabs that are only partially
+// removed are replaced with the remaining spaces.
+func trimMarkdownIndentation(line string, n int) string {
+	column := 0
+	for i, c := range line {
+		switch {
+		case column >= n:
+			return line[i:]
+		case c == ' ':
+			column++
+		case c == '\t':
+			width := 4 - column%4
+			if column+width > n {
+				return strings.Repeat(" ", column+width-n) + line[i+1:]
+			}
+			column += width
+		default:
+			return line[i:]
+		}
+	}
+	return ""
+}
+
+func normalizeMarkdownLabel(label string) string {
+	return strings.ToLower(strings.Join(strings.Fields(label), " "))
+}
+
+func markdownFootnoteName(label string) string { return strings.Join(strings.Fields(label), "-") }
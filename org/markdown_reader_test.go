@@ -0,0 +1,41 @@
+package org
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseMarkdown(t *testing.T) {
+	paths, err := filepath.Glob("./testdata/markdown/*.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range paths {
+		expected := fileString(path[:len(path)-len(".md")] + ".org")
+		reader, writer := strings.NewReader(fileString(path)), NewOrgWriter()
+		actual, err := New().Silent().ParseMarkdown(reader, path).Write(writer)
+		if err != nil {
+			t.Errorf("%s\n got error: %s", path, err)
+			continue
+		}
+		if actual != expected {
+			t.Errorf("%s:\n%s'", path, diff(actual, expected))
+		} else {
+			t.Logf("%s: passed!", path)
+		}
+	}
+}
+
+func TestParseMarkdownOutline(t *testing.T) {
+	d := New().Silent().ParseMarkdown(strings.NewReader("# a\n## b\ntext\n# c\n"), "")
+	if d.Error != nil {
+		t.Fatal(d.Error)
+	}
+	if n := len(d.Outline.Children); n != 2 {
+		t.Fatalf("expected 2 top-level sections, got %d", n)
+	}
+	if h := d.Outline.Children[0].Children[0].Headline; h.Index != 2 || h.Lvl != 2 || String(h.Title) != "b" {
+		t.Errorf("bad nested headline: %#v", h)
+	}
+}
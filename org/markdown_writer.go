@@ -0,0 +1,625 @@
+package org
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	u "net/url"
+)
+
+// MarkdownWriter exports an org document into a markdown (CommonMark with GitHub Flavored Markdown extensions) document.
+// Constructs that cannot be expressed in markdown (e.g. videos, underlines or #+ATTR_HTML attributes) are exported as inline html.
+type MarkdownWriter struct {
+	ExtendingWriter Writer
+
+	strings.Builder
+	document   *Document
+	footnotes  *footnotes
+	htmlWriter *HTMLWriter
+	escape     bool
+	inTable    bool
+}
+
+var emphasisMarkdownBorders = map[string][]string{
+	"/":   []string{"*", "*"},
+	"*":   []string{"**", "**"},
+	"+":   []string{"~~", "~~"},
+	"_":   []string{"<u>", "</u>"},
+	"_{}": []string{"<sub>", "</sub>"},
+	"^{}": []string{"<sup>", "</sup>"},
+}
+
+var markdownEscaper = strings.NewReplacer(`\`, `\\`, "`", "\\`", `*`, `\*`, `_`, `\_`, `[`, `\[`, `]`, `\]`, `<`, `\<`, `~`, `\~`)
+var markdownLineStartRegexp = regexp.MustCompile(`(?m)^([ \t]*)(#{1,6}(?:[ \t]|$)|[-+>](?:[ \t]|$)|=+[ \t]*$|-+[ \t]*$)`)
+var markdownOrderedLineStartRegexp = regexp.MustCompile(`(?m)^([ \t]*\d{1,9})([.)](?:[ \t]|$))`)
+var markdownBacktickRunRegexp = regexp.MustCompile("`+")
+var multipleBlankLinesRegexp = regexp.MustCompile(`\n{3,}`)
+
+func NewMarkdownWriter() *MarkdownWriter {
+	defaultConfig := New()
+	return &MarkdownWriter{
+		document:   &Document{Configuration: defaultConfig},
+		escape:     true,
+		footnotes:  &footnotes{mapping: map[string]int{}},
+		htmlWriter: NewHTMLWriter(),
+	}
+}
+
+func (w *MarkdownWriter) WriterWithExtensions() Writer {
+	if w.ExtendingWriter != nil {
+		return w.ExtendingWriter
+	}
+	return w
+}
+
+func (w *MarkdownWriter) WriteNodesAsString(nodes ...Node) string {
+	original := w.Builder
+	w.Builder = strings.Builder{}
+	WriteNodes(w, nodes...)
+	out := w.String()
+	w.Builder = original
+	return out
+}
+
+func (w *MarkdownWriter) Before(d *Document) {
+	w.document = d
+	w.htmlWriter.document = d
+	if title := d.Get("TITLE"); title != "" && d.GetOption("title") != "nil" {
+		titleDocument := d.parseFragment(title)
+		if titleDocument.Error == nil {
+			title = strings.TrimSpace(w.WriteNodesAsString(titleDocument.Nodes...))
+		}
+		w.WriteString("# " + title + "\n\n")
+	}
+	if d.GetOption("toc") != "nil" {
+		maxLvl, _ := strconv.Atoi(d.GetOption("toc"))
+		w.WriteOutline(d, maxLvl)
+	}
+}
+
+func (w *MarkdownWriter) After(d *Document) {
+	w.WriteFootnotes(d)
+	out := multipleBlankLinesRegexp.ReplaceAllString(w.String(), "\n\n")
+	w.Builder.Reset()
+	if out = strings.TrimSpace(out); out != "" {
+		w.WriteString(out + "\n")
+	}
+}
+
+func (w *MarkdownWriter) WriteOutline(d *Document, maxLvl int) {
+	out := ""
+	var writeSection func(*Section, string)
+	writeSection = func(s *Section, indent string) {
+		if (maxLvl != 0 && s.Headline.Lvl > maxLvl) || s.IsExcluded(d) {
+			return
+		}
+		title := strings.TrimSpace(w.WriteNodesAsString(s.Headline.Title...))
+		out += fmt.Sprintf("%s- [%s](#%s)\n", indent, title, s.Headline.ID())
+		for _, child := range s.Children {
+			writeSection(child, indent+"  ")
+		}
+	}
+	for _, s := range d.Outline.Children {
+		writeSection(s, "")
+	}
+	if out != "" {
+		w.WriteString(out + "\n")
+	}
+}
+
+func (w *MarkdownWriter) WriteFootnotes(d *Document) {
+	if d.GetOption("f") == "nil" || len(w.footnotes.list) == 0 {
+		return
+	}
+	w.WriteString("\n")
+	for i, definition := range w.footnotes.list {
+		if definition == nil {
+			w.document.addDiagnostic(CodeUndefinedFootnote, Position{}, "undefined footnote [fn:%s]", w.footnotes.name(i))
+			continue
+		}
+		content := strings.TrimSpace(w.writeBlocks(definition.Children))
+		w.WriteString(fmt.Sprintf("[^%d]: %s\n\n", i+1, indentLines(content, "    ")))
+	}
+}
+
+func (w *MarkdownWriter) WriteKeyword(k Keyword) {
+	switch k.Key {
+	case "HTML", "MD", "MARKDOWN":
+		w.WriteString(k.Value + "\n\n")
+	case "TOC":
+		if m := tocHeadlineMaxLvlRegexp.FindStringSubmatch(k.Value); m != nil {
+			maxLvl, _ := strconv.Atoi(m[1])
+			w.WriteOutline(w.document, maxLvl)
+		}
+	}
+}
+
+func (w *MarkdownWriter) WriteInclude(i Include) { WriteNodes(w, i.Resolve()) }
+
+func (w *MarkdownWriter) WriteComment(Comment)               {}
+func (w *MarkdownWriter) WritePropertyDrawer(PropertyDrawer) {}
+func (w *MarkdownWriter) WriteLogbookDrawer(d LogbookDrawer) { writeLogbookClocks(w, d) }
+
+func (w *MarkdownWriter) WriteNodeWithMeta(n NodeWithMeta) {
+	if len(n.Meta.HTMLAttributes) != 0 {
+		w.writeHTML(NodeWithMeta{n.Node, Metadata{HTMLAttributes: n.Meta.HTMLAttributes}, n.Position})
+	} else {
+		WriteNodes(w, n.Node)
+	}
+	if len(n.Meta.Caption) != 0 {
+		captions := []string{}
+		for _, caption := range n.Meta.Caption {
+			captions = append(captions, strings.TrimSpace(w.WriteNodesAsString(caption...)))
+		}
+		w.WriteString("*" + strings.Join(captions, " ") + "*\n\n")
+	}
+}
+
+func (w *MarkdownWriter) WriteNodeWithName(n NodeWithName) {
+	w.WriteString(fmt.Sprintf(`<a id="%s"></a>`, n.Name) + "\n\n")
+	WriteNodes(w, n.Node)
+}
+
+func (w *MarkdownWriter) WriteHeadline(h Headline) {
+	if h.IsExcluded(w.document) {
+		return
+	}
+	title := ""
+	if w.document.GetOption("todo") != "nil" && h.Status != "" {
+		title += h.Status + " "
+	}
+	if w.document.GetOption("pri") != "nil" && h.Priority != "" {
+		title += `\[#` + h.Priority + `\] `
+	}
+	title += strings.TrimSpace(w.WriteNodesAsString(h.Title...))
+	if w.document.GetOption("tags") != "nil" && len(h.Tags) != 0 {
+		title += "   :" + markdownEscaper.Replace(strings.Join(h.Tags, ":")) + ":"
+	}
+	if w.document.GetOption("toc") != "nil" {
+		w.WriteString(fmt.Sprintf(`<a id="%s"></a>`, h.ID()) + "\n\n")
+	}
+	if lvl := h.Lvl + 1; lvl <= 6 {
+		w.WriteString(strings.Repeat("#", lvl) + " " + title + "\n\n")
+	} else {
+		w.WriteString("**" + title + "**\n\n")
+	}
+	if h.Planning != nil {
+		WriteNodes(w, *h.Planning)
+	}
+	WriteNodes(w, h.Children...)
+}
+
+func (w *MarkdownWriter) WritePlanning(p Planning) {
+	if w.document.GetOption("p") == "nil" || len(p.Entries) == 0 {
+		return
+	}
+	entries := []string{}
+	for _, e := range p.Entries {
+		entries = append(entries, "**"+e.Keyword+":** "+markdownCode(e.Timestamp.format("<", ">")))
+	}
+	w.WriteString(strings.Join(entries, " ") + "\n\n")
+}
+
+func (w *MarkdownWriter) WriteClock(c Clock) {
+	if w.document.GetOption("c") == "nil" {
+		return
+	}
+	w.WriteString("**CLOCK:** " + markdownCode(c.Timestamp.format("<", ">")))
+	if !c.IsRunning() {
+		w.WriteString(" => " + strings.TrimSpace(formatClockDuration(c.Duration)))
+	}
+	w.WriteString("\n\n")
+}
+
+func (w *MarkdownWriter) WriteBlock(b Block) {
+	content, params := w.blockContent(b.Name, b.Children), b.ParameterMap()
+	switch b.Name {
+	case "SRC":
+		if params[":exports"] == "results" || params[":exports"] == "none" {
+			break
+		}
+		lang := ""
+		if len(b.Parameters) >= 1 {
+			lang = strings.ToLower(b.Parameters[0])
+		}
+		w.WriteString(markdownCodeBlock(content, lang))
+	case "EXAMPLE":
+		w.WriteString(markdownCodeBlock(content, ""))
+	case "EXPORT":
+		if len(b.Parameters) >= 1 {
+			switch strings.ToLower(b.Parameters[0]) {
+			case "md", "markdown", "html":
+				w.WriteString(content + "\n\n")
+			}
+		}
+	case "QUOTE":
+		content := strings.TrimSpace(w.writeBlocks(b.Children))
+		w.WriteString(prefixLines(content, "> ") + "\n\n")
+	default:
+		w.writeHTML(Block{b.Name, b.Parameters, b.Children, nil, b.Position})
+	}
+	if b.Result != nil && params[":exports"] != "code" && params[":exports"] != "none" {
+		WriteNodes(w, b.Result)
+	}
+}
+
+func (w *MarkdownWriter) WriteDynamicBlock(b DynamicBlock) { WriteNodes(w, b.Children...) }
+
+func (w *MarkdownWriter) WriteResult(r Result) { WriteNodes(w, r.Node) }
+
+func (w *MarkdownWriter) WriteInlineBlock(b InlineBlock) {
+	content := w.blockContent(strings.ToUpper(b.Name), b.Children)
+	switch b.Name {
+	case "src":
+		w.WriteString(markdownCode(content))
+	case "export":
+		switch strings.ToLower(b.Parameters[0]) {
+		case "md", "markdown", "html":
+			w.WriteString(content)
+		}
+	}
+}
+
+func (w *MarkdownWriter) WriteExample(e Example) {
+	lines := []string{}
+	for _, n := range e.Children {
+		lines = append(lines, w.blockContent("EXAMPLE", []Node{n}))
+	}
+	w.WriteString(markdownCodeBlock(strings.Join(lines, "\n"), ""))
+}
+
+func (w *MarkdownWriter) WriteDrawer(d Drawer) { WriteNodes(w, d.Children...) }
+
+func (w *MarkdownWriter) WriteList(l List) {
+	items := []string{}
+	for i, item := range l.Items {
+		switch item := item.(type) {
+		case ListItem:
+			bullet := "-"
+			if l.Kind == "ordered" {
+				n := i + 1
+				if v, err := strconv.Atoi(item.Value); err == nil {
+					n = v
+				}
+				bullet = strconv.Itoa(n) + "."
+			}
+			items = append(items, w.listItem(bullet, item.Status, item.Children))
+		case DescriptiveListItem:
+			term := "?"
+			if len(item.Term) != 0 {
+				term = strings.TrimSpace(w.WriteNodesAsString(item.Term...))
+			}
+			details := strings.TrimSpace(w.writeBlocks(item.Details))
+			items = append(items, w.listItem("-", item.Status, []Node{Paragraph{Children: []Node{Text{Content: "**" + term + ":** " + details, IsRaw: true}}}}))
+		}
+	}
+	w.WriteString(strings.Join(items, "\n") + "\n\n")
+}
+
+func (w *MarkdownWriter) WriteListItem(li ListItem) {
+	w.WriteString(w.listItem("-", li.Status, li.Children) + "\n\n")
+}
+
+func (w *MarkdownWriter) WriteDescriptiveListItem(di DescriptiveListItem) {
+	WriteNodes(w, List{"descriptive", []Node{di}, di.Position})
+}
+
+func (w *MarkdownWriter) listItem(bullet, status string, children []Node) string {
+	prefix := bullet + " "
+	switch status {
+	case "X":
+		prefix += "[x] "
+	case " ", "-":
+		prefix += "[ ] "
+	}
+	content := strings.TrimSpace(w.writeBlocks(children))
+	return prefix + indentLines(content, strings.Repeat(" ", len(bullet)+1))
+}
+
+// writeBlocks writes children separated by blank lines - except for lists and code blocks directly following
+// a paragraph. This keeps list items (and lists) tight.
+func (w *MarkdownWriter) writeBlocks(children []Node) string {
+	out := ""
+	for i, child := range children {
+		content := strings.TrimSpace(w.WriteNodesAsString(child))
+		if content == "" {
+			continue
+		}
+		if out != "" {
+			out += "\n"
+			if _, isParagraph := children[i-1].(Paragraph); !isParagraph || !isTightMarkdownBlock(child) {
+				out += "\n"
+			}
+		}
+		out += content
+	}
+	return out
+}
+
+func isTightMarkdownBlock(n Node) bool {
+	switch n := n.(type) {
+	case List, Example:
+		return true
+	case Block:
+		return n.Name == "SRC" || n.Name == "EXAMPLE"
+	}
+	return false
+}
+
+func (w *MarkdownWriter) WriteTable(t Table) {
+	header, rows := []Row(nil), []Row{}
+	for i, row := range t.Rows {
+		if len(row.Columns) == 0 {
+			if header == nil && i != 0 && len(rows) != 0 {
+				header, rows = rows, []Row{}
+			}
+			continue
+		} else if !row.IsSpecial {
+			rows = append(rows, row)
+		}
+	}
+	if len(header) != 1 || len(t.ColumnInfos) == 0 {
+		w.writeHTML(t)
+		return
+	}
+	w.inTable = true
+	cells := [][]string{}
+	for _, row := range append(header, rows...) {
+		rowCells := make([]string, len(t.ColumnInfos))
+		for i, column := range row.Columns {
+			if i < len(rowCells) {
+				rowCells[i] = strings.TrimSpace(strings.ReplaceAll(w.WriteNodesAsString(column.Children...), "\n", " "))
+			}
+		}
+		cells = append(cells, rowCells)
+	}
+	w.inTable = false
+	widths := make([]int, len(t.ColumnInfos))
+	for i := range widths {
+		widths[i] = 3
+		for _, row := range cells {
+			if n := utf8.RuneCountInString(row[i]); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+	writeRow := func(row []string) {
+		for i, cell := range row {
+			w.WriteString("| " + cell + strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)) + " ")
+		}
+		w.WriteString("|\n")
+	}
+	writeRow(cells[0])
+	for i, info := range t.ColumnInfos {
+		delimiter := strings.Repeat("-", widths[i])
+		switch info.Align {
+		case "left":
+			delimiter = ":" + delimiter[1:]
+		case "right":
+			delimiter = delimiter[1:] + ":"
+		case "center":
+			delimiter = ":" + delimiter[2:] + ":"
+		}
+		w.WriteString("| " + delimiter + " ")
+	}
+	w.WriteString("|\n")
+	for _, row := range cells[1:] {
+		writeRow(row)
+	}
+	w.WriteString("\n")
+}
+
+func (w *MarkdownWriter) WriteHorizontalRule(HorizontalRule) { w.WriteString("\n-----\n\n") }
+
+func (w *MarkdownWriter) WriteParagraph(p Paragraph) {
+	if len(p.Children) == 0 {
+		return
+	}
+	content := w.WriteNodesAsString(p.Children...)
+	content = markdownLineStartRegexp.ReplaceAllString(content, `$1\$2`)
+	content = markdownOrderedLineStartRegexp.ReplaceAllString(content, `$1\$2`)
+	w.WriteString(content + "\n\n")
+}
+
+func (w *MarkdownWriter) WriteText(t Text) {
+	content := t.Content
+	if w.escape && !t.IsRaw && w.document.GetOption("e") != "nil" {
+		content = htmlEntityReplacer.Replace(content)
+	}
+	if w.escape && !t.IsRaw {
+		content = markdownEscaper.Replace(content)
+		if w.inTable {
+			content = strings.ReplaceAll(content, "|", `\|`)
+		}
+	}
+	w.WriteString(content)
+}
+
+func (w *MarkdownWriter) WriteEmphasis(e Emphasis) {
+	switch e.Kind {
+	case "~", "=":
+		w.WriteString(markdownCode(w.blockContent("SRC", e.Content)))
+		return
+	}
+	borders, ok := emphasisMarkdownBorders[e.Kind]
+	if !ok {
+		panic(fmt.Sprintf("bad emphasis %#v", e))
+	}
+	w.WriteString(borders[0])
+	WriteNodes(w, e.Content...)
+	w.WriteString(borders[1])
+}
+
+func (w *MarkdownWriter) WriteLatexFragment(l LatexFragment) {
+	w.WriteString(l.OpeningPair + w.blockContent("SRC", l.Content) + l.ClosingPair)
+}
+
+func (w *MarkdownWriter) WriteStatisticToken(s StatisticToken) {
+	w.WriteString(markdownCode("[" + s.Content + "]"))
+}
+
+func (w *MarkdownWriter) WriteExplicitLineBreak(ExplicitLineBreak) { w.WriteString("\\\n") }
+
+func (w *MarkdownWriter) WriteLineBreak(l LineBreak) {
+	if w.document.GetOption("ealb") == "nil" || !l.BetweenMultibyteCharacters {
+		w.WriteString(strings.Repeat("\n", l.Count))
+	}
+}
+
+func (w *MarkdownWriter) WriteRegularLink(l RegularLink) {
+	url := w.linkURL(l)
+	switch l.Kind() {
+	case "image":
+		if l.Description == nil {
+			w.WriteString(fmt.Sprintf("![%s](%s)", markdownEscaper.Replace(url), markdownLinkDestination(url)))
+		} else {
+			description := strings.TrimPrefix(String(l.Description), "file:")
+			w.WriteString(fmt.Sprintf("[![%s](%s)](%s)", markdownEscaper.Replace(description), markdownLinkDestination(description), markdownLinkDestination(url)))
+		}
+	case "video":
+		w.writeHTML(l)
+	default:
+		if l.Description == nil && (l.AutoLink || l.Protocol == "http" || l.Protocol == "https" || l.Protocol == "mailto") {
+			w.WriteString("<" + url + ">")
+			return
+		}
+		description := markdownEscaper.Replace(url)
+		if l.Description != nil {
+			description = w.WriteNodesAsString(l.Description...)
+		}
+		w.WriteString(fmt.Sprintf("[%s](%s)", description, markdownLinkDestination(url)))
+	}
+}
+
+func (w *MarkdownWriter) WriteTarget(Target) {}
+
+func (w *MarkdownWriter) WriteRadioTarget(t RadioTarget) {
+	w.WriteText(Text{t.Name, false, t.Position})
+}
+
+func (w *MarkdownWriter) WriteMacro(m Macro) {
+	if macro := w.document.Macros[m.Name]; macro != "" {
+		for i, param := range m.Parameters {
+			macro = strings.Replace(macro, fmt.Sprintf("$%d", i+1), param, -1)
+		}
+		macroDocument := w.document.parseFragment(macro)
+		if macroDocument.Error != nil {
+			w.document.addDiagnostic(CodeBadMacro, m.Position, "bad macro %s (%s): %s", m.Name, macro, macroDocument.Error)
+		}
+		w.WriteString(strings.TrimSpace(w.writeBlocks(macroDocument.Nodes)))
+	}
+}
+
+func (w *MarkdownWriter) WriteTimestamp(t Timestamp) {
+	if w.document.GetOption("<") == "nil" {
+		return
+	}
+	w.WriteString(markdownCode(t.format("<", ">")))
+}
+
+func (w *MarkdownWriter) WriteFootnoteLink(l FootnoteLink) {
+	if w.document.GetOption("f") == "nil" {
+		return
+	}
+	w.WriteString(fmt.Sprintf("[^%d]", w.footnotes.add(l)+1))
+}
+
+func (w *MarkdownWriter) WriteFootnoteDefinition(f FootnoteDefinition) {
+	w.footnotes.updateDefinition(f)
+}
+
+// writeHTML writes nodes as inline html - as a fallback for constructs that cannot be expressed in markdown.
+func (w *MarkdownWriter) writeHTML(nodes ...Node) {
+	out := w.htmlWriter.WriteNodesAsString(nodes...)
+	if isBlock := strings.HasSuffix(out, "\n"); isBlock {
+		out = strings.TrimSpace(multipleBlankLinesRegexp.ReplaceAllString(out, "\n")) + "\n\n"
+	}
+	w.WriteString(out)
+}
+
+func (w *MarkdownWriter) blockContent(name string, children []Node) string {
+	builder, escape := w.Builder, w.escape
+	w.Builder, w.escape = strings.Builder{}, false
+	WriteNodes(w, children...)
+	out := w.String()
+	w.Builder, w.escape = builder, escape
+	if isRawTextBlock(name) {
+		return strings.TrimRight(out, " \t\n")
+	}
+	return out
+}
+
+func (w *MarkdownWriter) linkURL(l RegularLink) string {
+	url := l.URL
+	if l.Protocol == "file" {
+		url = url[len("file:"):]
+	}
+	if isRelative := l.Protocol == "file" || l.Protocol == ""; isRelative && strings.HasSuffix(url, ".org") {
+		url = strings.TrimSuffix(url, ".org") + ".md"
+	}
+	if prefix := w.document.Links[l.Protocol]; prefix != "" {
+		if tag := strings.TrimPrefix(l.URL, l.Protocol+":"); strings.Contains(prefix, "%s") || strings.Contains(prefix, "%h") {
+			url = strings.ReplaceAll(strings.ReplaceAll(prefix, "%s", tag), "%h", u.QueryEscape(tag))
+		} else {
+			url = prefix + tag
+		}
+	} else if prefix := w.document.Links[l.URL]; prefix != "" {
+		url = strings.ReplaceAll(strings.ReplaceAll(prefix, "%s", ""), "%h", "")
+	}
+	return url
+}
+
+// markdownCode returns s as a code span - using a backtick fence that is longer than any backtick run in s.
+func markdownCode(s string) string {
+	fence := "`"
+	for _, run := range markdownBacktickRunRegexp.FindAllString(s, -1) {
+		if len(run) >= len(fence) {
+			fence = run + "`"
+		}
+	}
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") {
+		s = " " + s + " "
+	}
+	return fence + s + fence
+}
+
+// markdownCodeBlock returns s as a fenced code block - using a fence that is longer than any backtick run in s.
+func markdownCodeBlock(s, lang string) string {
+	fence := "```"
+	for _, run := range markdownBacktickRunRegexp.FindAllString(s, -1) {
+		if len(run) >= len(fence) {
+			fence = run + "`"
+		}
+	}
+	return fence + lang + "\n" + s + "\n" + fence + "\n\n"
+}
+
+func markdownLinkDestination(url string) string {
+	if strings.ContainsAny(url, " ()<>") {
+		return "<" + strings.NewReplacer("<", `\<`, ">", `\>`).Replace(url) + ">"
+	}
+	return url
+}
+
+// indentLines indents all but the first line of s with indent. Blank lines are not indented.
+func indentLines(s, indent string) string {
+	lines := strings.Split(s, "\n")
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) != "" {
+			lines[i] = indent + lines[i]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// prefixLines prefixes all lines of s with prefix.
+func prefixLines(s, prefix string) string {
+	lines := strings.Split(s, "\n")
+	for i := range lines {
+		lines[i] = strings.TrimRight(prefix+lines[i], " ")
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,409 @@
+package org
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Match is a compiled Org mode tags / property match query (see "Matching tags and properties" in the Org manual), e.g.
+//
+//	+work-boring|urgent+PRIORITY="A"
+//	TODO="NEXT"
+//	LEVEL>2
+//	DEADLINE<"<today>"
+//	work/!NEXT|WAITING
+type Match struct {
+	query   string
+	terms   [][]matcher // terms are alternatives (|) of conjunctions of matchers
+	todo    [][]matcher
+	notDone bool
+}
+
+type matcher func(*Document, *Section) bool
+
+var matchElementRegexp = regexp.MustCompile(`^([-+&]?)(?:(\{[^}]*\})|([A-Za-z_][-\w]*)(<=|>=|<>|==|=|<|>)(\{[^}]*\}|"[^"]*"|-?[.0-9]+(?:[eE][-+]?[0-9]+)?)|([\w@#%]+))`)
+var matchTodoRegexp = regexp.MustCompile(`^([-+]?)([^-+|\s]+)`)
+var matchRelativeTimeRegexp = regexp.MustCompile(`^<([-+]\d+)([hdwmy])>$`)
+
+// ParseMatch compiles an Org mode match query.
+func ParseMatch(query string) (*Match, error) {
+	m := &Match{query: query}
+	tagsAndProperties, todo := splitMatchQuery(query)
+	for _, term := range splitMatchTerms(tagsAndProperties) {
+		matchers, err := parseMatchTerm(term)
+		if err != nil {
+			return nil, fmt.Errorf("bad match query %q: %s", query, err)
+		}
+		m.terms = append(m.terms, matchers)
+	}
+	if strings.HasPrefix(todo, "!") {
+		m.notDone, todo = true, todo[1:]
+	}
+	for _, term := range splitMatchTerms(todo) {
+		matchers := []matcher{}
+		for term != "" {
+			sm := matchTodoRegexp.FindStringSubmatch(term)
+			if sm == nil {
+				return nil, fmt.Errorf("bad match query %q: could not parse TODO match %q", query, term)
+			}
+			keyword := sm[2]
+			matchers = append(matchers, negateMatcher(sm[1] == "-", func(d *Document, s *Section) bool {
+				return s.Headline.Status == keyword
+			}))
+			term = term[len(sm[0]):]
+		}
+		m.todo = append(m.todo, matchers)
+	}
+	return m, nil
+}
+
+// Matches returns true if the headline of s matches the query. Tags are inherited (see Section.InheritedTags) - and so are
+// the properties listed in Configuration.InheritedProperties (see Section.InheritedProperty).
+func (m *Match) Matches(d *Document, s *Section) bool {
+	if s.Headline == nil {
+		return false
+	}
+	if m.notDone && (s.Headline.Status == "" || d.IsDone(*s.Headline)) {
+		return false
+	}
+	return matchAny(m.terms, d, s) && matchAny(m.todo, d, s)
+}
+
+func (m *Match) String() string { return m.query }
+
+// Match returns the sections of all headlines that match the Org mode match query in document order.
+func (d *Document) Match(query string) ([]*Section, error) {
+	m, err := ParseMatch(query)
+	if err != nil {
+		return nil, err
+	}
+	sections := []*Section{}
+	var walk func(*Section)
+	walk = func(s *Section) {
+		if m.Matches(d, s) {
+			sections = append(sections, s)
+		}
+		for _, child := range s.Children {
+			walk(child)
+		}
+	}
+	walk(d.Outline.Section)
+	return sections, nil
+}
+
+// SparseTree drops all headlines from the document that neither match the Org mode match query nor contain a matching headline -
+// i.e. only matching headlines and their ancestors (with their content) are kept for writing.
+func (d *Document) SparseTree(query string) error {
+	m, err := ParseMatch(query)
+	if err != nil {
+		return err
+	}
+	keep := map[*Section]bool{}
+	var mark func(*Section) bool
+	mark = func(s *Section) bool {
+		keep[s] = m.Matches(d, s)
+		for _, child := range s.Children {
+			if mark(child) {
+				keep[s] = true
+			}
+		}
+		return keep[s]
+	}
+	mark(d.Outline.Section)
+	d.Nodes = d.sparseTree(d.Nodes, d.Outline.Section, keep)
+	return nil
+}
+
+func (d *Document) sparseTree(nodes []Node, section *Section, keep map[*Section]bool) []Node {
+	out := make([]Node, 0, len(nodes))
+	for _, n := range nodes {
+		h, ok := n.(Headline)
+		if !ok {
+			out = append(out, n)
+			continue
+		}
+		s := section.findByIndex(h.Index)
+		if s == nil {
+			out = append(out, n)
+			continue
+		}
+		if !keep[s] {
+			s.Parent.remove(s)
+			continue
+		}
+		h.Children = d.sparseTree(h.Children, s, keep)
+		s.Headline.Children = h.Children
+		out = append(out, h)
+	}
+	return out
+}
+
+func (parent *Section) remove(child *Section) {
+	for i, s := range parent.Children {
+		if s == child {
+			parent.Children = append(parent.Children[:i:i], parent.Children[i+1:]...)
+			return
+		}
+	}
+}
+
+func matchAny(terms [][]matcher, d *Document, s *Section) bool {
+	if len(terms) == 0 {
+		return true
+	}
+	for _, matchers := range terms {
+		matched := true
+		for _, match := range matchers {
+			if !match(d, s) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// splitMatchQuery splits query into the tags / property part and the TODO part (after the first / outside of quotes and braces).
+func splitMatchQuery(query string) (string, string) {
+	inQuote, inBraces := false, false
+	for i, r := range query {
+		switch {
+		case r == '"' && !inBraces:
+			inQuote = !inQuote
+		case r == '{' && !inQuote:
+			inBraces = true
+		case r == '}' && !inQuote:
+			inBraces = false
+		case r == '/' && !inQuote && !inBraces:
+			return strings.TrimSpace(query[:i]), strings.TrimSpace(query[i+1:])
+		}
+	}
+	return strings.TrimSpace(query), ""
+}
+
+// splitMatchTerms splits s at | outside of quotes and braces.
+func splitMatchTerms(s string) []string {
+	if s == "" {
+		return nil
+	}
+	terms, start, inQuote, inBraces := []string{}, 0, false, false
+	for i, r := range s {
+		switch {
+		case r == '"' && !inBraces:
+			inQuote = !inQuote
+		case r == '{' && !inQuote:
+			inBraces = true
+		case r == '}' && !inQuote:
+			inBraces = false
+		case r == '|' && !inQuote && !inBraces:
+			terms, start = append(terms, s[start:i]), i+1
+		}
+	}
+	return append(terms, s[start:])
+}
+
+func parseMatchTerm(term string) ([]matcher, error) {
+	matchers := []matcher{}
+	for term = strings.TrimSpace(term); term != ""; term = strings.TrimSpace(term) {
+		m := matchElementRegexp.FindStringSubmatch(term)
+		if m == nil {
+			return nil, fmt.Errorf("could not parse %q", term)
+		}
+		term = term[len(m[0]):]
+		negate := m[1] == "-"
+		switch {
+		case m[2] != "":
+			re, err := regexp.Compile(m[2][1 : len(m[2])-1])
+			if err != nil {
+				return nil, err
+			}
+			matchers = append(matchers, negateMatcher(negate, func(d *Document, s *Section) bool {
+				for _, tag := range s.InheritedTags(d) {
+					if re.MatchString(tag) {
+						return true
+					}
+				}
+				return false
+			}))
+		case m[3] != "":
+			match, err := propertyMatcher(strings.ToUpper(m[3]), m[4], m[5])
+			if err != nil {
+				return nil, err
+			}
+			matchers = append(matchers, negateMatcher(negate, match))
+		default:
+			tag := m[6]
+			matchers = append(matchers, negateMatcher(negate, func(d *Document, s *Section) bool {
+				for _, t := range s.InheritedTags(d) {
+					if t == tag {
+						return true
+					}
+				}
+				return false
+			}))
+		}
+	}
+	return matchers, nil
+}
+
+func propertyMatcher(key, op, value string) (matcher, error) {
+	if strings.HasPrefix(value, "{") {
+		re, err := regexp.Compile(value[1 : len(value)-1])
+		if err != nil {
+			return nil, err
+		}
+		if op != "=" && op != "==" && op != "<>" {
+			return nil, fmt.Errorf("bad operator %s for regexp %s", op, value)
+		}
+		return func(d *Document, s *Section) bool {
+			v, _ := matchProperty(d, s, key)
+			return re.MatchString(v) == (op != "<>")
+		}, nil
+	} else if strings.HasPrefix(value, `"<`) && strings.HasSuffix(value, `>"`) {
+		t, ok := parseMatchTime(value[1 : len(value)-1])
+		if !ok {
+			return nil, fmt.Errorf("bad time %s", value)
+		}
+		return func(d *Document, s *Section) bool {
+			v, ok := matchProperty(d, s, key)
+			if !ok {
+				return false
+			}
+			pt, ok := parseMatchTime(v)
+			switch {
+			case !ok:
+				return false
+			case pt.Before(t):
+				return compare(op, -1)
+			case pt.After(t):
+				return compare(op, 1)
+			}
+			return compare(op, 0)
+		}, nil
+	} else if strings.HasPrefix(value, `"`) {
+		value = value[1 : len(value)-1]
+		return func(d *Document, s *Section) bool {
+			v, _ := matchProperty(d, s, key)
+			return compare(op, strings.Compare(v, value))
+		}, nil
+	}
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, err
+	}
+	return func(d *Document, s *Section) bool {
+		v, _ := matchProperty(d, s, key)
+		pn, _ := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		switch {
+		case pn < n:
+			return compare(op, -1)
+		case pn > n:
+			return compare(op, 1)
+		}
+		return compare(op, 0)
+	}, nil
+}
+
+func compare(op string, result int) bool {
+	switch op {
+	case "=", "==":
+		return result == 0
+	case "<>":
+		return result != 0
+	case "<":
+		return result < 0
+	case "<=":
+		return result <= 0
+	case ">":
+		return result > 0
+	case ">=":
+		return result >= 0
+	}
+	return false
+}
+
+func negateMatcher(negate bool, m matcher) matcher {
+	if !negate {
+		return m
+	}
+	return func(d *Document, s *Section) bool { return !m(d, s) }
+}
+
+// matchProperty returns the value of the (special) property key for the headline of s.
+func matchProperty(d *Document, s *Section, key string) (string, bool) {
+	h := s.Headline
+	switch key {
+	case "TODO":
+		return h.Status, h.Status != ""
+	case "LEVEL":
+		return strconv.Itoa(h.Lvl), true
+	case "PRIORITY":
+		return h.EffectivePriority(d), true
+	case "ITEM":
+		return strings.TrimSpace(String(h.Title)), true
+	case "TAGS":
+		if len(h.Tags) == 0 {
+			return "", false
+		}
+		return ":" + strings.Join(h.Tags, ":") + ":", true
+	case "ALLTAGS":
+		if tags := s.InheritedTags(d); len(tags) != 0 {
+			return ":" + strings.Join(tags, ":") + ":", true
+		}
+		return "", false
+	case "CATEGORY":
+		if category := d.Get("CATEGORY"); category != "" {
+			return category, true
+		}
+		return strings.TrimSuffix(filepath.Base(d.Path), filepath.Ext(d.Path)), true
+	case "SCHEDULED", "DEADLINE", "CLOSED":
+		if t, ok := h.Planning.Get(key); ok {
+			return t.format("<", ">"), true
+		}
+		return "", false
+	}
+	return s.InheritedProperty(d, key)
+}
+
+// parseMatchTime parses org timestamps as well as the special values <now>, <today>, <yesterday>, <tomorrow> and <+Nu> / <-Nu>
+// (relative to today, with unit u one of h, d, w, m, y).
+func parseMatchTime(s string) (time.Time, bool) {
+	current := now()
+	today := time.Date(current.Year(), current.Month(), current.Day(), 0, 0, 0, 0, time.UTC)
+	switch s {
+	case "<now>":
+		return time.Date(current.Year(), current.Month(), current.Day(), current.Hour(), current.Minute(), 0, 0, time.UTC), true
+	case "<today>":
+		return today, true
+	case "<yesterday>":
+		return today.AddDate(0, 0, -1), true
+	case "<tomorrow>":
+		return today.AddDate(0, 0, 1), true
+	}
+	if m := matchRelativeTimeRegexp.FindStringSubmatch(s); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		switch m[2] {
+		case "h":
+			return time.Date(current.Year(), current.Month(), current.Day(), current.Hour()+n, current.Minute(), 0, 0, time.UTC), true
+		case "d":
+			return today.AddDate(0, 0, n), true
+		case "w":
+			return today.AddDate(0, 0, 7*n), true
+		case "m":
+			return today.AddDate(0, n, 0), true
+		case "y":
+			return today.AddDate(n, 0, 0), true
+		}
+	}
+	if consumed, t, ok := parseSingleTimestamp(s); ok && consumed > 0 {
+		return t.Time, true
+	}
+	return time.Time{}, false
+}
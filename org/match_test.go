@@ -0,0 +1,95 @@
+package org
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+var matchTestInput = `#+TODO: TODO NEXT | DONE
+* Work                                                                 :work:
+:PROPERTIES:
+:EFFORT: 2
+:END:
+** NEXT [#A] Report                                                  :urgent:
+DEADLINE: <2021-03-01 Mon>
+** TODO Filing                                                       :boring:
+:PROPERTIES:
+:EFFORT: 0.5
+:END:
+*** DONE Old filing
+* Home
+** TODO [#A] Taxes                                               :urgent:boring:
+DEADLINE: <2021-04-15 Thu>
+** Garden                                                            :garden:
+`
+
+func TestMatch(t *testing.T) {
+	defer func(f func() time.Time) { now = f }(now)
+	now = func() time.Time { return time.Date(2021, 3, 2, 12, 0, 0, 0, time.UTC) }
+	c := New().Silent()
+	c.InheritedProperties = []string{"EFFORT"}
+	d := c.Parse(strings.NewReader(matchTestInput), "./match.org")
+	tests := map[string]string{
+		"work":                             "Work, Report, Filing, Old filing",
+		"+work-boring":                     "Work, Report",
+		`+work-boring|urgent+PRIORITY="A"`: "Work, Report, Taxes",
+		`TODO="NEXT"`:                      "Report",
+		"LEVEL>2":                          "Old filing",
+		`DEADLINE<"<today>"`:               "Report",
+		`DEADLINE>="<+1w>"`:                "Taxes",
+		"EFFORT<1":                         "Filing, Old filing, Home, Taxes, Garden",
+		"EFFORT>1&LEVEL=2":                 "Report",
+		"{^gar}":                           "Garden",
+		`ITEM={^[FG]}`:                     "Filing, Garden",
+		"work/!":                           "Report, Filing",
+		"/NEXT|DONE":                       "Report, Old filing",
+		"-work/-TODO":                      "Home, Garden",
+	}
+	for query, expected := range tests {
+		sections, err := d.Match(query)
+		if err != nil {
+			t.Errorf("%s: %s", query, err)
+			continue
+		}
+		titles := []string{}
+		for _, s := range sections {
+			titles = append(titles, String(s.Headline.Title))
+		}
+		if actual := strings.Join(titles, ", "); actual != expected {
+			t.Errorf("%s: got %q, expected %q", query, actual, expected)
+		}
+	}
+	for _, query := range []string{"LEVEL>", `ITEM<{foo}`, "{[}"} {
+		if _, err := ParseMatch(query); err == nil {
+			t.Errorf("%s: expected error", query)
+		}
+	}
+}
+
+func TestSparseTree(t *testing.T) {
+	d := New().Silent().Parse(strings.NewReader(matchTestInput), "./match.org")
+	if err := d.SparseTree("urgent-boring|garden"); err != nil {
+		t.Fatal(err)
+	}
+	actual, err := d.Write(NewOrgWriter())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `#+TODO: TODO NEXT | DONE
+* Work                                                                 :work:
+:PROPERTIES:
+:EFFORT: 2
+:END:
+** NEXT [#A] Report                                                  :urgent:
+DEADLINE: <2021-03-01 Mon>
+* Home
+** Garden                                                            :garden:
+`
+	if actual != expected {
+		t.Errorf("got:\n%s\nexpected:\n%s", actual, expected)
+	}
+	if len(d.Outline.Children) != 2 || len(d.Outline.Children[0].Children) != 1 || len(d.Outline.Children[1].Children) != 1 {
+		t.Errorf("expected outline to only contain matching sections and their ancestors")
+	}
+}
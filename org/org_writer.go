@@ -15,6 +15,10 @@ type OrgWriter struct {
 
 	strings.Builder
 	indent string
+	source *source // source is the parse input of lossless documents (see Configuration.Lossless).
+	line   int     // line is the index of the first source line that has not been written yet.
+
+	skipSource bool // skipSource makes the next call of writeSource return false to write the node as OrgWriter does.
 }
 
 var exampleBlockUnescapeRegexp = regexp.MustCompile(`(^|\n)([ \t]*)(\*|,\*|#\+|,#\+)`)
@@ -43,8 +47,8 @@ func (w *OrgWriter) WriterWithExtensions() Writer {
 	return w
 }
 
-func (w *OrgWriter) Before(d *Document) {}
-func (w *OrgWriter) After(d *Document)  {}
+func (w *OrgWriter) Before(d *Document) { w.source, w.line = d.source, 0 }
+func (w *OrgWriter) After(d *Document)  { w.writeSourceTrailer() }
 
 func (w *OrgWriter) WriteNodesAsString(nodes ...Node) string {
 	builder := w.Builder
@@ -56,6 +60,9 @@ func (w *OrgWriter) WriteNodesAsString(nodes ...Node) string {
 }
 
 func (w *OrgWriter) WriteHeadline(h Headline) {
+	if w.writeSource(h) {
+		return
+	}
 	start := w.Len()
 	w.WriteString(strings.Repeat("*", h.Lvl))
 	if h.Status != "" {
@@ -78,13 +85,33 @@ func (w *OrgWriter) WriteHeadline(h Headline) {
 	if len(h.Children) != 0 {
 		w.WriteString(w.indent)
 	}
+	if h.Planning != nil {
+		WriteNodes(w, *h.Planning)
+	}
 	if h.Properties != nil {
 		WriteNodes(w, *h.Properties)
 	}
 	WriteNodes(w, h.Children...)
 }
 
+func (w *OrgWriter) WritePlanning(p Planning) {
+	if w.writeSource(p) {
+		return
+	}
+	for i, e := range p.Entries {
+		if i != 0 {
+			w.WriteString(" ")
+		}
+		w.WriteString(e.Keyword + ": ")
+		WriteNodes(w, e.Timestamp)
+	}
+	w.WriteString("\n")
+}
+
 func (w *OrgWriter) WriteBlock(b Block) {
+	if w.writeSource(b) {
+		return
+	}
 	w.WriteString(w.indent + "#+BEGIN_" + b.Name)
 	if len(b.Parameters) != 0 {
 		w.WriteString(" " + strings.Join(b.Parameters, " "))
@@ -109,7 +136,23 @@ func (w *OrgWriter) WriteBlock(b Block) {
 	}
 }
 
+func (w *OrgWriter) WriteDynamicBlock(b DynamicBlock) {
+	if w.writeSource(b) {
+		return
+	}
+	w.WriteString(w.indent + "#+BEGIN: " + b.Name)
+	if len(b.Parameters) != 0 {
+		w.WriteString(" " + strings.Join(b.Parameters, " "))
+	}
+	w.WriteString("\n")
+	WriteNodes(w, b.Children...)
+	w.WriteString(w.indent + "#+END:\n")
+}
+
 func (w *OrgWriter) WriteResult(r Result) {
+	if w.writeSource(r) {
+		return
+	}
 	w.WriteString("#+RESULTS:\n")
 	WriteNodes(w, r.Node)
 }
@@ -132,24 +175,54 @@ func (w *OrgWriter) WriteInlineBlock(b InlineBlock) {
 }
 
 func (w *OrgWriter) WriteDrawer(d Drawer) {
+	if w.writeSource(d) {
+		return
+	}
 	w.WriteString(w.indent + ":" + d.Name + ":\n")
 	WriteNodes(w, d.Children...)
 	w.WriteString(w.indent + ":END:\n")
 }
 
 func (w *OrgWriter) WritePropertyDrawer(d PropertyDrawer) {
-	w.WriteString(":PROPERTIES:\n")
+	if w.writeSource(d) {
+		return
+	}
+	w.WriteString(w.indent + ":PROPERTIES:\n")
 	for _, kvPair := range d.Properties {
 		k, v := kvPair[0], kvPair[1]
 		if v != "" {
 			v = " " + v
 		}
-		w.WriteString(fmt.Sprintf(":%s:%s\n", k, v))
+		w.WriteString(fmt.Sprintf("%s:%s:%s\n", w.indent, k, v))
+	}
+	w.WriteString(w.indent + ":END:\n")
+}
+
+func (w *OrgWriter) WriteLogbookDrawer(d LogbookDrawer) {
+	if w.writeSource(d) {
+		return
+	}
+	w.WriteString(w.indent + ":LOGBOOK:\n")
+	WriteNodes(w, d.Children...)
+	w.WriteString(w.indent + ":END:\n")
+}
+
+func (w *OrgWriter) WriteClock(c Clock) {
+	if w.writeSource(c) {
+		return
+	}
+	w.WriteString(w.indent + "CLOCK: ")
+	WriteNodes(w, c.Timestamp)
+	if !c.IsRunning() {
+		w.WriteString(" => " + formatClockDuration(c.Duration))
 	}
-	w.WriteString(":END:\n")
+	w.WriteString("\n")
 }
 
 func (w *OrgWriter) WriteFootnoteDefinition(f FootnoteDefinition) {
+	if w.writeSource(f) {
+		return
+	}
 	w.WriteString(fmt.Sprintf("[fn:%s]", f.Name))
 	content := w.WriteNodesAsString(f.Children...)
 	if content != "" && !unicode.IsSpace(rune(content[0])) {
@@ -159,6 +232,9 @@ func (w *OrgWriter) WriteFootnoteDefinition(f FootnoteDefinition) {
 }
 
 func (w *OrgWriter) WriteParagraph(p Paragraph) {
+	if w.writeSource(p) {
+		return
+	}
 	content := w.WriteNodesAsString(p.Children...)
 	if len(content) > 0 && content[0] != '\n' {
 		w.WriteString(w.indent)
@@ -167,6 +243,9 @@ func (w *OrgWriter) WriteParagraph(p Paragraph) {
 }
 
 func (w *OrgWriter) WriteExample(e Example) {
+	if w.writeSource(e) {
+		return
+	}
 	for _, n := range e.Children {
 		w.WriteString(w.indent + ":")
 		if content := w.WriteNodesAsString(n); content != "" {
@@ -177,6 +256,9 @@ func (w *OrgWriter) WriteExample(e Example) {
 }
 
 func (w *OrgWriter) WriteKeyword(k Keyword) {
+	if w.writeSource(k) {
+		return
+	}
 	w.WriteString(w.indent + "#+" + k.Key + ":")
 	if k.Value != "" {
 		w.WriteString(" " + k.Value)
@@ -185,10 +267,16 @@ func (w *OrgWriter) WriteKeyword(k Keyword) {
 }
 
 func (w *OrgWriter) WriteInclude(i Include) {
+	if w.writeSource(i) {
+		return
+	}
 	w.WriteKeyword(i.Keyword)
 }
 
 func (w *OrgWriter) WriteNodeWithMeta(n NodeWithMeta) {
+	if w.writeSource(n) {
+		return
+	}
 	for _, ns := range n.Meta.Caption {
 		w.WriteString("#+CAPTION: ")
 		WriteNodes(w, ns...)
@@ -198,21 +286,39 @@ func (w *OrgWriter) WriteNodeWithMeta(n NodeWithMeta) {
 		w.WriteString("#+ATTR_HTML: ")
 		w.WriteString(strings.Join(attributes, " ") + "\n")
 	}
+	for _, attributes := range n.Meta.LatexAttributes {
+		w.WriteString("#+ATTR_LATEX: ")
+		w.WriteString(strings.Join(attributes, " ") + "\n")
+	}
 	WriteNodes(w, n.Node)
 }
 
 func (w *OrgWriter) WriteNodeWithName(n NodeWithName) {
+	if w.writeSource(n) {
+		return
+	}
 	w.WriteString(fmt.Sprintf("#+NAME: %s\n", n.Name))
 	WriteNodes(w, n.Node)
 }
 
 func (w *OrgWriter) WriteComment(c Comment) {
+	if w.writeSource(c) {
+		return
+	}
 	w.WriteString(w.indent + "# " + c.Content + "\n")
 }
 
-func (w *OrgWriter) WriteList(l List) { WriteNodes(w, l.Items...) }
+func (w *OrgWriter) WriteList(l List) {
+	if w.writeSource(l) {
+		return
+	}
+	WriteNodes(w, l.Items...)
+}
 
 func (w *OrgWriter) WriteListItem(li ListItem) {
+	if w.writeSource(li) {
+		return
+	}
 	originalBuilder, originalIndent := w.Builder, w.indent
 	w.Builder, w.indent = strings.Builder{}, w.indent+strings.Repeat(" ", len(li.Bullet)+1)
 	WriteNodes(w, li.Children...)
@@ -233,6 +339,9 @@ func (w *OrgWriter) WriteListItem(li ListItem) {
 }
 
 func (w *OrgWriter) WriteDescriptiveListItem(di DescriptiveListItem) {
+	if w.writeSource(di) {
+		return
+	}
 	indent := w.indent + strings.Repeat(" ", len(di.Bullet)+1)
 	w.WriteString(w.indent + di.Bullet)
 	if di.Status != "" {
@@ -257,6 +366,9 @@ func (w *OrgWriter) WriteDescriptiveListItem(di DescriptiveListItem) {
 }
 
 func (w *OrgWriter) WriteTable(t Table) {
+	if w.writeSource(t) {
+		return
+	}
 	for _, row := range t.Rows {
 		w.WriteString(w.indent)
 		if len(row.Columns) == 0 {
@@ -299,6 +411,9 @@ func (w *OrgWriter) WriteTable(t Table) {
 }
 
 func (w *OrgWriter) WriteHorizontalRule(hr HorizontalRule) {
+	if w.writeSource(hr) {
+		return
+	}
 	w.WriteString(w.indent + "-----\n")
 }
 
@@ -333,16 +448,7 @@ func (w *OrgWriter) WriteExplicitLineBreak(l ExplicitLineBreak) {
 }
 
 func (w *OrgWriter) WriteTimestamp(t Timestamp) {
-	w.WriteString("<")
-	if t.IsDate {
-		w.WriteString(t.Time.Format(datestampFormat))
-	} else {
-		w.WriteString(t.Time.Format(timestampFormat))
-	}
-	if t.Interval != "" {
-		w.WriteString(" " + t.Interval)
-	}
-	w.WriteString(">")
+	w.WriteString(t.format("<", ">"))
 }
 
 func (w *OrgWriter) WriteFootnoteLink(l FootnoteLink) {
@@ -364,6 +470,10 @@ func (w *OrgWriter) WriteRegularLink(l RegularLink) {
 	}
 }
 
+func (w *OrgWriter) WriteTarget(t Target) { w.WriteString("<<" + t.Name + ">>") }
+
+func (w *OrgWriter) WriteRadioTarget(t RadioTarget) { w.WriteString("<<<" + t.Name + ">>>") }
+
 func (w *OrgWriter) WriteMacro(m Macro) {
 	w.WriteString(fmt.Sprintf("{{{%s(%s)}}}", m.Name, strings.Join(m.Parameters, ",")))
 }
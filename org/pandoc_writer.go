@@ -0,0 +1,665 @@
+package org
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	u "net/url"
+)
+
+// PandocWriter exports an org document as a pandoc JSON AST (see https://pandoc.org/using-the-pandoc-api.html).
+// The output can be converted into any format supported by pandoc, e.g. using
+//
+//	go-org render FILE.org pandoc | pandoc --from json --to docx -o FILE.docx
+type PandocWriter struct {
+	ExtendingWriter Writer
+	Indent          string // Indent is used to pretty print the output. No indentation is applied if Indent is empty.
+
+	strings.Builder
+	document            *Document
+	elements            []interface{}
+	footnoteDefinitions map[string]*FootnoteDefinition
+}
+
+// pandocElement is the json representation of a pandoc Block or Inline element.
+// Elements without contents (e.g. Space) are written without the "c" key.
+type pandocElement struct {
+	T string
+	C interface{}
+}
+
+type pandocDocument struct {
+	APIVersion []int                  `json:"pandoc-api-version"`
+	Meta       map[string]interface{} `json:"meta"`
+	Blocks     []interface{}          `json:"blocks"`
+}
+
+var pandocAPIVersion = []int{1, 23, 1}
+
+var emphasisPandocElements = map[string]string{
+	"/":   "Emph",
+	"*":   "Strong",
+	"+":   "Strikeout",
+	"_":   "Underline",
+	"_{}": "Subscript",
+	"^{}": "Superscript",
+}
+
+var pandocListItemStatuses = map[string]string{
+	" ": "☐",
+	"-": "❍",
+	"X": "☒",
+}
+
+var pandocColumnAlignments = map[string]string{
+	"left":   "AlignLeft",
+	"right":  "AlignRight",
+	"center": "AlignCenter",
+}
+
+func NewPandocWriter() *PandocWriter {
+	defaultConfig := New()
+	return &PandocWriter{
+		Indent:              "  ",
+		document:            &Document{Configuration: defaultConfig},
+		footnoteDefinitions: map[string]*FootnoteDefinition{},
+	}
+}
+
+func (w *PandocWriter) WriterWithExtensions() Writer {
+	if w.ExtendingWriter != nil {
+		return w.ExtendingWriter
+	}
+	return w
+}
+
+// WriteNodesAsString returns the nodes as a json array of pandoc elements.
+func (w *PandocWriter) WriteNodesAsString(nodes ...Node) string {
+	out, err := w.marshal(w.collect(nodes...))
+	if err != nil {
+		panic(err)
+	}
+	return string(out)
+}
+
+func (w *PandocWriter) Before(d *Document) {
+	w.document = d
+	collectFootnoteDefinitions(d.Nodes, w.footnoteDefinitions)
+}
+
+func (w *PandocWriter) After(d *Document) {
+	meta := map[string]interface{}{}
+	if d.GetOption("title") != "nil" {
+		for _, key := range []string{"TITLE", "AUTHOR", "DATE"} {
+			if value := d.Get(key); value != "" {
+				meta[strings.ToLower(key)] = pandocElement{"MetaInlines", w.inlineSettingValue(value)}
+			}
+		}
+	}
+	out, err := w.marshal(pandocDocument{pandocAPIVersion, meta, w.elements})
+	if err != nil {
+		panic(err)
+	}
+	w.WriteString(string(out) + "\n")
+}
+
+func (w *PandocWriter) marshal(v interface{}) ([]byte, error) {
+	if w.Indent == "" {
+		return json.Marshal(v)
+	}
+	return json.MarshalIndent(v, "", w.Indent)
+}
+
+func (e pandocElement) MarshalJSON() ([]byte, error) {
+	if e.C == nil {
+		return json.Marshal(struct {
+			T string `json:"t"`
+		}{e.T})
+	}
+	return json.Marshal(struct {
+		T string      `json:"t"`
+		C interface{} `json:"c"`
+	}{e.T, e.C})
+}
+
+func (w *PandocWriter) add(t string, c interface{}) {
+	w.elements = append(w.elements, pandocElement{t, c})
+}
+
+// collect returns the pandoc elements for nodes rather than adding them to the output.
+func (w *PandocWriter) collect(nodes ...Node) []interface{} {
+	original := w.elements
+	w.elements = []interface{}{}
+	WriteNodes(w, nodes...)
+	elements := w.elements
+	w.elements = original
+	return elements
+}
+
+// inlineSettingValue returns the inline elements for the value of an in-buffer setting (e.g. #+TITLE).
+func (w *PandocWriter) inlineSettingValue(value string) []interface{} {
+	d := w.document.parseFragment(value)
+	if d.Error != nil {
+		return pandocText(value)
+	}
+	inlines := []interface{}{}
+	for _, n := range d.Nodes {
+		if p, ok := n.(Paragraph); ok {
+			inlines = append(inlines, w.collect(p.Children...)...)
+		}
+	}
+	return inlines
+}
+
+func (w *PandocWriter) WriteKeyword(k Keyword) {
+	switch k.Key {
+	case "HTML":
+		w.add("RawBlock", []interface{}{"html", k.Value})
+	case "LATEX", "TEX":
+		w.add("RawBlock", []interface{}{"latex", k.Value})
+	}
+}
+
+func (w *PandocWriter) WriteInclude(i Include) { WriteNodes(w, i.Resolve()) }
+
+func (w *PandocWriter) WriteComment(Comment)                       {}
+func (w *PandocWriter) WritePropertyDrawer(PropertyDrawer)         {}
+func (w *PandocWriter) WriteLogbookDrawer(d LogbookDrawer)         { writeLogbookClocks(w, d) }
+func (w *PandocWriter) WriteFootnoteDefinition(FootnoteDefinition) {}
+
+// WriteNodeWithMeta writes captioned tables using the caption of the pandoc table and other captioned nodes as figures.
+// #+ATTR_HTML attributes are added to images or, for all other nodes, a div wrapping the node.
+func (w *PandocWriter) WriteNodeWithMeta(n NodeWithMeta) {
+	attributes := [][]string{}
+	classes := []string{}
+	for _, attrs := range n.Meta.HTMLAttributes {
+		for i := 0; i+1 < len(attrs); i += 2 {
+			if k, v := strings.TrimPrefix(attrs[i], ":"), attrs[i+1]; k == "class" {
+				classes = append(classes, strings.Fields(v)...)
+			} else {
+				attributes = append(attributes, []string{k, v})
+			}
+		}
+	}
+	elements := w.collect(n.Node)
+	if p, ok := n.Node.(Paragraph); ok && len(p.Children) == 1 && isImageOrVideoLink(p.Children[0]) && len(elements) == 1 {
+		if inlines := elements[0].(pandocElement).C.([]interface{}); len(inlines) == 1 {
+			image := inlines[0].(pandocElement).C.([]interface{})
+			image[0] = pandocAttr("", classes, attributes)
+			classes, attributes = nil, nil
+		}
+	}
+	if len(classes) != 0 || len(attributes) != 0 {
+		elements = []interface{}{pandocElement{"Div", []interface{}{pandocAttr("", classes, attributes), elements}}}
+	}
+	if len(n.Meta.Caption) != 0 {
+		caption := []interface{}{}
+		for i, c := range n.Meta.Caption {
+			if i != 0 {
+				caption = append(caption, pandocElement{"Space", nil})
+			}
+			caption = append(caption, w.collect(c...)...)
+		}
+		captionBlock := []interface{}{nil, []interface{}{pandocElement{"Plain", caption}}}
+		if len(elements) == 1 && elements[0].(pandocElement).T == "Table" {
+			elements[0].(pandocElement).C.([]interface{})[1] = captionBlock
+		} else if len(elements) != 0 {
+			if len(elements) == 1 && elements[0].(pandocElement).T == "Para" {
+				elements[0] = pandocElement{"Plain", elements[0].(pandocElement).C}
+			}
+			elements = []interface{}{pandocElement{"Figure", []interface{}{pandocAttr("", nil, nil), captionBlock, elements}}}
+		}
+	}
+	w.elements = append(w.elements, elements...)
+}
+
+// WriteNodeWithName sets the name as the id of the named element - or wraps it in a div with that id
+// if the element does not have attributes.
+func (w *PandocWriter) WriteNodeWithName(n NodeWithName) {
+	elements := w.collect(n.Node)
+	if len(elements) == 1 {
+		switch e := elements[0].(pandocElement); e.T {
+		case "CodeBlock", "Table", "Figure", "Div":
+			if attr := e.C.([]interface{})[0].([]interface{}); attr[0] == "" {
+				attr[0] = n.Name
+				w.elements = append(w.elements, e)
+				return
+			}
+		}
+	}
+	w.add("Div", []interface{}{pandocAttr(n.Name, nil, nil), elements})
+}
+
+func (w *PandocWriter) WriteHeadline(h Headline) {
+	if h.IsExcluded(w.document) {
+		return
+	}
+	title := []interface{}{}
+	if w.document.GetOption("todo") != "nil" && h.Status != "" {
+		title = append(title, pandocElement{"Span", []interface{}{pandocAttr("", []string{"todo", h.Status}, nil), pandocText(h.Status)}})
+		title = append(title, pandocElement{"Space", nil})
+	}
+	if w.document.GetOption("pri") != "nil" && h.Priority != "" {
+		title = append(title, pandocElement{"Str", "[#" + h.Priority + "]"}, pandocElement{"Space", nil})
+	}
+	title = append(title, w.collect(h.Title...)...)
+	if w.document.GetOption("tags") != "nil" {
+		for _, tag := range h.Tags {
+			attr := pandocAttr("", []string{"tag"}, [][]string{{"tag-name", tag}})
+			title = append(title, pandocElement{"Space", nil},
+				pandocElement{"Span", []interface{}{attr, []interface{}{pandocElement{"SmallCaps", pandocText(tag)}}}})
+		}
+	}
+	w.add("Header", []interface{}{h.Lvl, pandocAttr(h.ID(), nil, nil), title})
+	if h.Planning != nil {
+		WriteNodes(w, *h.Planning)
+	}
+	WriteNodes(w, h.Children...)
+}
+
+func (w *PandocWriter) WritePlanning(p Planning) {
+	if w.document.GetOption("p") == "nil" || len(p.Entries) == 0 {
+		return
+	}
+	inlines := []interface{}{}
+	for i, e := range p.Entries {
+		if i != 0 {
+			inlines = append(inlines, pandocElement{"Space", nil})
+		}
+		inlines = append(inlines, pandocElement{"Strong", pandocText(e.Keyword + ":")}, pandocElement{"Space", nil}, pandocTimestamp(e.Timestamp))
+	}
+	w.add("Plain", inlines)
+}
+
+func (w *PandocWriter) WriteClock(c Clock) {
+	if w.document.GetOption("c") == "nil" {
+		return
+	}
+	inlines := []interface{}{pandocElement{"Strong", pandocText("CLOCK:")}, pandocElement{"Space", nil}, pandocTimestamp(c.Timestamp)}
+	if !c.IsRunning() {
+		inlines = append(inlines, pandocText(" => "+strings.TrimSpace(formatClockDuration(c.Duration)))...)
+	}
+	w.add("Plain", inlines)
+}
+
+func (w *PandocWriter) WriteBlock(b Block) {
+	content, params := w.blockContent(b.Children), b.ParameterMap()
+	switch b.Name {
+	case "SRC":
+		if params[":exports"] == "results" || params[":exports"] == "none" {
+			break
+		}
+		classes := []string{}
+		if len(b.Parameters) >= 1 {
+			classes = append(classes, strings.ToLower(b.Parameters[0]))
+		}
+		w.add("CodeBlock", []interface{}{pandocAttr("", classes, nil), content})
+	case "EXAMPLE":
+		w.add("CodeBlock", []interface{}{pandocAttr("", nil, nil), content})
+	case "EXPORT":
+		if len(b.Parameters) >= 1 {
+			w.add("RawBlock", []interface{}{strings.ToLower(b.Parameters[0]), content})
+		}
+	case "QUOTE":
+		w.add("BlockQuote", w.collect(b.Children...))
+	case "VERSE":
+		lines := [][]interface{}{}
+		for i, n := range b.Children {
+			p, ok := n.(Paragraph)
+			if !ok {
+				continue
+			} else if i != 0 {
+				lines = append(lines, []interface{}{})
+			}
+			line := []interface{}{}
+			for _, e := range w.collect(p.Children...) {
+				if t := e.(pandocElement).T; t == "SoftBreak" || t == "LineBreak" {
+					lines, line = append(lines, line), []interface{}{}
+				} else {
+					line = append(line, e)
+				}
+			}
+			if len(line) != 0 {
+				lines = append(lines, line)
+			}
+		}
+		w.add("LineBlock", lines)
+	default:
+		w.add("Div", []interface{}{pandocAttr("", []string{strings.ToLower(b.Name)}, nil), w.collect(b.Children...)})
+	}
+	if b.Result != nil && params[":exports"] != "code" && params[":exports"] != "none" {
+		WriteNodes(w, b.Result)
+	}
+}
+
+func (w *PandocWriter) WriteDynamicBlock(b DynamicBlock) { WriteNodes(w, b.Children...) }
+
+func (w *PandocWriter) WriteResult(r Result) { WriteNodes(w, r.Node) }
+
+func (w *PandocWriter) WriteInlineBlock(b InlineBlock) {
+	content := w.blockContent(b.Children)
+	switch b.Name {
+	case "src":
+		classes := []string{}
+		if len(b.Parameters) >= 1 {
+			classes = append(classes, strings.ToLower(b.Parameters[0]))
+		}
+		w.add("Code", []interface{}{pandocAttr("", classes, nil), content})
+	case "export":
+		w.add("RawInline", []interface{}{strings.ToLower(b.Parameters[0]), content})
+	}
+}
+
+func (w *PandocWriter) WriteExample(e Example) {
+	lines := []string{}
+	for _, n := range e.Children {
+		lines = append(lines, w.blockContent([]Node{n}))
+	}
+	w.add("CodeBlock", []interface{}{pandocAttr("", nil, nil), strings.Join(lines, "\n")})
+}
+
+func (w *PandocWriter) WriteDrawer(d Drawer) { WriteNodes(w, d.Children...) }
+
+func (w *PandocWriter) WriteList(l List) {
+	items := []interface{}{}
+	for _, item := range l.Items {
+		if l.Kind == "descriptive" {
+			items = append(items, w.collect(item)...)
+		} else {
+			items = append(items, w.collect(item))
+		}
+	}
+	switch l.Kind {
+	case "ordered":
+		start := 1
+		if item, ok := l.Items[0].(ListItem); ok && item.Value != "" {
+			if v, err := strconv.Atoi(item.Value); err == nil {
+				start = v
+			}
+		}
+		attributes := []interface{}{start, pandocElement{"Decimal", nil}, pandocElement{"Period", nil}}
+		w.add("OrderedList", []interface{}{attributes, items})
+	case "descriptive":
+		w.add("DefinitionList", items)
+	default:
+		w.add("BulletList", items)
+	}
+}
+
+func (w *PandocWriter) WriteListItem(li ListItem) {
+	w.elements = append(w.elements, pandocListItemBlocks(li.Status, w.collect(li.Children...))...)
+}
+
+// WriteDescriptiveListItem writes a (term, definitions) pair of a pandoc DefinitionList.
+func (w *PandocWriter) WriteDescriptiveListItem(di DescriptiveListItem) {
+	term := pandocText("?")
+	if len(di.Term) != 0 {
+		term = w.collect(di.Term...)
+	}
+	if status, ok := pandocListItemStatuses[di.Status]; ok {
+		term = append([]interface{}{pandocElement{"Str", status}, pandocElement{"Space", nil}}, term...)
+	}
+	details := pandocListItemBlocks("", w.collect(di.Details...))
+	w.elements = append(w.elements, []interface{}{term, []interface{}{details}})
+}
+
+func (w *PandocWriter) WriteTable(t Table) {
+	inHead := len(t.SeparatorIndices) > 0 &&
+		t.SeparatorIndices[0] != len(t.Rows)-1 &&
+		(t.SeparatorIndices[0] != 0 || len(t.SeparatorIndices) > 1 && t.SeparatorIndices[len(t.SeparatorIndices)-1] != len(t.Rows)-1)
+	head, bodies, rows := []interface{}{}, []interface{}{}, []interface{}{}
+	addBody := func() {
+		if len(rows) != 0 {
+			bodies = append(bodies, []interface{}{pandocAttr("", nil, nil), 0, []interface{}{}, rows})
+		}
+		rows = []interface{}{}
+	}
+	for i, row := range t.Rows {
+		if len(row.Columns) == 0 && i != 0 && i != len(t.Rows)-1 {
+			if inHead {
+				head, rows, inHead = rows, []interface{}{}, false
+			} else {
+				addBody()
+			}
+		}
+		if row.IsSpecial || len(row.Columns) == 0 {
+			continue
+		}
+		cells := []interface{}{}
+		for _, column := range row.Columns {
+			cell := []interface{}{pandocElement{"Plain", w.collect(column.Children...)}}
+			cells = append(cells, []interface{}{pandocAttr("", nil, nil), pandocElement{"AlignDefault", nil}, 1, 1, cell})
+		}
+		rows = append(rows, []interface{}{pandocAttr("", nil, nil), cells})
+	}
+	addBody()
+	columns := []interface{}{}
+	for _, info := range t.ColumnInfos {
+		align, ok := pandocColumnAlignments[info.Align]
+		if !ok {
+			align = "AlignDefault"
+		}
+		columns = append(columns, []interface{}{pandocElement{align, nil}, pandocElement{"ColWidthDefault", nil}})
+	}
+	w.add("Table", []interface{}{
+		pandocAttr("", nil, nil),
+		[]interface{}{nil, []interface{}{}},
+		columns,
+		[]interface{}{pandocAttr("", nil, nil), head},
+		bodies,
+		[]interface{}{pandocAttr("", nil, nil), []interface{}{}},
+	})
+}
+
+func (w *PandocWriter) WriteHorizontalRule(HorizontalRule) { w.add("HorizontalRule", nil) }
+
+func (w *PandocWriter) WriteParagraph(p Paragraph) {
+	if len(p.Children) == 0 {
+		return
+	}
+	inlines := w.collect(p.Children...)
+	for len(inlines) != 0 && isPandocWhitespace(inlines[0]) {
+		inlines = inlines[1:]
+	}
+	for len(inlines) != 0 && isPandocWhitespace(inlines[len(inlines)-1]) {
+		inlines = inlines[:len(inlines)-1]
+	}
+	w.add("Para", inlines)
+}
+
+func (w *PandocWriter) WriteText(t Text) {
+	content := t.Content
+	if !t.IsRaw && w.document.GetOption("e") != "nil" {
+		content = htmlEntityReplacer.Replace(content)
+	}
+	w.elements = append(w.elements, pandocText(content)...)
+}
+
+func (w *PandocWriter) WriteEmphasis(e Emphasis) {
+	switch e.Kind {
+	case "~", "=":
+		w.add("Code", []interface{}{pandocAttr("", nil, nil), w.blockContent(e.Content)})
+		return
+	}
+	t, ok := emphasisPandocElements[e.Kind]
+	if !ok {
+		panic(fmt.Sprintf("bad emphasis %#v", e))
+	}
+	w.add(t, w.collect(e.Content...))
+}
+
+func (w *PandocWriter) WriteLatexFragment(l LatexFragment) {
+	content := w.blockContent(l.Content)
+	switch l.OpeningPair {
+	case `\(`, `$`:
+		w.add("Math", []interface{}{pandocElement{"InlineMath", nil}, content})
+	case `\[`, `$$`:
+		w.add("Math", []interface{}{pandocElement{"DisplayMath", nil}, content})
+	default:
+		w.add("RawInline", []interface{}{"latex", l.OpeningPair + content + l.ClosingPair})
+	}
+}
+
+func (w *PandocWriter) WriteStatisticToken(s StatisticToken) { w.add("Str", "["+s.Content+"]") }
+
+func (w *PandocWriter) WriteExplicitLineBreak(ExplicitLineBreak) { w.add("LineBreak", nil) }
+
+func (w *PandocWriter) WriteLineBreak(l LineBreak) {
+	if w.document.GetOption("ealb") == "nil" || !l.BetweenMultibyteCharacters {
+		w.add("SoftBreak", nil)
+	}
+}
+
+func (w *PandocWriter) WriteRegularLink(l RegularLink) {
+	url := w.linkURL(l)
+	switch l.Kind() {
+	case "image", "video":
+		if l.Description == nil {
+			w.add("Image", []interface{}{pandocAttr("", nil, nil), []interface{}{}, []string{url, ""}})
+			return
+		}
+		fallthrough
+	default:
+		description := pandocText(url)
+		if l.Description != nil {
+			description = w.collect(l.Description...)
+		}
+		w.add("Link", []interface{}{pandocAttr("", nil, nil), description, []string{url, ""}})
+	}
+}
+
+func (w *PandocWriter) WriteTarget(t Target) {
+	w.add("Span", []interface{}{pandocAttr(targetID(t.Name), nil, nil), []interface{}{}})
+}
+
+func (w *PandocWriter) WriteRadioTarget(t RadioTarget) {
+	w.add("Span", []interface{}{pandocAttr(targetID(t.Name), nil, nil), pandocText(t.Name)})
+}
+
+func (w *PandocWriter) WriteMacro(m Macro) {
+	if macro := w.document.Macros[m.Name]; macro != "" {
+		for i, param := range m.Parameters {
+			macro = strings.Replace(macro, fmt.Sprintf("$%d", i+1), param, -1)
+		}
+		macroDocument := w.document.parseFragment(macro)
+		if macroDocument.Error != nil {
+			w.document.addDiagnostic(CodeBadMacro, m.Position, "bad macro %s (%s): %s", m.Name, macro, macroDocument.Error)
+		}
+		for _, n := range macroDocument.Nodes {
+			if p, ok := n.(Paragraph); ok {
+				WriteNodes(w, p.Children...)
+			}
+		}
+	}
+}
+
+func (w *PandocWriter) WriteTimestamp(t Timestamp) {
+	if w.document.GetOption("<") == "nil" {
+		return
+	}
+	w.elements = append(w.elements, pandocTimestamp(t))
+}
+
+// WriteFootnoteLink writes the footnote definition as a pandoc Note.
+func (w *PandocWriter) WriteFootnoteLink(l FootnoteLink) {
+	if w.document.GetOption("f") == "nil" {
+		return
+	}
+	definition := l.Definition
+	if definition == nil {
+		definition = w.footnoteDefinitions[l.Name]
+	}
+	if definition == nil {
+		w.document.addDiagnostic(CodeUndefinedFootnote, l.Position, "undefined footnote [fn:%s]", l.Name)
+		return
+	}
+	w.add("Note", w.collect(definition.Children...))
+}
+
+func (w *PandocWriter) blockContent(children []Node) string {
+	return strings.TrimRightFunc(String(children), unicode.IsSpace)
+}
+
+func (w *PandocWriter) linkURL(l RegularLink) string {
+	url := l.URL
+	if l.Protocol == "file" {
+		url = url[len("file:"):]
+	}
+	if prefix := w.document.Links[l.Protocol]; prefix != "" {
+		if tag := strings.TrimPrefix(l.URL, l.Protocol+":"); strings.Contains(prefix, "%s") || strings.Contains(prefix, "%h") {
+			url = strings.ReplaceAll(strings.ReplaceAll(prefix, "%s", tag), "%h", u.QueryEscape(tag))
+		} else {
+			url = prefix + tag
+		}
+	} else if prefix := w.document.Links[l.URL]; prefix != "" {
+		url = strings.ReplaceAll(strings.ReplaceAll(prefix, "%s", ""), "%h", "")
+	}
+	return url
+}
+
+// pandocAttr returns a pandoc Attr (identifier, classes, key-value pairs).
+func pandocAttr(id string, classes []string, attributes [][]string) []interface{} {
+	if classes == nil {
+		classes = []string{}
+	}
+	if attributes == nil {
+		attributes = [][]string{}
+	}
+	return []interface{}{id, classes, attributes}
+}
+
+// pandocText splits s into Str elements separated by Space and SoftBreak elements.
+func pandocText(s string) []interface{} {
+	inlines := []interface{}{}
+	for i := 0; i < len(s); {
+		j := strings.IndexFunc(s[i:], unicode.IsSpace)
+		if j == -1 {
+			inlines = append(inlines, pandocElement{"Str", s[i:]})
+			break
+		} else if j > 0 {
+			inlines = append(inlines, pandocElement{"Str", s[i : i+j]})
+		}
+		i += j
+		k := strings.IndexFunc(s[i:], func(r rune) bool { return !unicode.IsSpace(r) })
+		if k == -1 {
+			k = len(s) - i
+		}
+		if strings.Contains(s[i:i+k], "\n") {
+			inlines = append(inlines, pandocElement{"SoftBreak", nil})
+		} else {
+			inlines = append(inlines, pandocElement{"Space", nil})
+		}
+		i += k
+	}
+	return inlines
+}
+
+func pandocTimestamp(t Timestamp) pandocElement {
+	return pandocElement{"Span", []interface{}{pandocAttr("", []string{"timestamp"}, nil), pandocText(t.format("<", ">"))}}
+}
+
+// pandocListItemBlocks turns the paragraphs of a list item into Plain blocks and prefixes them with the checkbox for status.
+func pandocListItemBlocks(status string, blocks []interface{}) []interface{} {
+	for i, b := range blocks {
+		if e, ok := b.(pandocElement); ok && e.T == "Para" {
+			blocks[i] = pandocElement{"Plain", e.C}
+		}
+	}
+	if checkbox, ok := pandocListItemStatuses[status]; ok {
+		prefix := []interface{}{pandocElement{"Str", checkbox}, pandocElement{"Space", nil}}
+		if len(blocks) != 0 && blocks[0].(pandocElement).T == "Plain" {
+			blocks[0] = pandocElement{"Plain", append(prefix, blocks[0].(pandocElement).C.([]interface{})...)}
+		} else {
+			blocks = append([]interface{}{pandocElement{"Plain", prefix[:1]}}, blocks...)
+		}
+	}
+	return blocks
+}
+
+func isPandocWhitespace(e interface{}) bool {
+	t := e.(pandocElement).T
+	return t == "Space" || t == "SoftBreak"
+}
@@ -0,0 +1,23 @@
+package org
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPandocWriter(t *testing.T) {
+	for _, path := range orgTestFiles() {
+		expected := fileString(path[:len(path)-len(".org")] + ".json")
+		reader, writer := strings.NewReader(fileString(path)), NewPandocWriter()
+		actual, err := New().Silent().Parse(reader, path).Write(writer)
+		if err != nil {
+			t.Errorf("%s\n got error: %s", path, err)
+			continue
+		}
+		if actual != expected {
+			t.Errorf("%s:\n%s'", path, diff(actual, expected))
+		} else {
+			t.Logf("%s: passed!", path)
+		}
+	}
+}
@@ -6,8 +6,12 @@ import (
 	"strings"
 )
 
-type Paragraph struct{ Children []Node }
-type HorizontalRule struct{}
+type Paragraph struct {
+	Children []Node
+	Position
+}
+
+type HorizontalRule struct{ Position }
 
 var horizontalRuleRegexp = regexp.MustCompile(`^(\s*)-{5,}\s*$`)
 var plainTextRegexp = regexp.MustCompile(`^(\s*)(.*)`)
@@ -28,19 +32,22 @@ func lexHorizontalRule(line string) (token, bool) {
 
 func (d *Document) parseParagraph(i int, parentStop stopFn) (int, Node) {
 	lines, start := []string{d.tokens[i].content}, i
+	cols := []int{d.suffixColumn(i, d.tokens[i].content)}
 	stop := func(d *Document, i int) bool {
 		return parentStop(d, i) || d.tokens[i].kind != "text" || d.tokens[i].content == ""
 	}
 	for i += 1; !stop(d, i); i++ {
 		lvl := math.Max(float64(d.tokens[i].lvl-d.baseLvl), 0)
 		lines = append(lines, strings.Repeat(" ", int(lvl))+d.tokens[i].content)
+		cols = append(cols, int(math.Max(float64(d.suffixColumn(i, d.tokens[i].content))-lvl, 0)))
 	}
 	consumed := i - start
-	return consumed, Paragraph{d.parseInline(strings.Join(lines, "\n"))}
+	m := d.lineSourceMap(start, lines, cols)
+	return consumed, Paragraph{d.parseInlineFrom(strings.Join(lines, "\n"), m), d.blockPosition(start, consumed)}
 }
 
 func (d *Document) parseHorizontalRule(i int, parentStop stopFn) (int, Node) {
-	return 1, HorizontalRule{}
+	return 1, HorizontalRule{d.blockPosition(i, 1)}
 }
 
 func (n Paragraph) String() string      { return orgWriter.WriteNodesAsString(n) }
@@ -0,0 +1,140 @@
+package org
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Point is a location in the parse input.
+type Point struct {
+	Offset int // Offset is the byte offset from the start of the input, starting at 0.
+	Line   int // Line is the line number, starting at 1.
+	Column int // Column is the byte offset from the start of the line, starting at 1.
+}
+
+// Position is the range of the parse input a node was parsed from. End is exclusive.
+// Nodes that were not created by the parser have the zero Position.
+type Position struct {
+	Start Point
+	End   Point
+}
+
+// sourceMap maps offsets of an inline input string (e.g. the joined lines of a paragraph)
+// back to Points in the parse input. It contains one segment per contiguous part of the input.
+type sourceMap []sourceSegment
+
+type sourceSegment struct {
+	offset int
+	point  Point
+}
+
+// Pos returns the position of the node in the parse input.
+func (p Position) Pos() Position { return p }
+
+// IsValid reports whether the position was set by the parser.
+func (p Position) IsValid() bool { return p.Start.Line > 0 }
+
+func (p Point) String() string { return fmt.Sprintf("%d:%d", p.Line, p.Column) }
+
+func (p Position) String() string {
+	if !p.IsValid() {
+		return "-"
+	}
+	return fmt.Sprintf("%s-%s", p.Start, p.End)
+}
+
+func (p Point) add(n int) Point { return Point{p.Offset + n, p.Line, p.Column + n} }
+
+// point returns the Point for the (0-based) column col of the (0-based) line i.
+func (d *Document) point(i, col int) Point {
+	if i >= len(d.lineOffsets) {
+		if len(d.lineOffsets) == 0 {
+			return Point{}
+		}
+		i = len(d.lineOffsets) - 1
+		col = len(d.lines[i])
+	}
+	return Point{d.lineOffsets[i] + col, i + 1, col + 1}
+}
+
+// tokenColumn returns the column the content of the token at i starts at.
+// Tokens are sometimes replaced with tokens for a suffix of the original line (e.g. list item content) -
+// the column then is relative to the start of that suffix.
+func (d *Document) tokenColumn(i int) int {
+	t, line := d.tokens[i], d.lines[i]
+	if len(t.matches) != 0 && strings.HasSuffix(line, t.matches[0]) {
+		return len(line) - len(t.matches[0]) + t.lvl
+	} else if t.content != "" && strings.HasSuffix(line, t.content) {
+		return len(line) - len(t.content)
+	}
+	return t.lvl
+}
+
+// suffixColumn returns the column s starts at in line i if s is a suffix of it - and the indentation otherwise.
+func (d *Document) suffixColumn(i int, s string) int {
+	if line := d.lines[i]; strings.HasSuffix(line, s) {
+		return len(line) - len(s)
+	}
+	return d.tokens[i].lvl
+}
+
+// blockStart returns the start Point of a node that is parsed from the token at i.
+func (d *Document) blockStart(i int) Point {
+	if i >= len(d.lines) {
+		return Point{}
+	}
+	return d.point(i, d.tokenColumn(i))
+}
+
+// blockEnd returns the end Point of a node that was parsed from the tokens [i, i+consumed).
+// Trailing blank lines are not included.
+func (d *Document) blockEnd(i, consumed int) Point {
+	if i >= len(d.lines) {
+		return Point{}
+	}
+	end := i + consumed - 1
+	if end >= len(d.lines) {
+		end = len(d.lines) - 1
+	}
+	for ; end > i && strings.TrimSpace(d.lines[end]) == ""; end-- {
+	}
+	return d.point(end, len(d.lines[end]))
+}
+
+// blockPosition returns the Position of a node that was parsed from the tokens [i, i+consumed).
+func (d *Document) blockPosition(i, consumed int) Position {
+	return Position{d.blockStart(i), d.blockEnd(i, consumed)}
+}
+
+// lineSourceMap returns a sourceMap for an input consisting of lines joined by "\n",
+// where lines[j] starts at column cols[j] of line i+j.
+func (d *Document) lineSourceMap(i int, lines []string, cols []int) sourceMap {
+	m, offset := make(sourceMap, 0, len(lines)), 0
+	for j, line := range lines {
+		if i+j >= len(d.lines) {
+			break
+		}
+		m = append(m, sourceSegment{offset, d.point(i+j, cols[j])})
+		offset += len(line) + 1
+	}
+	return m
+}
+
+func (m sourceMap) point(offset int) Point {
+	if len(m) == 0 {
+		return Point{}
+	}
+	j := sort.Search(len(m), func(j int) bool { return m[j].offset > offset }) - 1
+	if j < 0 {
+		j = 0
+	}
+	return m[j].point.add(offset - m[j].offset)
+}
+
+func (m sourceMap) position(start, end int) Position {
+	if len(m) == 0 {
+		return Position{}
+	}
+	return Position{m.point(start), m.point(end)}
+}
@@ -0,0 +1,95 @@
+package org
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+var positionTests = map[string][]string{
+	"* TODO headline *bold* :tag:\n  content\n** child\n": {
+		"Headline 1:1-3:9 * TODO headline *bold* :tag:\n  content\n** child",
+		"Text 1:8-1:17 headline ",
+		"Emphasis 1:17-1:23 *bold*",
+		"Paragraph 2:3-2:10 content",
+		"Headline 3:1-3:9 ** child",
+		"Text 3:4-3:9 child",
+	},
+	"- [X] item with a [[https://example.com][link]]\n  continued\n\n- item 2\n": {
+		"List 1:1-4:9 - [X] item with a [[https://example.com][link]]\n  continued\n\n- item 2",
+		"ListItem 1:1-2:12 - [X] item with a [[https://example.com][link]]\n  continued",
+		"Paragraph 1:7-2:12 item with a [[https://example.com][link]]\n  continued",
+		"RegularLink 1:19-1:48 [[https://example.com][link]]",
+		"Paragraph 3:1-3:1 ",
+		"ListItem 4:1-4:9 - item 2",
+		"Paragraph 4:3-4:9 item 2",
+	},
+	"#+CAPTION: a ~caption~\n| a | <2021-01-01 Fri> |\n": {
+		"NodeWithMeta 1:1-2:25 #+CAPTION: a ~caption~\n| a | <2021-01-01 Fri> |",
+		"Emphasis 1:14-1:23 ~caption~",
+		"Table 2:1-2:25 | a | <2021-01-01 Fri> |",
+		"Timestamp 2:7-2:23 <2021-01-01 Fri>",
+	},
+	"\r\nline 1\r\n#+BEGIN_QUOTE\r\nquote\r\n#+END_QUOTE\r\n": {
+		"Paragraph 1:1-2:7 \r\nline 1",
+		"Block 3:1-5:12 #+BEGIN_QUOTE\r\nquote\r\n#+END_QUOTE",
+		"Paragraph 4:1-4:6 quote",
+	},
+}
+
+func TestPositions(t *testing.T) {
+	for input, expected := range positionTests {
+		d := New().Silent().Parse(strings.NewReader(input), "./positionTests.org")
+		actual := []string{}
+		collectPositions(input, d.Nodes, &actual)
+		if a, e := strings.Join(actual, "\n---\n"), strings.Join(expected, "\n---\n"); a != e {
+			t.Errorf("%q:\n%s", input, diff(a, e))
+		}
+	}
+}
+
+// collectPositions collects the positions of interesting nodes in document order.
+// Text nodes are only collected as part of a headline title to keep the expectations readable.
+func collectPositions(input string, nodes []Node, out *[]string) {
+	add := func(n Node) {
+		p := n.Pos()
+		*out = append(*out, fmt.Sprintf("%s %s %s", strings.TrimPrefix(fmt.Sprintf("%T", n), "org."), p, input[p.Start.Offset:p.End.Offset]))
+	}
+	for _, n := range nodes {
+		switch n := n.(type) {
+		case Headline:
+			add(n)
+			for _, n := range n.Title {
+				add(n)
+			}
+			collectPositions(input, n.Children, out)
+		case Paragraph:
+			add(n)
+			collectPositions(input, n.Children, out)
+		case List:
+			add(n)
+			collectPositions(input, n.Items, out)
+		case ListItem:
+			add(n)
+			collectPositions(input, n.Children, out)
+		case Block:
+			add(n)
+			collectPositions(input, n.Children, out)
+		case NodeWithMeta:
+			add(n)
+			for _, caption := range n.Meta.Caption {
+				collectPositions(input, caption, out)
+			}
+			collectPositions(input, []Node{n.Node}, out)
+		case Table:
+			add(n)
+			for _, row := range n.Rows {
+				for _, column := range row.Columns {
+					collectPositions(input, column.Children, out)
+				}
+			}
+		case Emphasis, RegularLink, Timestamp:
+			add(n)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package org
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Priorities is the range of priorities of a document as defined by #+PRIORITIES (highest lowest default), e.g.
+//
+//	#+PRIORITIES: A C B
+//	#+PRIORITIES: 1 9 5
+//
+// Priorities are either upper case letters or numbers from 0 to 64 - the highest priority is the smallest one.
+type Priorities struct {
+	Highest string
+	Lowest  string
+	Default string
+}
+
+var priorityRegexp = regexp.MustCompile(`^\[#([A-Z]|\d{1,2})\]`)
+
+// Priorities returns the priority range of the document. Invalid #+PRIORITIES settings fall back to A C B.
+func (d *Document) Priorities() Priorities {
+	if fields := strings.Fields(d.Get("PRIORITIES")); len(fields) >= 3 {
+		p := Priorities{fields[0], fields[1], fields[2]}
+		if p.isValid() {
+			return p
+		}
+	}
+	return Priorities{"A", "C", "B"}
+}
+
+// Contains returns true if priority is in the range of p.
+func (p Priorities) Contains(priority string) bool {
+	rank, ok := p.rank(priority)
+	return ok && rank >= 0 && rank <= p.Rank(p.Lowest)
+}
+
+// Rank returns the rank of priority in p - 0 for the highest priority, 1 for the next one and so on.
+// Priorities outside the range of p are ranked like the default priority.
+func (p Priorities) Rank(priority string) int {
+	if rank, ok := p.rank(priority); ok && rank >= 0 {
+		if lowest, _ := p.rank(p.Lowest); rank <= lowest {
+			return rank
+		}
+	}
+	rank, _ := p.rank(p.Default)
+	return rank
+}
+
+func (p Priorities) rank(priority string) (int, bool) {
+	v, ok := priorityValue(priority)
+	highest, _ := priorityValue(p.Highest)
+	return v - highest, ok && isNumericPriority(priority) == isNumericPriority(p.Highest)
+}
+
+func (p Priorities) isValid() bool {
+	highest, ok1 := priorityValue(p.Highest)
+	lowest, ok2 := priorityValue(p.Lowest)
+	def, ok3 := priorityValue(p.Default)
+	numeric := isNumericPriority(p.Highest)
+	return ok1 && ok2 && ok3 && numeric == isNumericPriority(p.Lowest) && numeric == isNumericPriority(p.Default) &&
+		highest <= def && def <= lowest
+}
+
+// EffectivePriority returns the priority of the headline - or the default priority of d if the headline does not have one.
+func (h Headline) EffectivePriority(d *Document) string {
+	if h.Priority != "" {
+		return h.Priority
+	}
+	return d.Priorities().Default
+}
+
+// priorityValue returns the numeric value of a priority - letters are mapped to their character code.
+func priorityValue(priority string) (int, bool) {
+	if isNumericPriority(priority) {
+		n, err := strconv.Atoi(priority)
+		return n, err == nil && n <= 64
+	} else if len(priority) == 1 && priority[0] >= 'A' && priority[0] <= 'Z' {
+		return int(priority[0]), true
+	}
+	return 0, false
+}
+
+func isNumericPriority(priority string) bool {
+	return priority != "" && priority[0] >= '0' && priority[0] <= '9'
+}
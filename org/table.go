@@ -11,6 +11,7 @@ type Table struct {
 	Rows             []Row
 	ColumnInfos      []ColumnInfo
 	SeparatorIndices []int
+	Position
 }
 
 type Row struct {
@@ -44,39 +45,63 @@ func lexTable(line string) (token, bool) {
 }
 
 func (d *Document) parseTable(i int, parentStop stopFn) (int, Node) {
-	rawRows, separatorIndices, start := [][]string{}, []int{}, i
+	rawRows, rawColumnStarts, separatorIndices, start := [][]string{}, [][]int{}, []int{}, i
 	for ; !parentStop(d, i); i++ {
 		if t := d.tokens[i]; t.kind == "tableRow" {
-			rawRow := strings.FieldsFunc(d.tokens[i].content, func(r rune) bool { return r == '|' })
-			for i := range rawRow {
-				rawRow[i] = strings.TrimSpace(rawRow[i])
+			rawRow, columnStarts := splitTableRow(t.content)
+			for j := range columnStarts {
+				columnStarts[j] += d.suffixColumn(i, t.content)
 			}
-			rawRows = append(rawRows, rawRow)
+			rawRows, rawColumnStarts = append(rawRows, rawRow), append(rawColumnStarts, columnStarts)
 		} else if t.kind == "tableSeparator" {
 			separatorIndices = append(separatorIndices, i-start)
-			rawRows = append(rawRows, nil)
+			rawRows, rawColumnStarts = append(rawRows, nil), append(rawColumnStarts, nil)
 		} else {
 			break
 		}
 	}
 
-	table := Table{nil, getColumnInfos(rawRows), separatorIndices}
-	for _, rawColumns := range rawRows {
+	table := Table{nil, getColumnInfos(rawRows), separatorIndices, Position{}}
+	for j, rawColumns := range rawRows {
 		row := Row{nil, isSpecialRow(rawColumns)}
 		if len(rawColumns) != 0 {
 			for i := range table.ColumnInfos {
 				column := Column{nil, &table.ColumnInfos[i]}
 				if i < len(rawColumns) {
-					column.Children = d.parseInline(rawColumns[i])
+					m := d.lineSourceMap(start+j, rawColumns[i:i+1], rawColumnStarts[j][i:i+1])
+					column.Children = d.parseInlineFrom(rawColumns[i], m)
 				}
 				row.Columns = append(row.Columns, column)
 			}
 		}
 		table.Rows = append(table.Rows, row)
 	}
+	table.Position = d.blockPosition(start, i-start)
 	return i - start, table
 }
 
+// splitTableRow splits a table row into its (trimmed) columns and returns the offset of each column in the row.
+func splitTableRow(row string) ([]string, []int) {
+	columns, starts := []string{}, []int{}
+	for i := 0; i < len(row); {
+		if row[i] == '|' {
+			i++
+			continue
+		}
+		end := strings.IndexByte(row[i:], '|')
+		if end == -1 {
+			end = len(row)
+		} else {
+			end += i
+		}
+		column := strings.TrimSpace(row[i:end])
+		columns = append(columns, column)
+		starts = append(starts, i+strings.Index(row[i:end], column))
+		i = end
+	}
+	return columns, starts
+}
+
 func getColumnInfos(rows [][]string) []ColumnInfo {
 	columnCount := 0
 	for _, columns := range rows {
@@ -124,6 +149,36 @@ func getColumnInfos(rows [][]string) []ColumnInfo {
 	return columnInfos
 }
 
+// newTable returns a Table for rows of (inline) cell contents - nil rows are separators.
+// Column infos (alignment, length) are determined the same way they are for parsed tables.
+func newTable(rows [][][]Node) Table {
+	rawRows, separatorIndices := make([][]string, len(rows)), []int{}
+	for i, row := range rows {
+		if row == nil {
+			separatorIndices = append(separatorIndices, i)
+			continue
+		}
+		for _, cell := range row {
+			rawRows[i] = append(rawRows[i], String(cell))
+		}
+	}
+	table := Table{nil, getColumnInfos(rawRows), separatorIndices, Position{}}
+	for j, rawColumns := range rawRows {
+		row := Row{nil, isSpecialRow(rawColumns)}
+		if len(rawColumns) != 0 {
+			for i := range table.ColumnInfos {
+				column := Column{nil, &table.ColumnInfos[i]}
+				if i < len(rows[j]) {
+					column.Children = rows[j][i]
+				}
+				row.Columns = append(row.Columns, column)
+			}
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	return table
+}
+
 func isSpecialRow(rawColumns []string) bool {
 	isAlignRow := true
 	for _, rawColumn := range rawColumns {
@@ -0,0 +1,145 @@
+package org
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// linkTargets indexes the elements of a document that internal links can point to.
+type linkTargets struct {
+	customIDs   map[string]Headline
+	titles      map[string]Headline
+	names       map[string]string
+	targets     map[string]string
+	radioRegexp *regexp.Regexp // radioRegexp matches the names of all radio targets - nil if there are none.
+}
+
+// linkTargets returns the headlines (by CUSTOM_ID and title), named elements (#+NAME) and (radio) targets
+// of the document. The first element wins if multiple elements have the same name.
+func (d *Document) linkTargets() *linkTargets {
+	t := &linkTargets{map[string]Headline{}, map[string]Headline{}, map[string]string{}, map[string]string{}, nil}
+	radios := []string{}
+	Inspect(d.Nodes, func(n Node) bool {
+		switch n := n.(type) {
+		case Headline:
+			if id, ok := n.Properties.Get("CUSTOM_ID"); ok && !hasHeadline(t.customIDs, id) {
+				t.customIDs[id] = n
+			}
+			if title := normalizeLinkText(String(n.Title)); !hasHeadline(t.titles, title) {
+				t.titles[title] = n
+			}
+		case NodeWithName:
+			if name := normalizeLinkText(n.Name); t.names[name] == "" {
+				t.names[name] = nameID(n.Name)
+			}
+		case Target:
+			t.addTarget(n.Name)
+		case RadioTarget:
+			if t.addTarget(n.Name) {
+				radios = append(radios, n.Name)
+			}
+		}
+		return true
+	})
+	if len(radios) != 0 {
+		sort.Slice(radios, func(i, j int) bool { return len(radios[i]) > len(radios[j]) })
+		for i, radio := range radios {
+			radios[i] = strings.Join(strings.Fields(regexp.QuoteMeta(radio)), `\s+`)
+		}
+		t.radioRegexp = regexp.MustCompile(`(?i)` + strings.Join(radios, "|"))
+	}
+	return t
+}
+
+func (t *linkTargets) addTarget(name string) bool {
+	if target := normalizeLinkText(name); t.targets[target] == "" {
+		t.targets[target] = targetID(name)
+		return true
+	}
+	return false
+}
+
+// radioLinks returns the ranges of s that are occurrences of radio targets - and the ids of the radio targets.
+// Like Org mode, occurrences are matched case-insensitively and must not be part of a longer word.
+func (t *linkTargets) radioLinks(s string) (ranges [][2]int, ids []string) {
+	for i := 0; t.radioRegexp != nil && i < len(s); {
+		m := t.radioRegexp.FindStringIndex(s[i:])
+		if m == nil {
+			break
+		}
+		start, end := i+m[0], i+m[1]
+		before, _ := utf8.DecodeLastRuneInString(s[:start])
+		after, _ := utf8.DecodeRuneInString(s[end:])
+		if isWordRune(before) || isWordRune(after) {
+			_, size := utf8.DecodeRuneInString(s[start:])
+			i = start + size
+			continue
+		}
+		ranges, ids = append(ranges, [2]int{start, end}), append(ids, t.targets[normalizeLinkText(s[start:end])])
+		i = end
+	}
+	return ranges, ids
+}
+
+// fileLinkRegexp matches link urls that look like paths - i.e. that start with ./, ../, / or ~ or have a file extension.
+var fileLinkRegexp = regexp.MustCompile(`^(\.\.?/|/|~)|^\S+\.\w+$`)
+
+// isInternalLink reports whether l points to an element of the document rather than to a file or url - i.e. whether
+// it is a link to a headline ([[*title]]), a CUSTOM_ID ([[#id]]) or a target, name or headline title ([[text]]).
+func (d *Document) isInternalLink(l RegularLink) bool {
+	url := l.URL
+	if l.Protocol != "" || url == "" || d.Links[url] != "" {
+		return false
+	}
+	return strings.HasPrefix(url, "#") || strings.HasPrefix(url, "*") ||
+		!strings.HasPrefix(url, "(") && !fileLinkRegexp.MatchString(url)
+}
+
+// resolve returns the html id of the element the internal link url points to - and the headline if it points
+// to a headline. Like Org mode, [[text]] links are resolved to targets, named elements and headline titles (in that order).
+func (t *linkTargets) resolve(url string) (string, *Headline, error) {
+	switch {
+	case strings.HasPrefix(url, "#"):
+		if h, ok := t.customIDs[url[1:]]; ok {
+			return h.ID(), &h, nil
+		}
+		return "", nil, fmt.Errorf("no headline with CUSTOM_ID %q for link [[%s]]", url[1:], url)
+	case strings.HasPrefix(url, "*"):
+		if h, ok := t.titles[normalizeLinkText(url[1:])]; ok {
+			return h.ID(), &h, nil
+		}
+		return "", nil, fmt.Errorf("no headline %q for link [[%s]]", url[1:], url)
+	}
+	text := normalizeLinkText(url)
+	if id := t.targets[text]; id != "" {
+		return id, nil, nil
+	} else if name := t.names[text]; name != "" {
+		return name, nil, nil
+	} else if h, ok := t.titles[text]; ok {
+		return h.ID(), &h, nil
+	}
+	return "", nil, fmt.Errorf("no target, name or headline %q for link [[%s]]", url, url)
+}
+
+// targetID returns the html id of the target with the given text.
+func targetID(text string) string {
+	return "target-" + strings.ReplaceAll(normalizeLinkText(text), " ", "-")
+}
+
+// nameID returns the html id of the element named (#+NAME) name.
+func nameID(name string) string {
+	return "name-" + strings.ReplaceAll(normalizeLinkText(name), " ", "-")
+}
+
+func normalizeLinkText(s string) string { return strings.ToLower(strings.Join(strings.Fields(s), " ")) }
+
+func isWordRune(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }
+
+func hasHeadline(headlines map[string]Headline, key string) bool {
+	_, ok := headlines[key]
+	return ok
+}
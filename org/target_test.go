@@ -0,0 +1,26 @@
+package org
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInternalLinkDiagnostics(t *testing.T) {
+	path := "./testdata/internal_links.org"
+	d := New().Silent().Parse(strings.NewReader(fileString(path)), path)
+	if _, err := d.Write(NewHTMLWriter()); err != nil {
+		t.Fatalf("expected unresolved links to not fail writing: %s", err)
+	}
+	actual := []string{}
+	for _, diagnostic := range d.Diagnostics {
+		actual = append(actual, diagnostic.String())
+	}
+	expected := []string{
+		`10:66: warning: no headline "does not exist" for link [[*does not exist]] (broken-link)`,
+		`10:87: warning: no headline with CUSTOM_ID "does-not-exist" for link [[#does-not-exist]] (broken-link)`,
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("got diagnostics\n%s\nexpected\n%s", strings.Join(actual, "\n"), strings.Join(expected, "\n"))
+	}
+}
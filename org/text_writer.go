@@ -0,0 +1,662 @@
+package org
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	u "net/url"
+)
+
+// TextWriter exports an org document into plain text (akin to org-ascii-export) - e.g. for emails or terminal output.
+// Paragraphs are wrapped at Width columns; the width of east asian wide characters is counted as 2 columns.
+type TextWriter struct {
+	ExtendingWriter Writer
+	Width           int
+
+	strings.Builder
+	document       *Document
+	footnotes      *footnotes
+	sectionNumbers map[int]string
+	indent         int
+}
+
+var textHeadlineUnderlines = []string{"=", "~", "-"}
+
+var emphasisTextBorders = map[string][]string{
+	"/":   []string{"/", "/"},
+	"*":   []string{"*", "*"},
+	"+":   []string{"+", "+"},
+	"_":   []string{"_", "_"},
+	"~":   []string{"`", "'"},
+	"=":   []string{"`", "'"},
+	"_{}": []string{"_{", "}"},
+	"^{}": []string{"^{", "}"},
+}
+
+var textListItemStatuses = map[string]string{
+	" ": "[ ] ",
+	"-": "[-] ",
+	"X": "[X] ",
+}
+
+// eastAsianWideRanges are the (approximate) ranges of east asian wide and fullwidth characters.
+var eastAsianWideRanges = [][2]rune{
+	{0x1100, 0x115F}, {0x2E80, 0x303E}, {0x3041, 0x33FF}, {0x3400, 0x4DBF}, {0x4E00, 0x9FFF},
+	{0xA000, 0xA4CF}, {0xAC00, 0xD7A3}, {0xF900, 0xFAFF}, {0xFE30, 0xFE4F}, {0xFF00, 0xFF60},
+	{0xFFE0, 0xFFE6}, {0x1F300, 0x1F64F}, {0x1F900, 0x1F9FF}, {0x20000, 0x2FFFD}, {0x30000, 0x3FFFD},
+}
+
+const textExplicitLineBreak = "\u2028" // unicode line separator - replaced by a newline when wrapping paragraphs
+const textQuoteMargin = 4
+
+func NewTextWriter() *TextWriter {
+	defaultConfig := New()
+	return &TextWriter{
+		Width:          72,
+		document:       &Document{Configuration: defaultConfig},
+		footnotes:      &footnotes{mapping: map[string]int{}},
+		sectionNumbers: map[int]string{},
+	}
+}
+
+func (w *TextWriter) WriterWithExtensions() Writer {
+	if w.ExtendingWriter != nil {
+		return w.ExtendingWriter
+	}
+	return w
+}
+
+func (w *TextWriter) WriteNodesAsString(nodes ...Node) string {
+	original := w.Builder
+	w.Builder = strings.Builder{}
+	WriteNodes(w, nodes...)
+	out := w.String()
+	w.Builder = original
+	return out
+}
+
+func (w *TextWriter) Before(d *Document) {
+	w.document = d
+	w.numberSections(d.Outline.Children, "")
+	if title := d.Get("TITLE"); title != "" && d.GetOption("title") != "nil" {
+		titleDocument := d.parseFragment(title)
+		if titleDocument.Error == nil {
+			title = strings.TrimSpace(w.WriteNodesAsString(titleDocument.Nodes...))
+		}
+		w.WriteString(w.center(title) + "\n")
+		w.WriteString(w.center(strings.Repeat("=", textWidth(title))) + "\n")
+		for _, key := range []string{"AUTHOR", "DATE"} {
+			if value := d.Get(key); value != "" {
+				w.WriteString("\n" + w.center(value) + "\n")
+			}
+		}
+		w.WriteString("\n\n")
+	}
+	if d.GetOption("toc") != "nil" {
+		maxLvl, _ := strconv.Atoi(d.GetOption("toc"))
+		w.WriteOutline(d, maxLvl)
+	}
+}
+
+func (w *TextWriter) After(d *Document) {
+	w.WriteFootnotes(d)
+	out := multipleBlankLinesRegexp.ReplaceAllString(w.String(), "\n\n")
+	w.Builder.Reset()
+	if out = strings.TrimRightFunc(strings.TrimLeft(out, "\n"), unicode.IsSpace); out != "" {
+		w.WriteString(out + "\n")
+	}
+}
+
+// numberSections assigns section numbers (e.g. 1.2.3) to all sections that are not excluded from the export.
+func (w *TextWriter) numberSections(sections []*Section, prefix string) {
+	n := 0
+	for _, s := range sections {
+		if s.IsExcluded(w.document) {
+			continue
+		}
+		n++
+		number := prefix + strconv.Itoa(n)
+		w.sectionNumbers[s.Headline.Index] = number
+		w.numberSections(s.Children, number+".")
+	}
+}
+
+func (w *TextWriter) WriteOutline(d *Document, maxLvl int) {
+	lines := []string{}
+	var writeSection func(*Section, string)
+	writeSection = func(s *Section, indent string) {
+		if (maxLvl != 0 && s.Headline.Lvl > maxLvl) || s.IsExcluded(d) {
+			return
+		}
+		lines = append(lines, strings.TrimRight(indent+w.headlineTitle(*s.Headline, false), " "))
+		for _, child := range s.Children {
+			writeSection(child, indent+"  ")
+		}
+	}
+	for _, s := range d.Outline.Children {
+		writeSection(s, "")
+	}
+	if len(lines) != 0 {
+		w.WriteString("Table of Contents\n=================\n\n" + strings.Join(lines, "\n") + "\n\n\n")
+	}
+}
+
+func (w *TextWriter) WriteFootnotes(d *Document) {
+	if d.GetOption("f") == "nil" || len(w.footnotes.list) == 0 {
+		return
+	}
+	w.WriteString("\n\nFootnotes\n=========\n\n")
+	for i, definition := range w.footnotes.list {
+		if definition == nil {
+			w.document.addDiagnostic(CodeUndefinedFootnote, Position{}, "undefined footnote [fn:%s]", w.footnotes.name(i))
+			continue
+		}
+		label := fmt.Sprintf("[%d] ", i+1)
+		w.indent += len(label)
+		content := w.writeBlocks(definition.Children)
+		w.indent -= len(label)
+		w.WriteString(label + indentLines(content, strings.Repeat(" ", len(label))) + "\n\n")
+	}
+}
+
+func (w *TextWriter) WriteKeyword(k Keyword) {
+	switch k.Key {
+	case "ASCII", "TXT", "TEXT":
+		w.WriteString(k.Value + "\n\n")
+	case "TOC":
+		if m := tocHeadlineMaxLvlRegexp.FindStringSubmatch(k.Value); m != nil {
+			maxLvl, _ := strconv.Atoi(m[1])
+			w.WriteOutline(w.document, maxLvl)
+		}
+	}
+}
+
+func (w *TextWriter) WriteInclude(i Include) { WriteNodes(w, i.Resolve()) }
+
+func (w *TextWriter) WriteComment(Comment)               {}
+func (w *TextWriter) WritePropertyDrawer(PropertyDrawer) {}
+func (w *TextWriter) WriteLogbookDrawer(d LogbookDrawer) { writeLogbookClocks(w, d) }
+
+func (w *TextWriter) WriteNodeWithMeta(n NodeWithMeta) {
+	WriteNodes(w, n.Node)
+	if len(n.Meta.Caption) != 0 {
+		captions := []string{}
+		for _, caption := range n.Meta.Caption {
+			captions = append(captions, strings.TrimSpace(w.WriteNodesAsString(caption...)))
+		}
+		w.WriteString(w.wrap(strings.Join(captions, " ")) + "\n\n")
+	}
+}
+
+func (w *TextWriter) WriteNodeWithName(n NodeWithName) { WriteNodes(w, n.Node) }
+
+func (w *TextWriter) WriteHeadline(h Headline) {
+	if h.IsExcluded(w.document) {
+		return
+	}
+	title := strings.TrimRight(w.headlineTitle(h, true), " ")
+	w.WriteString(title + "\n")
+	if h.Lvl <= len(textHeadlineUnderlines) {
+		w.WriteString(strings.Repeat(textHeadlineUnderlines[h.Lvl-1], textWidth(title)) + "\n")
+	}
+	w.WriteString("\n")
+	if h.Planning != nil {
+		WriteNodes(w, *h.Planning)
+	}
+	WriteNodes(w, h.Children...)
+}
+
+func (w *TextWriter) headlineTitle(h Headline, withTags bool) string {
+	title := ""
+	if number, ok := w.sectionNumbers[h.Index]; ok && w.document.GetOption("num") != "nil" {
+		title += number + " "
+	}
+	if w.document.GetOption("todo") != "nil" && h.Status != "" {
+		title += h.Status + " "
+	}
+	if w.document.GetOption("pri") != "nil" && h.Priority != "" {
+		title += "[#" + h.Priority + "] "
+	}
+	title += strings.TrimSpace(strings.ReplaceAll(w.WriteNodesAsString(h.Title...), textExplicitLineBreak, " "))
+	if withTags && w.document.GetOption("tags") != "nil" && len(h.Tags) != 0 {
+		title += "  :" + strings.Join(h.Tags, ":") + ":"
+	}
+	return title
+}
+
+func (w *TextWriter) WritePlanning(p Planning) {
+	if w.document.GetOption("p") == "nil" || len(p.Entries) == 0 {
+		return
+	}
+	entries := []string{}
+	for _, e := range p.Entries {
+		entries = append(entries, e.Keyword+": "+e.Timestamp.format("<", ">"))
+	}
+	w.WriteString(strings.Join(entries, " ") + "\n\n")
+}
+
+func (w *TextWriter) WriteClock(c Clock) {
+	if w.document.GetOption("c") == "nil" {
+		return
+	}
+	w.WriteString("CLOCK: " + c.Timestamp.format("<", ">"))
+	if !c.IsRunning() {
+		w.WriteString(" => " + formatClockDuration(c.Duration))
+	}
+	w.WriteString("\n\n")
+}
+
+func (w *TextWriter) WriteBlock(b Block) {
+	params := b.ParameterMap()
+	switch b.Name {
+	case "SRC":
+		if params[":exports"] == "results" || params[":exports"] == "none" {
+			break
+		}
+		w.WriteString(w.blockContent(b.Name, b.Children) + "\n\n")
+	case "EXAMPLE":
+		w.WriteString(w.blockContent(b.Name, b.Children) + "\n\n")
+	case "EXPORT":
+		if len(b.Parameters) >= 1 {
+			switch strings.ToLower(b.Parameters[0]) {
+			case "ascii", "txt", "text":
+				w.WriteString(w.blockContent(b.Name, b.Children) + "\n\n")
+			}
+		}
+	case "QUOTE":
+		w.indent += textQuoteMargin
+		content := w.writeBlocks(b.Children)
+		w.indent -= textQuoteMargin
+		w.WriteString(prefixLines(content, strings.Repeat(" ", textQuoteMargin)) + "\n\n")
+	case "CENTER":
+		lines := strings.Split(w.writeBlocks(b.Children), "\n")
+		for i, line := range lines {
+			lines[i] = w.center(line)
+		}
+		w.WriteString(strings.Join(lines, "\n") + "\n\n")
+	case "VERSE":
+		paragraphs := []string{}
+		for _, child := range b.Children {
+			if p, ok := child.(Paragraph); ok {
+				paragraphs = append(paragraphs, strings.Trim(w.WriteNodesAsString(p.Children...), "\n"))
+			} else {
+				paragraphs = append(paragraphs, strings.Trim(w.WriteNodesAsString(child), "\n"))
+			}
+		}
+		content := strings.ReplaceAll(strings.Join(paragraphs, "\n\n"), textExplicitLineBreak, "\n")
+		w.WriteString(prefixLines(content, strings.Repeat(" ", textQuoteMargin)) + "\n\n")
+	default:
+		w.WriteString(w.writeBlocks(b.Children) + "\n\n")
+	}
+	if b.Result != nil && params[":exports"] != "code" && params[":exports"] != "none" {
+		WriteNodes(w, b.Result)
+	}
+}
+
+func (w *TextWriter) WriteDynamicBlock(b DynamicBlock) { WriteNodes(w, b.Children...) }
+
+func (w *TextWriter) WriteResult(r Result) { WriteNodes(w, r.Node) }
+
+func (w *TextWriter) WriteInlineBlock(b InlineBlock) {
+	content := w.blockContent(strings.ToUpper(b.Name), b.Children)
+	switch b.Name {
+	case "src":
+		w.WriteString("`" + content + "'")
+	case "export":
+		switch strings.ToLower(b.Parameters[0]) {
+		case "ascii", "txt", "text":
+			w.WriteString(content)
+		}
+	}
+}
+
+func (w *TextWriter) WriteExample(e Example) {
+	lines := []string{}
+	for _, n := range e.Children {
+		lines = append(lines, w.blockContent("EXAMPLE", []Node{n}))
+	}
+	w.WriteString(strings.Join(lines, "\n") + "\n\n")
+}
+
+func (w *TextWriter) WriteDrawer(d Drawer) { WriteNodes(w, d.Children...) }
+
+func (w *TextWriter) WriteList(l List) {
+	items := []string{}
+	for i, item := range l.Items {
+		switch item := item.(type) {
+		case ListItem:
+			bullet := "-"
+			if l.Kind == "ordered" {
+				n := i + 1
+				if v, err := strconv.Atoi(item.Value); err == nil {
+					n = v
+				}
+				bullet = strconv.Itoa(n) + "."
+			}
+			items = append(items, w.listItem(bullet+" "+textListItemStatuses[item.Status], item.Children))
+		case DescriptiveListItem:
+			term := "?"
+			if len(item.Term) != 0 {
+				term = strings.TrimSpace(w.WriteNodesAsString(item.Term...))
+			}
+			items = append(items, w.listItem("- "+textListItemStatuses[item.Status]+term+": ", item.Details))
+		}
+	}
+	w.WriteString(strings.Join(items, "\n") + "\n\n")
+}
+
+func (w *TextWriter) WriteListItem(li ListItem) {
+	w.WriteString(w.listItem("- "+textListItemStatuses[li.Status], li.Children) + "\n\n")
+}
+
+func (w *TextWriter) WriteDescriptiveListItem(di DescriptiveListItem) {
+	WriteNodes(w, List{"descriptive", []Node{di}, di.Position})
+}
+
+func (w *TextWriter) listItem(prefix string, children []Node) string {
+	width := textWidth(prefix)
+	if strings.HasPrefix(prefix, "- ") {
+		width = 2
+	} else if i := strings.Index(prefix, " "); i != -1 {
+		width = i + 1
+	}
+	w.indent += width
+	content := w.writeBlocks(children)
+	w.indent -= width
+	return strings.TrimRight(prefix+indentLines(content, strings.Repeat(" ", width)), " ")
+}
+
+// writeBlocks writes children separated by blank lines - except for lists directly following a paragraph.
+func (w *TextWriter) writeBlocks(children []Node) string {
+	out := ""
+	for i, child := range children {
+		content := strings.TrimRight(strings.TrimLeft(w.WriteNodesAsString(child), "\n"), " \n")
+		if content == "" {
+			continue
+		}
+		if out != "" {
+			out += "\n"
+			_, isParagraph := children[i-1].(Paragraph)
+			if _, isList := child.(List); !isParagraph || !isList {
+				out += "\n"
+			}
+		}
+		out += content
+	}
+	return out
+}
+
+func (w *TextWriter) WriteTable(t Table) {
+	widths := make([]int, len(t.ColumnInfos))
+	for i, info := range t.ColumnInfos {
+		widths[i] = info.Len
+	}
+	rows := [][]string{}
+	for _, row := range t.Rows {
+		if len(row.Columns) == 0 {
+			rows = append(rows, nil)
+			continue
+		} else if row.IsSpecial {
+			continue
+		}
+		cells := make([]string, len(widths))
+		for i, column := range row.Columns {
+			if i < len(cells) {
+				cells[i] = strings.TrimSpace(strings.NewReplacer("\n", " ", textExplicitLineBreak, " ").Replace(w.WriteNodesAsString(column.Children...)))
+				if n := textWidth(cells[i]); n > widths[i] {
+					widths[i] = n
+				}
+			}
+		}
+		rows = append(rows, cells)
+	}
+	border := ""
+	for _, width := range widths {
+		border += "+" + strings.Repeat("-", width+2)
+	}
+	border += "+"
+	lines := []string{border}
+	for _, cells := range rows {
+		if cells == nil {
+			if lines[len(lines)-1] != border {
+				lines = append(lines, border)
+			}
+			continue
+		}
+		line := ""
+		for i, cell := range cells {
+			line += "| " + textAlign(cell, widths[i], t.ColumnInfos[i].Align) + " "
+		}
+		lines = append(lines, line+"|")
+	}
+	if lines[len(lines)-1] != border {
+		lines = append(lines, border)
+	}
+	w.WriteString(strings.Join(lines, "\n") + "\n\n")
+}
+
+func (w *TextWriter) WriteHorizontalRule(HorizontalRule) {
+	w.WriteString(strings.Repeat("-", w.width()) + "\n\n")
+}
+
+func (w *TextWriter) WriteParagraph(p Paragraph) {
+	if len(p.Children) == 0 {
+		return
+	}
+	w.WriteString(w.wrap(w.WriteNodesAsString(p.Children...)) + "\n\n")
+}
+
+func (w *TextWriter) WriteText(t Text) {
+	content := t.Content
+	if !t.IsRaw && w.document.GetOption("e") != "nil" {
+		content = htmlEntityReplacer.Replace(content)
+	}
+	w.WriteString(content)
+}
+
+func (w *TextWriter) WriteEmphasis(e Emphasis) {
+	borders, ok := emphasisTextBorders[e.Kind]
+	if !ok {
+		panic(fmt.Sprintf("bad emphasis %#v", e))
+	}
+	w.WriteString(borders[0])
+	WriteNodes(w, e.Content...)
+	w.WriteString(borders[1])
+}
+
+func (w *TextWriter) WriteLatexFragment(l LatexFragment) {
+	w.WriteString(l.OpeningPair + w.blockContent("SRC", l.Content) + l.ClosingPair)
+}
+
+func (w *TextWriter) WriteStatisticToken(s StatisticToken) { w.WriteString("[" + s.Content + "]") }
+
+func (w *TextWriter) WriteExplicitLineBreak(ExplicitLineBreak) {
+	w.WriteString(textExplicitLineBreak)
+}
+
+func (w *TextWriter) WriteLineBreak(l LineBreak) {
+	if w.document.GetOption("ealb") == "nil" || !l.BetweenMultibyteCharacters {
+		w.WriteString(strings.Repeat("\n", l.Count))
+	}
+}
+
+func (w *TextWriter) WriteRegularLink(l RegularLink) {
+	url := w.linkURL(l)
+	description := url
+	if l.Description != nil {
+		description = strings.TrimSpace(w.WriteNodesAsString(l.Description...))
+	}
+	if description == url || l.Kind() == "image" && l.Description == nil {
+		w.WriteString("<" + url + ">")
+	} else {
+		w.WriteString(description + " <" + url + ">")
+	}
+}
+
+func (w *TextWriter) WriteTarget(Target) {}
+
+func (w *TextWriter) WriteRadioTarget(t RadioTarget) { w.WriteText(Text{t.Name, false, t.Position}) }
+
+func (w *TextWriter) WriteMacro(m Macro) {
+	if macro := w.document.Macros[m.Name]; macro != "" {
+		for i, param := range m.Parameters {
+			macro = strings.Replace(macro, fmt.Sprintf("$%d", i+1), param, -1)
+		}
+		macroDocument := w.document.parseFragment(macro)
+		if macroDocument.Error != nil {
+			w.document.addDiagnostic(CodeBadMacro, m.Position, "bad macro %s (%s): %s", m.Name, macro, macroDocument.Error)
+		}
+		w.WriteString(strings.TrimSpace(w.writeBlocks(macroDocument.Nodes)))
+	}
+}
+
+func (w *TextWriter) WriteTimestamp(t Timestamp) {
+	if w.document.GetOption("<") == "nil" {
+		return
+	}
+	w.WriteString(t.format("<", ">"))
+}
+
+func (w *TextWriter) WriteFootnoteLink(l FootnoteLink) {
+	if w.document.GetOption("f") == "nil" {
+		return
+	}
+	w.WriteString(fmt.Sprintf("[%d]", w.footnotes.add(l)+1))
+}
+
+func (w *TextWriter) WriteFootnoteDefinition(f FootnoteDefinition) {
+	w.footnotes.updateDefinition(f)
+}
+
+func (w *TextWriter) blockContent(name string, children []Node) string {
+	builder := w.Builder
+	w.Builder = strings.Builder{}
+	WriteNodes(w, children...)
+	out := w.String()
+	w.Builder = builder
+	if isRawTextBlock(name) {
+		return strings.TrimRight(out, " \t\n")
+	}
+	return out
+}
+
+func (w *TextWriter) linkURL(l RegularLink) string {
+	url := l.URL
+	if l.Protocol == "file" {
+		url = url[len("file:"):]
+	}
+	if prefix := w.document.Links[l.Protocol]; prefix != "" {
+		if tag := strings.TrimPrefix(l.URL, l.Protocol+":"); strings.Contains(prefix, "%s") || strings.Contains(prefix, "%h") {
+			url = strings.ReplaceAll(strings.ReplaceAll(prefix, "%s", tag), "%h", u.QueryEscape(tag))
+		} else {
+			url = prefix + tag
+		}
+	} else if prefix := w.document.Links[l.URL]; prefix != "" {
+		url = strings.ReplaceAll(strings.ReplaceAll(prefix, "%s", ""), "%h", "")
+	}
+	return url
+}
+
+// width returns the number of columns available to the content that is currently being written.
+func (w *TextWriter) width() int {
+	if width := w.Width - w.indent; width > 20 {
+		return width
+	}
+	return 20
+}
+
+func (w *TextWriter) center(s string) string {
+	if n := (w.width() - textWidth(s)) / 2; n > 0 {
+		return strings.Repeat(" ", n) + s
+	}
+	return s
+}
+
+// wrap fills s into lines of at most width() columns. Whitespace (including line breaks) is collapsed
+// and lines are only broken at whitespace or between east asian wide characters.
+func (w *TextWriter) wrap(s string) string {
+	lines, width := []string{}, w.width()
+	for _, segment := range strings.Split(s, textExplicitLineBreak) {
+		line, lineWidth := "", 0
+		for _, word := range textWords(segment) {
+			text, separator := strings.TrimLeftFunc(word, unicode.IsSpace), ""
+			if line != "" && text != word {
+				separator = " "
+			}
+			if textWidth(text) == 0 {
+				continue
+			} else if line != "" && lineWidth+len(separator)+textWidth(text) > width {
+				lines, line, lineWidth = append(lines, line), text, textWidth(text)
+			} else {
+				line, lineWidth = line+separator+text, lineWidth+len(separator)+textWidth(text)
+			}
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// textWords splits s into words - each word includes the whitespace preceding it. East asian wide characters
+// are words on their own as text in east asian languages can be broken between any two characters.
+func textWords(s string) []string {
+	words, word, hasText := []string{}, "", false
+	for _, r := range s {
+		switch {
+		case unicode.IsSpace(r):
+			if hasText {
+				words, word, hasText = append(words, word), "", false
+			}
+			word += string(r)
+		case runeWidth(r) == 2:
+			if hasText {
+				words, word = append(words, word), ""
+			}
+			words, word, hasText = append(words, word+string(r)), "", false
+		default:
+			word, hasText = word+string(r), true
+		}
+	}
+	if hasText {
+		words = append(words, word)
+	}
+	return words
+}
+
+func textAlign(s string, width int, align string) string {
+	padding := width - textWidth(s)
+	if padding <= 0 {
+		return s
+	}
+	switch align {
+	case "right":
+		return strings.Repeat(" ", padding) + s
+	case "center":
+		return strings.Repeat(" ", padding/2) + s + strings.Repeat(" ", padding-padding/2)
+	default:
+		return s + strings.Repeat(" ", padding)
+	}
+}
+
+// textWidth returns the number of columns s occupies in a monospace font.
+func textWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		width += runeWidth(r)
+	}
+	return width
+}
+
+func runeWidth(r rune) int {
+	if r == utf8.RuneError || unicode.Is(unicode.Mn, r) || unicode.IsControl(r) {
+		return 0
+	}
+	for _, wideRange := range eastAsianWideRanges {
+		if r >= wideRange[0] && r <= wideRange[1] {
+			return 2
+		}
+	}
+	return 1
+}
@@ -0,0 +1,46 @@
+package org
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTextWriter(t *testing.T) {
+	for _, path := range orgTestFiles() {
+		expected := fileString(path[:len(path)-len(".org")] + ".txt")
+		reader, writer := strings.NewReader(fileString(path)), NewTextWriter()
+		actual, err := New().Silent().Parse(reader, path).Write(writer)
+		if err != nil {
+			t.Errorf("%s\n got error: %s", path, err)
+			continue
+		}
+		if actual != expected {
+			t.Errorf("%s:\n%s'", path, diff(actual, expected))
+		} else {
+			t.Logf("%s: passed!", path)
+		}
+	}
+}
+
+func TestTextWriterWrap(t *testing.T) {
+	testCases := []struct {
+		width    int
+		input    string
+		expected string
+	}{
+		{20, "the quick brown fox jumps over the lazy dog", "the quick brown fox\njumps over the lazy\ndog"},
+		{20, "一二三四五六七八九十一二三四五", "一二三四五六七八九十\n一二三四五"},
+		{20, "mixed 中文 and english text", "mixed 中文 and\nenglish text"},
+		{20, "a hard\\\\\nline break", "a hard\nline break"},
+	}
+	for _, tc := range testCases {
+		writer := NewTextWriter()
+		writer.Width = tc.width
+		actual, err := New().Silent().Parse(strings.NewReader(tc.input), "").Write(writer)
+		if err != nil {
+			t.Errorf("%q: got error: %s", tc.input, err)
+		} else if actual != tc.expected+"\n" {
+			t.Errorf("%q:\n%s", tc.input, diff(actual, tc.expected+"\n"))
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package org
+
+import (
+	"regexp"
+	"strings"
+)
+
+// TodoSequence is a sequence of TODO keywords as defined by a #+TODO, #+SEQ_TODO or #+TYP_TODO line, e.g.
+//
+//	#+TODO: TODO(t) WAIT(w@/!) | DONE(d!) CANCELED(c@)
+type TodoSequence struct {
+	Type   string        // Type is "sequence" for #+TODO and #+SEQ_TODO and "type" for #+TYP_TODO.
+	Active []TodoKeyword // Active are the keywords before the "|".
+	Done   []TodoKeyword // Done are the keywords after the "|" - or the last keyword if there is no "|".
+}
+
+// TodoKeyword is a keyword of a TodoSequence.
+type TodoKeyword struct {
+	Name    string
+	Key     string // Key is the fast access key, e.g. "t" for TODO(t).
+	Logging string // Logging is the logging specification, e.g. "@/!" for WAIT(w@/!).
+}
+
+var todoKeywordRegexp = regexp.MustCompile(`^(.+?)(?:\((\w?)([@!]?(?:/[@!])?)\))?$`)
+
+// TodoSequences returns the TODO keyword sequences of the document in the order #+TODO, #+SEQ_TODO and #+TYP_TODO.
+// Each line defines a separate sequence. If the document does not define any sequence, the TODO default setting is used.
+func (d *Document) TodoSequences() []TodoSequence {
+	key := ""
+	for _, k := range []string{"TODO", "SEQ_TODO", "TYP_TODO"} {
+		key += d.BufferSettings[k] + "\x00"
+	}
+	if d.todoSequences != nil && d.todoSettings == key {
+		return d.todoSequences
+	}
+	sequences := []TodoSequence{}
+	for _, k := range []string{"TODO", "SEQ_TODO", "TYP_TODO"} {
+		if v, ok := d.BufferSettings[k]; ok {
+			kind := "sequence"
+			if k == "TYP_TODO" {
+				kind = "type"
+			}
+			for _, line := range strings.Split(v, "\n") {
+				if s, ok := parseTodoSequence(kind, line); ok {
+					sequences = append(sequences, s)
+				}
+			}
+		}
+	}
+	if len(sequences) == 0 {
+		if s, ok := parseTodoSequence("sequence", d.Get("TODO")); ok {
+			sequences = append(sequences, s)
+		}
+	}
+	d.todoSettings, d.todoSequences = key, sequences
+	return sequences
+}
+
+// TodoKeyword returns the keyword with the given name and whether it is a done keyword.
+func (d *Document) TodoKeyword(name string) (keyword TodoKeyword, isDone bool, ok bool) {
+	for _, s := range d.TodoSequences() {
+		for _, k := range s.Active {
+			if k.Name == name {
+				return k, false, true
+			}
+		}
+		for _, k := range s.Done {
+			if k.Name == name {
+				return k, true, true
+			}
+		}
+	}
+	return TodoKeyword{}, false, false
+}
+
+// Keywords returns all keywords of the sequence - active keywords first.
+func (s TodoSequence) Keywords() []TodoKeyword {
+	return append(append([]TodoKeyword{}, s.Active...), s.Done...)
+}
+
+// IsDone returns true if the TODO status of the headline is a done keyword of the document (e.g. DONE).
+// Done-ness depends on the TODO sequences of the document - so it is not a property of the headline itself.
+func (d *Document) IsDone(h Headline) bool {
+	_, isDone, _ := d.TodoKeyword(h.Status)
+	return isDone
+}
+
+func parseTodoSequence(kind, line string) (TodoSequence, bool) {
+	s, fields, done := TodoSequence{Type: kind}, strings.Fields(line), false
+	for _, field := range fields {
+		if field == "|" {
+			done = true
+			continue
+		}
+		m := todoKeywordRegexp.FindStringSubmatch(field)
+		k := TodoKeyword{m[1], m[2], m[3]}
+		if done {
+			s.Done = append(s.Done, k)
+		} else {
+			s.Active = append(s.Active, k)
+		}
+	}
+	if !done && len(s.Active) != 0 {
+		s.Active, s.Done = s.Active[:len(s.Active)-1], s.Active[len(s.Active)-1:]
+	}
+	return s, len(s.Active)+len(s.Done) != 0
+}
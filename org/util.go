@@ -1,5 +1,11 @@
 package org
 
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
 func isSecondBlankLine(d *Document, i int) bool {
 	if i-1 <= 0 {
 		return false
@@ -17,3 +23,79 @@ func isImageOrVideoLink(n Node) bool {
 	}
 	return false
 }
+
+// collectFootnoteDefinitions adds the footnote definitions in nodes (and the headlines & drawers in nodes) to definitions.
+func collectFootnoteDefinitions(nodes []Node, definitions map[string]*FootnoteDefinition) {
+	for _, n := range nodes {
+		switch n := n.(type) {
+		case FootnoteDefinition:
+			definition := n
+			definitions[n.Name] = &definition
+		case Headline:
+			collectFootnoteDefinitions(n.Children, definitions)
+		case Drawer:
+			collectFootnoteDefinitions(n.Children, definitions)
+		case NodeWithMeta:
+			collectFootnoteDefinitions([]Node{n.Node}, definitions)
+		case NodeWithName:
+			collectFootnoteDefinitions([]Node{n.Node}, definitions)
+		}
+	}
+}
+
+// nestSections nests the nodes following a headline (up to the next headline of the same or a lower level)
+// into its children and numbers the headlines in document order.
+func nestSections(nodes []Node, index *int) []Node {
+	out := []Node{}
+	for i := 0; i < len(nodes); i++ {
+		h, ok := nodes[i].(Headline)
+		if !ok {
+			out = append(out, nodes[i])
+			continue
+		}
+		j := i + 1
+		for ; j < len(nodes); j++ {
+			if next, ok := nodes[j].(Headline); ok && next.Lvl <= h.Lvl {
+				break
+			}
+		}
+		children := nodes[i+1 : j]
+		for len(children) != 0 {
+			if p, ok := children[0].(Paragraph); !ok || len(p.Children) != 0 {
+				break
+			}
+			children = children[1:]
+		}
+		*index++
+		h.Index = *index
+		h.Children = nestSections(children, index)
+		out, i = append(out, h), j-1
+	}
+	return out
+}
+
+var linkProtocolRegexp = regexp.MustCompile(`^([a-zA-Z][a-zA-Z0-9+.-]+):`)
+
+// orgLinkURL returns the protocol and the org link url for the url of a link in a foreign (e.g. markdown) document.
+// Relative links are turned into file links - and links to files with one of the given extensions into
+// links to the org file of the same name.
+func orgLinkURL(url string, extensions ...string) (string, string) {
+	if m := linkProtocolRegexp.FindStringSubmatch(url); m != nil {
+		return m[1], url
+	} else if url == "" || strings.HasPrefix(url, "#") {
+		return "", url
+	}
+	file, fragment := url, ""
+	if i := strings.Index(url, "#"); i != -1 {
+		file, fragment = url[:i], url[i+1:]
+	}
+	for _, ext := range extensions {
+		if path.Ext(file) == ext {
+			file = strings.TrimSuffix(file, ext) + ".org"
+		}
+	}
+	if url = "file:" + file; fragment != "" {
+		url += "::#" + fragment
+	}
+	return "file", url
+}
@@ -0,0 +1,236 @@
+package org
+
+import "errors"
+
+// Path contains the ancestors of a node visited by Walk or Transform - from the outermost ancestor to the parent of the node.
+type Path []Node
+
+// WalkFunc is called by Walk for each node with the path of its ancestors.
+// Returning SkipChildren skips the children of the node - any other error stops the walk and is returned by Walk.
+type WalkFunc func(n Node, path Path) error
+
+// TransformFunc is called by Transform for each node with the path of its (untransformed) ancestors.
+// The node is replaced by the returned nodes - i.e. returning nil deletes it and returning []Node{n} keeps it.
+type TransformFunc func(n Node, path Path) []Node
+
+// SkipChildren can be returned by a WalkFunc to skip the children of the current node.
+var SkipChildren = errors.New("skip children")
+
+// Parent returns the parent of the node the path belongs to - or nil for top level nodes.
+func (p Path) Parent() Node {
+	if len(p) == 0 {
+		return nil
+	}
+	return p[len(p)-1]
+}
+
+// Walk calls f for each node in nodes and their children (depth first, in document order).
+// Children are all nodes contained in a node - e.g. the title, planning, properties and children of a headline,
+// the items of a list, the column contents of a table, the caption of a NodeWithMeta or the result of a block.
+func Walk(nodes []Node, f WalkFunc) error {
+	return walk(nodes, Path{}, f)
+}
+
+// Inspect calls f for each node in nodes and their children (see Walk). The children of a node are skipped if f returns false.
+func Inspect(nodes []Node, f func(Node) bool) {
+	Walk(nodes, func(n Node, _ Path) error {
+		if !f(n) {
+			return SkipChildren
+		}
+		return nil
+	})
+}
+
+// Transform returns a copy of nodes with each node (and each of their children) replaced by the result of f.
+// The children of a node are transformed before the node itself - so f is called with the transformed children.
+// Nodes that contain a single node (e.g. Result, NodeWithMeta or NodeWithName) are deleted when that node is deleted
+// and only keep the first of multiple replacement nodes. nodes itself is not modified.
+func Transform(nodes []Node, f TransformFunc) []Node {
+	return transform(nodes, Path{}, f)
+}
+
+func walk(nodes []Node, path Path, f WalkFunc) error {
+	for _, n := range nodes {
+		if n == nil {
+			continue
+		}
+		if err := f(n, path); err == SkipChildren {
+			continue
+		} else if err != nil {
+			return err
+		}
+		var err error
+		mapChildren(n, func(children []Node) []Node {
+			if err == nil {
+				err = walk(children, append(path[:len(path):len(path)], n), f)
+			}
+			return children
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func transform(nodes []Node, path Path, f TransformFunc) []Node {
+	if len(nodes) == 0 {
+		return nodes
+	}
+	out := make([]Node, 0, len(nodes))
+	for _, n := range nodes {
+		if n == nil {
+			out = append(out, n)
+			continue
+		}
+		childPath := append(path[:len(path):len(path)], n)
+		transformed := mapChildren(n, func(children []Node) []Node { return transform(children, childPath, f) })
+		if isEmptyWrapper(transformed) && !isEmptyWrapper(n) {
+			continue
+		}
+		out = append(out, f(transformed, path)...)
+	}
+	return out
+}
+
+func isEmptyWrapper(n Node) bool {
+	switch n := n.(type) {
+	case Result:
+		return n.Node == nil
+	case NodeWithMeta:
+		return n.Node == nil
+	case NodeWithName:
+		return n.Node == nil
+	}
+	return false
+}
+
+// mapChildren returns a copy of n with each list of children replaced by the result of f.
+// Single children (e.g. Result.Node) are passed to f as a list of one node and replaced by the first node returned
+// by f (or nil) - children that must be of a specific type (e.g. Headline.Planning) are dropped if they are not.
+func mapChildren(n Node, f func([]Node) []Node) Node {
+	one := func(c Node) Node {
+		if c == nil {
+			return nil
+		} else if cs := f([]Node{c}); len(cs) != 0 {
+			return cs[0]
+		}
+		return nil
+	}
+	switch n := n.(type) {
+	case Headline:
+		n.Title = f(n.Title)
+		if n.Planning != nil {
+			p, ok := one(*n.Planning).(Planning)
+			if n.Planning = nil; ok {
+				n.Planning = &p
+			}
+		}
+		if n.Properties != nil {
+			p, ok := one(*n.Properties).(PropertyDrawer)
+			if n.Properties = nil; ok {
+				n.Properties = &p
+			}
+		}
+		n.Children = f(n.Children)
+		return n
+	case Planning:
+		entries := n.Entries[:0:0]
+		for _, e := range n.Entries {
+			if t, ok := one(e.Timestamp).(Timestamp); ok {
+				entries = append(entries, PlanningEntry{e.Keyword, t})
+			}
+		}
+		n.Entries = entries
+		return n
+	case Clock:
+		if t, ok := one(n.Timestamp).(Timestamp); ok {
+			n.Timestamp = t
+		}
+		return n
+	case Block:
+		n.Children = f(n.Children)
+		n.Result = one(n.Result)
+		return n
+	case DynamicBlock:
+		n.Children = f(n.Children)
+		return n
+	case Result:
+		n.Node = one(n.Node)
+		return n
+	case Example:
+		n.Children = f(n.Children)
+		return n
+	case Drawer:
+		n.Children = f(n.Children)
+		return n
+	case LogbookDrawer:
+		n.Children = f(n.Children)
+		return n
+	case FootnoteDefinition:
+		n.Children = f(n.Children)
+		return n
+	case NodeWithMeta:
+		if n.Meta.Caption != nil {
+			caption := make([][]Node, len(n.Meta.Caption))
+			for i, c := range n.Meta.Caption {
+				caption[i] = f(c)
+			}
+			n.Meta.Caption = caption
+		}
+		n.Node = one(n.Node)
+		return n
+	case NodeWithName:
+		n.Node = one(n.Node)
+		return n
+	case List:
+		n.Items = f(n.Items)
+		return n
+	case ListItem:
+		n.Children = f(n.Children)
+		return n
+	case DescriptiveListItem:
+		n.Term = f(n.Term)
+		n.Details = f(n.Details)
+		return n
+	case Table:
+		rows := append(n.Rows[:0:0], n.Rows...)
+		for i, r := range rows {
+			if r.Columns != nil {
+				columns := make([]Column, len(r.Columns))
+				for j, c := range r.Columns {
+					c.Children = f(c.Children)
+					columns[j] = c
+				}
+				r.Columns = columns
+			}
+			rows[i] = r
+		}
+		n.Rows = rows
+		return n
+	case Paragraph:
+		n.Children = f(n.Children)
+		return n
+	case Emphasis:
+		n.Content = f(n.Content)
+		return n
+	case InlineBlock:
+		n.Children = f(n.Children)
+		return n
+	case LatexFragment:
+		n.Content = f(n.Content)
+		return n
+	case RegularLink:
+		n.Description = f(n.Description)
+		return n
+	case FootnoteLink:
+		if n.Definition != nil {
+			d, ok := one(*n.Definition).(FootnoteDefinition)
+			if n.Definition = nil; ok {
+				n.Definition = &d
+			}
+		}
+		return n
+	}
+	return n
+}
@@ -0,0 +1,101 @@
+package org
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWalk(t *testing.T) {
+	input := "* headline *bold*\n- item [[https://example.com][link /desc/]]\n| a | b[fn:1:inline] |\n#+CAPTION: caption\n#+BEGIN_SRC go\nx\n#+END_SRC\n\n#+RESULTS:\n: result\n"
+	d := New().Silent().Parse(strings.NewReader(input), "./testdata/walk.org")
+	texts := []string{}
+	err := Walk(d.Nodes, func(n Node, path Path) error {
+		if text, ok := n.(Text); ok && strings.TrimSpace(text.Content) != "" {
+			kinds := []string{}
+			for _, p := range path {
+				kinds = append(kinds, strings.TrimPrefix(fmt.Sprintf("%T", p), "org."))
+			}
+			texts = append(texts, strings.TrimSpace(text.Content)+" "+strings.Join(kinds, "/"))
+		}
+		return nil
+	})
+	expected := []string{
+		"headline Headline",
+		"bold Headline/Emphasis",
+		"item Headline/List/ListItem/Paragraph",
+		"link Headline/List/ListItem/Paragraph/RegularLink",
+		"desc Headline/List/ListItem/Paragraph/RegularLink/Emphasis",
+		"a Headline/Table",
+		"b Headline/Table",
+		"inline Headline/Table/FootnoteLink/FootnoteDefinition/Paragraph",
+		"caption Headline/NodeWithMeta",
+		"x Headline/NodeWithMeta/Block",
+		"result Headline/NodeWithMeta/Block/Result/Example",
+	}
+	if err != nil || !reflect.DeepEqual(texts, expected) {
+		t.Errorf("got %v (%v), expected %v", texts, err, expected)
+	}
+
+	stop := errors.New("stop")
+	count := 0
+	if err := Walk(d.Nodes, func(n Node, path Path) error {
+		if count++; count == 3 {
+			return stop
+		}
+		return nil
+	}); err != stop || count != 3 {
+		t.Errorf("expected walk to stop after 3 nodes, got %d (%v)", count, err)
+	}
+
+	links := 0
+	Inspect(d.Nodes, func(n Node) bool {
+		if _, ok := n.(RegularLink); ok {
+			links++
+		}
+		_, isList := n.(List)
+		return !isList
+	})
+	if links != 0 {
+		t.Errorf("expected list children to be skipped, got %d links", links)
+	}
+}
+
+func TestTransform(t *testing.T) {
+	input := "* TODO headline *bold*\n- item /one/\n- item two\n#+NAME: named\n| *a* | b |\n"
+	d := New().Silent().Parse(strings.NewReader(input), "./testdata/transform.org")
+	original := String(d.Nodes)
+	nodes := Transform(d.Nodes, func(n Node, path Path) []Node {
+		switch n := n.(type) {
+		case Emphasis:
+			if _, ok := path.Parent().(Headline); ok {
+				return nil
+			}
+			return n.Content
+		case Text:
+			n.Content = strings.ToUpper(n.Content)
+			return []Node{n}
+		case ListItem:
+			return []Node{n, n}
+		case Table:
+			return nil
+		}
+		return []Node{n}
+	})
+	expected := "* TODO HEADLINE \n- ITEM ONE\n- ITEM ONE\n- ITEM TWO\n- ITEM TWO\n"
+	if actual := String(nodes); actual != expected {
+		t.Errorf("got:\n%s", diff(actual, expected))
+	}
+	if actual := String(d.Nodes); actual != original {
+		t.Errorf("expected original nodes to be unmodified:\n%s", diff(actual, original))
+	}
+	for _, path := range orgTestFiles() {
+		d := New().Silent().Parse(strings.NewReader(fileString(path)), path)
+		identity := Transform(d.Nodes, func(n Node, _ Path) []Node { return []Node{n} })
+		if NewJSONWriter().WriteNodesAsString(identity...) != NewJSONWriter().WriteNodesAsString(d.Nodes...) {
+			t.Errorf("%s: identity transform modified nodes", path)
+		}
+	}
+}
@@ -17,12 +17,16 @@ type Writer interface {
 	WriteNodeWithMeta(NodeWithMeta)
 	WriteNodeWithName(NodeWithName)
 	WriteHeadline(Headline)
+	WritePlanning(Planning)
 	WriteBlock(Block)
+	WriteDynamicBlock(DynamicBlock)
 	WriteResult(Result)
 	WriteInlineBlock(InlineBlock)
 	WriteExample(Example)
 	WriteDrawer(Drawer)
 	WritePropertyDrawer(PropertyDrawer)
+	WriteLogbookDrawer(LogbookDrawer)
+	WriteClock(Clock)
 	WriteList(List)
 	WriteListItem(ListItem)
 	WriteDescriptiveListItem(DescriptiveListItem)
@@ -36,6 +40,8 @@ type Writer interface {
 	WriteExplicitLineBreak(ExplicitLineBreak)
 	WriteLineBreak(LineBreak)
 	WriteRegularLink(RegularLink)
+	WriteTarget(Target)
+	WriteRadioTarget(RadioTarget)
 	WriteMacro(Macro)
 	WriteTimestamp(Timestamp)
 	WriteFootnoteLink(FootnoteLink)
@@ -58,8 +64,12 @@ func WriteNodes(w Writer, nodes ...Node) {
 			w.WriteNodeWithName(n)
 		case Headline:
 			w.WriteHeadline(n)
+		case Planning:
+			w.WritePlanning(n)
 		case Block:
 			w.WriteBlock(n)
+		case DynamicBlock:
+			w.WriteDynamicBlock(n)
 		case Result:
 			w.WriteResult(n)
 		case InlineBlock:
@@ -70,6 +80,10 @@ func WriteNodes(w Writer, nodes ...Node) {
 			w.WriteDrawer(n)
 		case PropertyDrawer:
 			w.WritePropertyDrawer(n)
+		case LogbookDrawer:
+			w.WriteLogbookDrawer(n)
+		case Clock:
+			w.WriteClock(n)
 		case List:
 			w.WriteList(n)
 		case ListItem:
@@ -96,6 +110,10 @@ func WriteNodes(w Writer, nodes ...Node) {
 			w.WriteLineBreak(n)
 		case RegularLink:
 			w.WriteRegularLink(n)
+		case Target:
+			w.WriteTarget(n)
+		case RadioTarget:
+			w.WriteRadioTarget(n)
 		case Macro:
 			w.WriteMacro(n)
 		case Timestamp: